@@ -15,7 +15,8 @@ type AchievementReference struct {
 	// Field Title (Tambahan Modul 6 Search/Sort) - Tetap di Postgres agar query cepat
 	Title              string     `gorm:"type:varchar(255);not null" json:"title"`
 	
-	// Enum (draft, submitted, verified, rejected)
+	// Enum (draft, submitted, verified, rejected, revoked), dijaga CHECK constraint di database.
+	// Perpindahan status lewat lifecycle di AchievementService
 	Status             string     `gorm:"type:varchar(20);default:'draft'" json:"status"`
 	
 	SubmittedAt        *time.Time `gorm:"column:submitted_at" json:"submittedAt"`
@@ -24,7 +25,26 @@ type AchievementReference struct {
 	VerifiedBy         *string    `gorm:"type:uuid;column:verified_by" json:"verifiedBy"`
 	Verifier           *User      `gorm:"foreignKey:VerifiedBy;references:ID" json:"verifier,omitempty"`
 	
+	// Dosen wali mahasiswa saat prestasi diverifikasi / ditolak (snapshot, tidak ikut berubah saat dosen wali diganti)
+	AdvisorID          *string    `gorm:"type:uuid;column:advisor_id" json:"advisorId"`
+	Advisor            *Lecturer  `gorm:"foreignKey:AdvisorID;references:ID" json:"advisor,omitempty"`
+	
 	RejectionNote      string     `gorm:"type:text;column:rejection_note" json:"rejectionNote"`
+	
+	// Verifikasi yang dicabut Admin (status revoked), alasannya ada di riwayat status
+	RevokedAt          *time.Time `gorm:"column:revoked_at" json:"revokedAt"`
+	RevokedBy          *string    `gorm:"type:uuid;column:revoked_by" json:"revokedBy"`
+	
+	Points             int        `gorm:"default:0;not null" json:"points"` // Poin dari dosen wali saat verifikasi
 	CreatedAt          time.Time  `gorm:"default:CURRENT_TIMESTAMP;column:created_at" json:"createdAt"`
 	UpdatedAt          time.Time  `gorm:"default:CURRENT_TIMESTAMP;column:updated_at" json:"updatedAt"`
-}
\ No newline at end of file
+}
+
+// Status prestasi
+const (
+	AchievementDraft     = "draft"
+	AchievementSubmitted = "submitted"
+	AchievementVerified  = "verified"
+	AchievementRejected  = "rejected"
+	AchievementRevoked   = "revoked"
+)
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// Tabel achievement_status_history (append-only)
+// Satu baris = satu perpindahan status prestasi, beserta pelaku, catatan & poin saat itu.
+// Baris tidak pernah diubah / dihapus, termasuk saat prestasi draft dihapus.
+type AchievementStatusHistory struct {
+	ID            string `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	AchievementID string `gorm:"type:uuid;not null;index;column:achievement_id" json:"achievementId"` // achievement_references.id
+	FromStatus    string `gorm:"type:varchar(20);column:from_status" json:"fromStatus"`               // Kosong = prestasi baru dibuat
+	ToStatus      string `gorm:"type:varchar(20);not null;column:to_status" json:"toStatus"`
+
+	ActorID        *string `gorm:"type:uuid;column:actor_id" json:"actorId"` // nil = sistem (migrasi)
+	Actor          *User   `gorm:"foreignKey:ActorID;references:ID" json:"actor,omitempty"`
+	ImpersonatorID *string `gorm:"type:uuid;column:impersonator_id" json:"impersonatorId,omitempty"` // Helpdesk yang sedang impersonate
+
+	Note      string    `gorm:"type:text" json:"note,omitempty"`
+	Points    *int      `gorm:"column:points" json:"points,omitempty"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP;index;column:created_at" json:"createdAt"`
+}
+
+func (AchievementStatusHistory) TableName() string {
+	return "achievement_status_history"
+}
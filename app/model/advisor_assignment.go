@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// Tabel advisor_assignments (riwayat dosen wali mahasiswa)
+// Satu baris = satu periode bimbingan. Periode yang masih berjalan punya EffectiveTo = nil.
+type AdvisorAssignment struct {
+	ID         string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	StudentID  string    `gorm:"type:uuid;not null;index;column:student_id" json:"studentId"`
+	LecturerID string    `gorm:"type:uuid;not null;index;column:lecturer_id" json:"lecturerId"`
+	Lecturer   *Lecturer `gorm:"foreignKey:LecturerID;references:ID" json:"lecturer,omitempty"`
+
+	EffectiveFrom time.Time  `gorm:"not null;column:effective_from" json:"effectiveFrom"`
+	EffectiveTo   *time.Time `gorm:"column:effective_to" json:"effectiveTo"`
+
+	AssignedBy *string `gorm:"type:uuid;column:assigned_by" json:"assignedBy"` // nil = sistem (import / migrasi)
+	Reason     string  `gorm:"type:varchar(255)" json:"reason,omitempty"`
+
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP;column:created_at" json:"createdAt"`
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// Tabel api_tokens
+// Personal access token untuk integrasi (script fakultas, dashboard kampus).
+// Token asli hanya ditampilkan sekali saat dibuat, yang disimpan hanya hash-nya.
+type APIToken struct {
+	ID     string `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID string `gorm:"type:uuid;not null;index;column:user_id" json:"userId"`
+	User   User   `gorm:"foreignKey:UserID;references:ID" json:"-"`
+
+	Name      string `gorm:"not null;type:varchar(100)" json:"name"`
+	Prefix    string `gorm:"not null;type:varchar(16)" json:"prefix"` // Potongan awal token, untuk dikenali user
+	TokenHash string `gorm:"unique;not null;type:varchar(64);column:token_hash" json:"-"`
+
+	// Subset permission pemilik token (boleh wildcard, misal "achievement:*")
+	Scopes []string `gorm:"serializer:json;type:jsonb;not null" json:"scopes"`
+
+	ExpiresAt  time.Time  `gorm:"not null;column:expires_at" json:"expiresAt"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at" json:"revokedAt"`
+	LastUsedAt *time.Time `gorm:"column:last_used_at" json:"lastUsedAt"`
+	LastUsedIP string     `gorm:"type:varchar(45);column:last_used_ip" json:"lastUsedIp"`
+
+	CreatedBy string    `gorm:"type:uuid;column:created_by" json:"createdBy"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP;column:created_at" json:"createdAt"`
+}
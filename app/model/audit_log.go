@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// Tabel audit_logs (append-only)
+// Mencatat aksi sensitif: impersonation (beserta setiap request selama impersonate),
+// perubahan role/permission, dll. Satu-satunya update adalah status_code request impersonation,
+// yang baru diketahui setelah handler selesai.
+type AuditLog struct {
+	ID       string  `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	ActorID  string  `gorm:"type:uuid;not null;index;column:actor_id" json:"actorId"`         // User yang sebenarnya melakukan aksi
+	OnBehalf *string `gorm:"type:uuid;index;column:on_behalf_of" json:"onBehalfOf,omitempty"` // User yang di-impersonate (jika ada)
+
+	Action     string `gorm:"type:varchar(100);not null;index" json:"action"`
+	TargetType string `gorm:"type:varchar(50);column:target_type" json:"targetType,omitempty"`
+	TargetID   string `gorm:"type:varchar(100);column:target_id" json:"targetId,omitempty"`
+
+	Method     string `gorm:"type:varchar(10)" json:"method,omitempty"`
+	Path       string `gorm:"type:varchar(255)" json:"path,omitempty"` // Isi lewat AuditPath (dipotong)
+	StatusCode int    `gorm:"column:status_code" json:"statusCode,omitempty"`
+	IPAddress  string `gorm:"type:varchar(45);column:ip_address" json:"ipAddress,omitempty"`
+
+	Metadata  map[string]interface{} `gorm:"serializer:json;type:jsonb" json:"metadata,omitempty"`
+	CreatedAt time.Time              `gorm:"default:CURRENT_TIMESTAMP;index;column:created_at" json:"createdAt"`
+}
+
+// Panjang maksimal kolom audit_logs.path
+const AuditPathMaxLength = 255
+
+// AuditPath memotong URL (beserta query string) agar muat di kolom path.
+// Dipotong per karakter, bukan per byte, supaya UTF-8 tetap valid.
+func AuditPath(url string) string {
+	runes := []rune(url)
+	if len(runes) <= AuditPathMaxLength {
+		return url
+	}
+	return string(runes[:AuditPathMaxLength])
+}
@@ -1,6 +1,10 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // Tabel lecturers
 type Lecturer struct {
@@ -11,5 +15,8 @@ type Lecturer struct {
 	
 	LecturerID string    `gorm:"unique;not null;type:varchar(20);column:lecturer_id" json:"lecturerId"` // NIP
 	Department string    `gorm:"type:varchar(100)" json:"department"`
+	DepartmentID  *string     `gorm:"type:uuid;index;column:department_id" json:"departmentId"` // Referensi master data
+	DepartmentRef *Department `gorm:"foreignKey:DepartmentID;references:ID" json:"departmentRef,omitempty"`
 	CreatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP;column:created_at" json:"createdAt"`
+	DeletedAt  gorm.DeletedAt `gorm:"index;column:deleted_at" json:"deletedAt,omitempty"`
 }
\ No newline at end of file
@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// Tabel login_attempts
+// Catatan setiap percobaan login (berhasil/gagal), dipakai untuk throttling per IP
+type LoginAttempt struct {
+	ID        string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID    *string   `gorm:"type:uuid;column:user_id" json:"userId"` // NULL jika email tidak terdaftar
+	Email     string    `gorm:"type:varchar(100)" json:"email"`
+	IPAddress string    `gorm:"type:varchar(45);index:idx_login_attempts_ip_time,priority:1;column:ip_address" json:"ipAddress"`
+	Success   bool      `gorm:"not null" json:"success"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP;index:idx_login_attempts_ip_time,priority:2;column:created_at" json:"createdAt"`
+}
@@ -0,0 +1,88 @@
+package model
+
+import "time"
+
+// --- MASTER DATA AKADEMIK ---
+// Fakultas -> Jurusan (department) -> Program Studi, serta Tahun Akademik -> Semester.
+// Mahasiswa & dosen merujuk ke tabel ini, kolom teks lama (program_study, academic_year, department)
+// tetap diisi nama resmi-nya agar data lama & response lama tetap terbaca.
+
+// Tabel faculties
+type Faculty struct {
+	ID        string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	Code      string    `gorm:"unique;not null;type:varchar(20)" json:"code"`
+	Name      string    `gorm:"unique;not null;type:varchar(100)" json:"name"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP;column:created_at" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP;column:updated_at" json:"updatedAt"`
+}
+
+// Tabel departments (jurusan), bagian dari satu fakultas
+type Department struct {
+	ID        string   `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	FacultyID string   `gorm:"type:uuid;not null;index;column:faculty_id" json:"facultyId"`
+	Faculty   *Faculty `gorm:"foreignKey:FacultyID;references:ID" json:"faculty,omitempty"`
+
+	Code      string    `gorm:"unique;not null;type:varchar(20)" json:"code"`
+	Name      string    `gorm:"not null;type:varchar(100)" json:"name"`
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP;column:created_at" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP;column:updated_at" json:"updatedAt"`
+}
+
+// Tabel program_studies, bagian dari satu jurusan
+type ProgramStudy struct {
+	ID           string      `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	DepartmentID string      `gorm:"type:uuid;not null;index;column:department_id" json:"departmentId"`
+	Department   *Department `gorm:"foreignKey:DepartmentID;references:ID" json:"department,omitempty"`
+
+	Code      string    `gorm:"unique;not null;type:varchar(20)" json:"code"`
+	Name      string    `gorm:"not null;type:varchar(100)" json:"name"`
+	Degree    string    `gorm:"type:varchar(10)" json:"degree"` // Jenjang, mis. D3, S1, S2
+	CreatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP;column:created_at" json:"createdAt"`
+	UpdatedAt time.Time `gorm:"default:CURRENT_TIMESTAMP;column:updated_at" json:"updatedAt"`
+}
+
+// Tabel academic_years (tahun akademik, mis. "2024/2025")
+type AcademicYear struct {
+	ID        string     `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	Name      string     `gorm:"unique;not null;type:varchar(10)" json:"name"`
+	StartDate time.Time  `gorm:"type:date;not null;column:start_date" json:"startDate"`
+	EndDate   time.Time  `gorm:"type:date;not null;column:end_date" json:"endDate"`
+	Semesters []Semester `gorm:"foreignKey:AcademicYearID" json:"semesters,omitempty"`
+	CreatedAt time.Time  `gorm:"default:CURRENT_TIMESTAMP;column:created_at" json:"createdAt"`
+	UpdatedAt time.Time  `gorm:"default:CURRENT_TIMESTAMP;column:updated_at" json:"updatedAt"`
+}
+
+// Tabel semesters, bagian dari satu tahun akademik
+type Semester struct {
+	ID             string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	AcademicYearID string    `gorm:"type:uuid;not null;uniqueIndex:idx_semester_term;column:academic_year_id" json:"academicYearId"`
+	Term           string    `gorm:"type:varchar(10);not null;uniqueIndex:idx_semester_term" json:"term"` // ganjil | genap | pendek
+	StartDate      time.Time `gorm:"type:date;not null;column:start_date" json:"startDate"`
+	EndDate        time.Time `gorm:"type:date;not null;column:end_date" json:"endDate"`
+	CreatedAt      time.Time `gorm:"default:CURRENT_TIMESTAMP;column:created_at" json:"createdAt"`
+	UpdatedAt      time.Time `gorm:"default:CURRENT_TIMESTAMP;column:updated_at" json:"updatedAt"`
+}
+
+// Term semester yang valid
+const (
+	SemesterGanjil = "ganjil"
+	SemesterGenap  = "genap"
+	SemesterPendek = "pendek"
+)
+
+// Tabel master_aliases: penulisan lain dari data master, mis. "T. Informatika" -> prodi Teknik Informatika.
+// Dipakai saat memetakan teks bebas lama & saat import / input teks.
+type MasterAlias struct {
+	ID         string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	EntityType string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_master_alias;column:entity_type" json:"entityType"`
+	Alias      string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_master_alias" json:"alias"` // Sudah dinormalisasi (utils.NormalizeLabel)
+	TargetID   string    `gorm:"type:uuid;not null;index;column:target_id" json:"targetId"`
+	CreatedAt  time.Time `gorm:"default:CURRENT_TIMESTAMP;column:created_at" json:"createdAt"`
+}
+
+// Jenis data master yang bisa diberi alias
+const (
+	MasterDepartment   = "department"
+	MasterProgramStudy = "program_study"
+	MasterAcademicYear = "academic_year"
+)
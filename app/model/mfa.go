@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+// Tabel user_mfa
+// Secret TOTP milik user. Enabled = false berarti enrollment belum dikonfirmasi.
+type UserMFA struct {
+	UserID       string     `gorm:"primaryKey;type:uuid;column:user_id" json:"userId"`
+	Secret       string     `gorm:"not null;type:varchar(64)" json:"-"`
+	Enabled      bool       `gorm:"default:false;not null" json:"enabled"`
+	ConfirmedAt  *time.Time `gorm:"column:confirmed_at" json:"confirmedAt"`
+	LastUsedStep int64      `gorm:"default:0;not null;column:last_used_step" json:"-"` // Anti replay kode TOTP
+	CreatedAt    time.Time  `gorm:"default:CURRENT_TIMESTAMP;column:created_at" json:"createdAt"`
+	UpdatedAt    time.Time  `gorm:"default:CURRENT_TIMESTAMP;column:updated_at" json:"updatedAt"`
+}
+
+func (UserMFA) TableName() string {
+	return "user_mfa"
+}
+
+// Tabel mfa_recovery_codes (hash, sekali pakai)
+type MFARecoveryCode struct {
+	ID        string     `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID    string     `gorm:"type:uuid;not null;index;column:user_id" json:"userId"`
+	CodeHash  string     `gorm:"not null;type:varchar(64);column:code_hash" json:"-"`
+	UsedAt    *time.Time `gorm:"column:used_at" json:"usedAt"`
+	CreatedAt time.Time  `gorm:"default:CURRENT_TIMESTAMP;column:created_at" json:"createdAt"`
+}
+
+func (MFARecoveryCode) TableName() string {
+	return "mfa_recovery_codes"
+}
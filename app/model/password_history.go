@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// Tabel password_histories
+// Hash password lama user, dipakai policy untuk menolak pemakaian ulang N password terakhir
+type PasswordHistory struct {
+	ID           string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID       string    `gorm:"type:uuid;not null;index;column:user_id" json:"userId"`
+	PasswordHash string    `gorm:"not null;type:varchar(255);column:password_hash" json:"-"`
+	CreatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP;column:created_at" json:"createdAt"`
+}
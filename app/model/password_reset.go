@@ -0,0 +1,14 @@
+package model
+
+import "time"
+
+// Tabel password_reset_tokens
+// Token reset hanya bisa dipakai sekali (UsedAt) dan memiliki masa berlaku (ExpiresAt)
+type PasswordResetToken struct {
+	ID        string     `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID    string     `gorm:"type:uuid;not null;index;column:user_id" json:"userId"`
+	TokenHash string     `gorm:"unique;not null;type:varchar(64);column:token_hash" json:"-"`
+	ExpiresAt time.Time  `gorm:"not null;column:expires_at" json:"expiresAt"`
+	UsedAt    *time.Time `gorm:"column:used_at" json:"usedAt"`
+	CreatedAt time.Time  `gorm:"default:CURRENT_TIMESTAMP;column:created_at" json:"createdAt"`
+}
@@ -9,6 +9,15 @@ type Permission struct {
 	Description string `gorm:"type:text" json:"description"`
 }
 
+// Key mengembalikan permission dalam format "resource:action" berdasarkan kolom Resource & Action.
+// Fallback ke Name jika salah satu kolom kosong (data lama).
+func (p Permission) Key() string {
+	if p.Resource == "" || p.Action == "" {
+		return p.Name
+	}
+	return p.Resource + ":" + p.Action
+}
+
 // Tabel role_permissions
 type RolePermission struct {
 	RoleID       string     `gorm:"primaryKey;type:uuid;column:role_id" json:"roleId"`
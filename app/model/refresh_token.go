@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// Tabel refresh_tokens
+// Token asli hanya dikirim ke client, di database yang disimpan hanya hash-nya (SHA-256).
+// Semua token hasil rotasi dari satu login berbagi FamilyID yang sama.
+type RefreshToken struct {
+	ID         string     `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
+	UserID     string     `gorm:"type:uuid;not null;index;column:user_id" json:"userId"`
+	FamilyID   string     `gorm:"type:uuid;not null;index;column:family_id" json:"familyId"`
+	TokenHash  string     `gorm:"unique;not null;type:varchar(64);column:token_hash" json:"-"`
+	ExpiresAt  time.Time  `gorm:"not null;column:expires_at" json:"expiresAt"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at" json:"revokedAt"`
+	ReplacedBy *string    `gorm:"type:uuid;column:replaced_by" json:"replacedBy"`
+	CreatedAt  time.Time  `gorm:"default:CURRENT_TIMESTAMP;column:created_at" json:"createdAt"`
+}
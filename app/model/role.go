@@ -7,5 +7,16 @@ type Role struct {
 	ID          string    `gorm:"primaryKey;type:uuid;default:gen_random_uuid()" json:"id"`
 	Name        string    `gorm:"unique;not null;type:varchar(50)" json:"name"`
 	Description string    `gorm:"type:text" json:"description"`
+	MFARequired bool      `gorm:"default:false;not null;column:mfa_required" json:"mfaRequired"` // Wajib 2FA (TOTP) untuk role ini
+	
+	// Naik setiap kali role_permissions role ini berubah (trigger DB), token dengan versi lama dianggap basi
+	PermissionVersion int `gorm:"default:1;not null;column:permission_version" json:"permissionVersion"`
 	CreatedAt   time.Time `gorm:"default:CURRENT_TIMESTAMP;column:created_at" json:"createdAt"`
-}
\ No newline at end of file
+}
+
+// Nama role bawaan sistem (SRS)
+const (
+	RoleAdmin     = "Admin"
+	RoleMahasiswa = "Mahasiswa"
+	RoleDosenWali = "Dosen Wali"
+)
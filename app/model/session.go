@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+// Tabel user_sessions
+// Satu baris per login (perangkat). ID sama dengan FamilyID refresh token milik login tersebut,
+// dan dibawa di access token sebagai claim "sid".
+type Session struct {
+	ID         string     `gorm:"primaryKey;type:uuid" json:"id"`
+	UserID     string     `gorm:"type:uuid;not null;index;column:user_id" json:"userId"`
+	UserAgent  string     `gorm:"type:varchar(255);column:user_agent" json:"userAgent"`
+	IPAddress  string     `gorm:"type:varchar(45);column:ip_address" json:"ipAddress"`
+	CreatedAt  time.Time  `gorm:"default:CURRENT_TIMESTAMP;column:created_at" json:"createdAt"`
+	LastSeenAt time.Time  `gorm:"column:last_seen_at" json:"lastSeenAt"`
+	RevokedAt  *time.Time `gorm:"column:revoked_at" json:"revokedAt,omitempty"`
+}
+
+func (Session) TableName() string {
+	return "user_sessions"
+}
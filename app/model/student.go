@@ -1,6 +1,10 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // Tabel students
 type Student struct {
@@ -15,8 +19,15 @@ type Student struct {
 	ProgramStudy string    `gorm:"type:varchar(100);column:program_study" json:"programStudy"`
 	AcademicYear string    `gorm:"type:varchar(10);column:academic_year" json:"academicYear"`
 	
+	// Referensi master data (kolom teks di atas berisi nama resmi-nya)
+	ProgramStudyID  *string       `gorm:"type:uuid;index;column:program_study_id" json:"programStudyId"`
+	ProgramStudyRef *ProgramStudy `gorm:"foreignKey:ProgramStudyID;references:ID" json:"programStudyRef,omitempty"`
+	AcademicYearID  *string       `gorm:"type:uuid;index;column:academic_year_id" json:"academicYearId"`
+	AcademicYearRef *AcademicYear `gorm:"foreignKey:AcademicYearID;references:ID" json:"academicYearRef,omitempty"`
+	
 	AdvisorID    *string   `gorm:"type:uuid;column:advisor_id" json:"advisorId"`
 	Advisor      *Lecturer `gorm:"foreignKey:AdvisorID;references:ID" json:"advisor,omitempty"`
 	
 	CreatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP;column:created_at" json:"createdAt"`
+	DeletedAt    gorm.DeletedAt `gorm:"index;column:deleted_at" json:"deletedAt,omitempty"`
 }
\ No newline at end of file
@@ -0,0 +1,21 @@
+package model
+
+import "time"
+
+// Tabel revoked_tokens
+// Daftar access token (berdasarkan claim jti) yang sudah di-logout sebelum masa berlakunya habis.
+// Baris boleh dibersihkan setelah ExpiresAt lewat karena token-nya sudah tidak valid.
+type RevokedToken struct {
+	JTI       string    `gorm:"primaryKey;type:varchar(64);column:jti" json:"jti"`
+	UserID    string    `gorm:"type:uuid;not null;index;column:user_id" json:"userId"`
+	ExpiresAt time.Time `gorm:"not null;index;column:expires_at" json:"expiresAt"`
+	RevokedAt time.Time `gorm:"default:CURRENT_TIMESTAMP;column:revoked_at" json:"revokedAt"`
+}
+
+// Tabel user_token_cutoffs
+// "Logout everywhere": semua token milik user yang diterbitkan sebelum RevokedBefore ditolak.
+type UserTokenCutoff struct {
+	UserID        string    `gorm:"primaryKey;type:uuid;column:user_id" json:"userId"`
+	RevokedBefore time.Time `gorm:"not null;column:revoked_before" json:"revokedBefore"`
+	UpdatedAt     time.Time `gorm:"default:CURRENT_TIMESTAMP;column:updated_at" json:"updatedAt"`
+}
@@ -1,6 +1,10 @@
 package model
 
-import "time"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
 
 // Tabel users
 type User struct {
@@ -14,6 +18,21 @@ type User struct {
 	Role         Role      `gorm:"foreignKey:RoleID;references:ID" json:"role,omitempty"`
 	
 	IsActive     bool      `gorm:"default:true;column:is_active" json:"isActive"`
+	
+	// Akun non-manusia untuk integrasi, hanya bisa memakai API token (tidak bisa login password)
+	IsServiceAccount bool `gorm:"default:false;not null;column:is_service_account" json:"isServiceAccount"`
+	
+	// Naik setiap kali role user diganti (trigger DB), token dengan versi lama dianggap basi
+	PermissionVersion int `gorm:"default:1;not null;column:permission_version" json:"permissionVersion"`
+	
+	// Proteksi brute-force login
+	FailedLoginAttempts int        `gorm:"default:0;not null;column:failed_login_attempts" json:"failedLoginAttempts"`
+	LastFailedLoginAt   *time.Time `gorm:"column:last_failed_login_at" json:"lastFailedLoginAt"`
+	LockedUntil         *time.Time `gorm:"column:locked_until" json:"lockedUntil"`
+	
 	CreatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP;column:created_at" json:"createdAt"`
 	UpdatedAt    time.Time `gorm:"default:CURRENT_TIMESTAMP;column:updated_at" json:"updatedAt"`
+
+	// Soft delete: user yang dihapus masuk trash & bisa di-restore (prestasinya tetap utuh)
+	DeletedAt    gorm.DeletedAt `gorm:"index;column:deleted_at" json:"deletedAt,omitempty"`
 }
\ No newline at end of file
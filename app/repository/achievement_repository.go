@@ -27,7 +27,7 @@ func NewAchievementRepository(pg *gorm.DB, mongoDB *mongo.Database) *Achievement
 
 // --- CREATE (HYBRID TRANSACTION) ---
 
-func (r *AchievementRepository) Create(ctx context.Context, content *model.Achievement, ref *model.AchievementReference) error {
+func (r *AchievementRepository) Create(ctx context.Context, content *model.Achievement, ref *model.AchievementReference, history *model.AchievementStatusHistory) error {
 	// 1. Set Timestamp
 	now := time.Now()
 	content.CreatedAt = now
@@ -45,8 +45,14 @@ func (r *AchievementRepository) Create(ctx context.Context, content *model.Achie
 	oid, _ := res.InsertedID.(primitive.ObjectID)
 	ref.MongoAchievementID = oid.Hex()
 
-	// 4. Insert ke PostgreSQL
-	if err := r.pgDB.Create(ref).Error; err != nil {
+	// 4. Insert ke PostgreSQL (reference + riwayat status awal dalam satu transaksi)
+	err = r.pgDB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(ref).Error; err != nil {
+			return err
+		}
+		return recordStatusChange(tx, ref.ID, "", ref.Status, history, now)
+	})
+	if err != nil {
 		// KOMPENSASI (ROLLBACK MANUAL):
 		// Jika simpan ke Postgres gagal, hapus data sampah di Mongo
 		_, _ = r.mongoColl.DeleteOne(ctx, bson.M{"_id": oid})
@@ -115,7 +121,7 @@ func (r *AchievementRepository) FindAll(param model.PaginationParam, studentID s
 func (r *AchievementRepository) FindDetail(ctx context.Context, id string) (*model.AchievementReference, *model.Achievement, error) {
 	// 1. Ambil data Metadata dari Postgres
 	var ref model.AchievementReference
-	if err := r.pgDB.Preload("Student.User").Preload("Verifier").First(&ref, "id = ?", id).Error; err != nil {
+	if err := r.pgDB.Preload("Student.User").Preload("Verifier").Preload("Advisor.User").First(&ref, "id = ?", id).Error; err != nil {
 		return nil, nil, err
 	}
 
@@ -135,26 +141,134 @@ func (r *AchievementRepository) FindDetail(ctx context.Context, id string) (*mod
 	return &ref, &content, nil
 }
 
-// --- UPDATE STATUS (VERIFIKASI DOSEN) ---
+// --- UPDATE CONTENT (HYBRID) ---
+
+// ErrStatusConflict: status prestasi sudah berubah (mis. diajukan / diverifikasi di request lain)
+// sejak dibaca, sehingga perubahan dibatalkan
+var ErrStatusConflict = errors.New("achievement status has changed")
 
-func (r *AchievementRepository) UpdateStatus(id string, status string, verifiedBy string, note string, points int) error {
-	updates := map[string]interface{}{
-		"status":      status,
-		"updated_at":  time.Now(),
-		"verified_by": verifiedBy,
-		"verified_at": time.Now(),
+// StatusChange adalah satu perpindahan status yang sudah divalidasi lifecycle di AchievementService
+type StatusChange struct {
+	From    string
+	To      string
+	Updates map[string]interface{} // Kolom yang diisi / dikosongkan transisi (timestamp, verifikator, dll)
+	History *model.AchievementStatusHistory
+}
+
+// FindReference: metadata prestasi di Postgres saja (tanpa isi dari Mongo)
+func (r *AchievementRepository) FindReference(id string) (*model.AchievementReference, error) {
+	var ref model.AchievementReference
+	err := r.pgDB.Preload("Student.User").First(&ref, "id = ?", id).Error
+	return &ref, err
+}
+
+// UpdateContent mengganti isi prestasi di Mongo & judul di Postgres, hanya jika status belum berubah sejak dibaca.
+// change != nil jika update sekaligus memindahkan status (rejected -> draft).
+// Poin, pemilik & waktu dibuat tidak bisa diubah lewat update ini.
+func (r *AchievementRepository) UpdateContent(ctx context.Context, ref *model.AchievementReference, content *model.Achievement, change *StatusChange) error {
+	now := time.Now()
+	objID, err := primitive.ObjectIDFromHex(ref.MongoAchievementID)
+	if err != nil {
+		return errors.New("invalid mongo id format")
 	}
-	
-	if note != "" {
-		updates["rejection_note"] = note
+
+	// 1. Simpan dokumen lama untuk kompensasi
+	var previous model.Achievement
+	if err := r.mongoColl.FindOne(ctx, bson.M{"_id": objID}).Decode(&previous); err != nil {
+		return errors.New("detail data not found in mongo")
 	}
-	
-	// Jika verified, tambahkan poin
-	if status == "verified" && points > 0 {
-		updates["points"] = points
+
+	// 2. Update MongoDB
+	content.ID = objID
+	content.StudentID = previous.StudentID
+	content.Points = previous.Points
+	content.CreatedAt = previous.CreatedAt
+	content.UpdatedAt = now
+	if _, err := r.mongoColl.ReplaceOne(ctx, bson.M{"_id": objID}, content); err != nil {
+		return err
 	}
 
-	return r.pgDB.Model(&model.AchievementReference{}).Where("id = ?", id).Updates(updates).Error
+	// 3. Update PostgreSQL (+ perpindahan status & riwayatnya) dalam satu transaksi
+	err = r.pgDB.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{"title": content.Title, "updated_at": now}
+		if change != nil {
+			for column, value := range change.Updates {
+				updates[column] = value
+			}
+			updates["status"] = change.To
+		}
+
+		result := tx.Model(&model.AchievementReference{}).Where("id = ? AND status = ?", ref.ID, ref.Status).Updates(updates)
+		if result.Error != nil {
+			return errors.New("failed to update reference in postgres: " + result.Error.Error())
+		}
+		if result.RowsAffected == 0 {
+			return ErrStatusConflict
+		}
+		if change == nil {
+			return nil
+		}
+		return recordStatusChange(tx, ref.ID, change.From, change.To, change.History, now)
+	})
+	if err != nil {
+		// KOMPENSASI: kembalikan dokumen Mongo seperti semula
+		_, _ = r.mongoColl.ReplaceOne(ctx, bson.M{"_id": objID}, previous)
+		return err
+	}
+
+	return nil
+}
+
+// --- TRANSISI STATUS (LIFECYCLE) ---
+
+// TransitionStatus memindahkan status secara kondisional (WHERE status = change.From) dan mencatat riwayatnya
+// dalam satu transaksi. Return ErrStatusConflict jika status sudah bukan change.From.
+func (r *AchievementRepository) TransitionStatus(id string, change StatusChange) error {
+	return r.pgDB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		updates := map[string]interface{}{"status": change.To, "updated_at": now}
+		for column, value := range change.Updates {
+			updates[column] = value
+		}
+
+		result := tx.Model(&model.AchievementReference{}).Where("id = ? AND status = ?", id, change.From).Updates(updates)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrStatusConflict
+		}
+		return recordStatusChange(tx, id, change.From, change.To, change.History, now)
+	})
+}
+
+// --- RIWAYAT STATUS ---
+
+// FindStatusHistory: riwayat status prestasi, urut dari yang paling lama
+func (r *AchievementRepository) FindStatusHistory(achievementID string) ([]model.AchievementStatusHistory, error) {
+	var history []model.AchievementStatusHistory
+	err := r.pgDB.
+		Preload("Actor", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("achievement_id = ?", achievementID).
+		Order("created_at ASC").
+		Find(&history).Error
+	return history, err
+}
+
+// recordStatusChange menambahkan satu baris riwayat status (append-only) dalam transaksi yang sama
+func recordStatusChange(tx *gorm.DB, achievementID string, from string, to string, history *model.AchievementStatusHistory, at time.Time) error {
+	entry := model.AchievementStatusHistory{}
+	if history != nil {
+		entry = *history
+	}
+	entry.ID = ""
+	entry.AchievementID = achievementID
+	entry.FromStatus = from
+	entry.ToStatus = to
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = at
+	}
+	return tx.Omit("Actor").Create(&entry).Error
 }
 
 // --- DELETE (SOFT DELETE / HARD DELETE) ---
@@ -166,14 +280,13 @@ func (r *AchievementRepository) Delete(ctx context.Context, id string) error {
 		return err
 	}
 
-	// Cek status: Hanya boleh hapus jika Draft
-	if ref.Status != "draft" {
-		return errors.New("cannot delete submitted or verified achievement")
+	// 2. Hapus dari Postgres, hanya jika masih Draft (kondisional, cegah balapan dengan submit)
+	result := r.pgDB.Where("status = ?", model.AchievementDraft).Delete(&ref)
+	if result.Error != nil {
+		return result.Error
 	}
-
-	// 2. Hapus dari Postgres
-	if err := r.pgDB.Delete(&ref).Error; err != nil {
-		return err
+	if result.RowsAffected == 0 {
+		return ErrStatusConflict
 	}
 
 	// 3. Hapus dari Mongo (Cleanup)
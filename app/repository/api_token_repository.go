@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"time"
+	"uas/app/model"
+
+	"gorm.io/gorm"
+)
+
+// Last used hanya ditulis ulang jika sudah lewat interval ini, agar tidak ada UPDATE di setiap request
+const apiTokenTouchInterval = time.Minute
+
+type APITokenRepository struct {
+	db *gorm.DB
+}
+
+func NewAPITokenRepository(db *gorm.DB) *APITokenRepository {
+	return &APITokenRepository{db: db}
+}
+
+// Simpan token baru
+func (r *APITokenRepository) Create(token *model.APIToken) error {
+	return r.db.Create(token).Error
+}
+
+// Cari token berdasarkan hash, beserta user pemiliknya (untuk cek status aktif)
+func (r *APITokenRepository) FindByHash(hash string) (*model.APIToken, error) {
+	var token model.APIToken
+	err := r.db.Preload("User").Where("token_hash = ?", hash).First(&token).Error
+	return &token, err
+}
+
+// List token milik user (terbaru di atas)
+func (r *APITokenRepository) FindByUserID(userID string) ([]model.APIToken, error) {
+	var tokens []model.APIToken
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&tokens).Error
+	return tokens, err
+}
+
+// Cabut token milik user tertentu. Return false jika token tidak ditemukan / sudah dicabut.
+func (r *APITokenRepository) Revoke(id string, userID string) (bool, error) {
+	res := r.db.Model(&model.APIToken{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	return res.RowsAffected == 1, res.Error
+}
+
+// Catat waktu & IP terakhir token dipakai
+func (r *APITokenRepository) TouchLastUsed(id string, ip string) error {
+	now := time.Now()
+	return r.db.Model(&model.APIToken{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ? OR last_used_ip <> ?)", id, now.Add(-apiTokenTouchInterval), ip).
+		Updates(map[string]interface{}{"last_used_at": now, "last_used_ip": ip}).Error
+}
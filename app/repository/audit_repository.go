@@ -0,0 +1,25 @@
+package repository
+
+import (
+	"uas/app/model"
+
+	"gorm.io/gorm"
+)
+
+type AuditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) *AuditRepository {
+	return &AuditRepository{db: db}
+}
+
+// Catat satu entry audit (tidak pernah dihapus)
+func (r *AuditRepository) Create(entry *model.AuditLog) error {
+	return r.db.Create(entry).Error
+}
+
+// SetStatusCode mengisi status code request yang sudah dicatat sebelum handler dijalankan
+func (r *AuditRepository) SetStatusCode(id string, status int) error {
+	return r.db.Model(&model.AuditLog{}).Where("id = ?", id).Update("status_code", status).Error
+}
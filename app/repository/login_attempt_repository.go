@@ -0,0 +1,30 @@
+package repository
+
+import (
+	"time"
+	"uas/app/model"
+
+	"gorm.io/gorm"
+)
+
+type LoginAttemptRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginAttemptRepository(db *gorm.DB) *LoginAttemptRepository {
+	return &LoginAttemptRepository{db: db}
+}
+
+// Catat satu percobaan login
+func (r *LoginAttemptRepository) Record(attempt *model.LoginAttempt) error {
+	return r.db.Create(attempt).Error
+}
+
+// Hitung login gagal dari satu IP sejak waktu tertentu
+func (r *LoginAttemptRepository) CountFailuresByIP(ip string, since time.Time) (int64, error) {
+	var total int64
+	err := r.db.Model(&model.LoginAttempt{}).
+		Where("ip_address = ? AND success = ? AND created_at >= ?", ip, false, since).
+		Count(&total).Error
+	return total, err
+}
@@ -0,0 +1,509 @@
+package repository
+
+import (
+	"errors"
+	"regexp"
+	"time"
+	"uas/app/model"
+	"uas/utils"
+
+	"gorm.io/gorm"
+)
+
+// ErrAmbiguousLabel: teks cocok dengan lebih dari satu data master (mis. nama prodi yang sama di dua jurusan)
+var ErrAmbiguousLabel = errors.New("label matches more than one master record")
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Tahun angkatan lama, mis. "2021" -> tahun akademik "2021/2022"
+var entryYearPattern = regexp.MustCompile(`^[0-9]{4}$`)
+
+// UnmappedValue adalah teks bebas lama yang belum terpetakan ke data master
+type UnmappedValue struct {
+	EntityType string `json:"entityType"`
+	Value      string `json:"value"`
+	Total      int64  `json:"total"`
+}
+
+// LegacyMappingResult adalah ringkasan pemetaan teks bebas lama ke data master
+type LegacyMappingResult struct {
+	ProgramStudies int64           `json:"programStudies"` // Jumlah mahasiswa yang baru terpetakan
+	AcademicYears  int64           `json:"academicYears"`
+	Departments    int64           `json:"departments"` // Jumlah dosen yang baru terpetakan
+	Unmapped       []UnmappedValue `json:"unmapped"`
+}
+
+// Kolom teks lama & kolom referensi per jenis data master
+type legacyColumn struct {
+	entityType string
+	table      string
+	textColumn string
+	refColumn  string
+}
+
+var legacyColumns = []legacyColumn{
+	{model.MasterProgramStudy, "students", "program_study", "program_study_id"},
+	{model.MasterAcademicYear, "students", "academic_year", "academic_year_id"},
+	{model.MasterDepartment, "lecturers", "department", "department_id"},
+}
+
+type MasterRepository struct {
+	db *gorm.DB
+}
+
+func NewMasterRepository(db *gorm.DB) *MasterRepository {
+	return &MasterRepository{db: db}
+}
+
+// --- Fakultas ---
+
+func (r *MasterRepository) FindFaculties() ([]model.Faculty, error) {
+	var faculties []model.Faculty
+	err := r.db.Order("name ASC").Find(&faculties).Error
+	return faculties, err
+}
+
+func (r *MasterRepository) FindFacultyByID(id string) (*model.Faculty, error) {
+	var faculty model.Faculty
+	if !uuidPattern.MatchString(id) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	err := r.db.Where("id = ?", id).First(&faculty).Error
+	return &faculty, err
+}
+
+func (r *MasterRepository) CreateFaculty(faculty *model.Faculty) error {
+	return r.db.Create(faculty).Error
+}
+
+func (r *MasterRepository) UpdateFaculty(id string, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now()
+	return r.db.Model(&model.Faculty{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *MasterRepository) DeleteFaculty(id string) error {
+	return r.db.Where("id = ?", id).Delete(&model.Faculty{}).Error
+}
+
+// --- Jurusan ---
+
+func (r *MasterRepository) FindDepartments(facultyID string) ([]model.Department, error) {
+	var departments []model.Department
+	query := r.db.Preload("Faculty").Order("name ASC")
+	if facultyID != "" {
+		query = query.Where("faculty_id = ?", facultyID)
+	}
+	err := query.Find(&departments).Error
+	return departments, err
+}
+
+func (r *MasterRepository) FindDepartmentByID(id string) (*model.Department, error) {
+	var department model.Department
+	if !uuidPattern.MatchString(id) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	err := r.db.Preload("Faculty").Where("id = ?", id).First(&department).Error
+	return &department, err
+}
+
+func (r *MasterRepository) CreateDepartment(department *model.Department) error {
+	return r.db.Omit("Faculty").Create(department).Error
+}
+
+// UpdateDepartment: jika nama berubah, kolom teks department milik dosen ikut diperbarui
+func (r *MasterRepository) UpdateDepartment(id string, updates map[string]interface{}) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		updates["updated_at"] = time.Now()
+		if err := tx.Model(&model.Department{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+		if name, ok := updates["name"]; ok {
+			return tx.Exec("UPDATE lecturers SET department = ? WHERE department_id = ?", name, id).Error
+		}
+		return nil
+	})
+}
+
+func (r *MasterRepository) DeleteDepartment(id string) error {
+	return r.deleteWithAliases(&model.Department{}, model.MasterDepartment, id)
+}
+
+// --- Program Studi ---
+
+// FindProgramStudies: filter opsional departmentId / facultyId
+func (r *MasterRepository) FindProgramStudies(departmentID string, facultyID string) ([]model.ProgramStudy, error) {
+	var programs []model.ProgramStudy
+	query := r.db.Preload("Department.Faculty").Order("program_studies.name ASC")
+	if departmentID != "" {
+		query = query.Where("program_studies.department_id = ?", departmentID)
+	}
+	if facultyID != "" {
+		query = query.Joins("JOIN departments ON departments.id = program_studies.department_id").
+			Where("departments.faculty_id = ?", facultyID)
+	}
+	err := query.Find(&programs).Error
+	return programs, err
+}
+
+func (r *MasterRepository) FindProgramStudyByID(id string) (*model.ProgramStudy, error) {
+	var program model.ProgramStudy
+	if !uuidPattern.MatchString(id) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	err := r.db.Preload("Department.Faculty").Where("id = ?", id).First(&program).Error
+	return &program, err
+}
+
+func (r *MasterRepository) CreateProgramStudy(program *model.ProgramStudy) error {
+	return r.db.Omit("Department").Create(program).Error
+}
+
+// UpdateProgramStudy: jika nama berubah, kolom teks program_study milik mahasiswa ikut diperbarui
+func (r *MasterRepository) UpdateProgramStudy(id string, updates map[string]interface{}) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		updates["updated_at"] = time.Now()
+		if err := tx.Model(&model.ProgramStudy{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+		if name, ok := updates["name"]; ok {
+			return tx.Exec("UPDATE students SET program_study = ? WHERE program_study_id = ?", name, id).Error
+		}
+		return nil
+	})
+}
+
+func (r *MasterRepository) DeleteProgramStudy(id string) error {
+	return r.deleteWithAliases(&model.ProgramStudy{}, model.MasterProgramStudy, id)
+}
+
+// --- Tahun Akademik & Semester ---
+
+func (r *MasterRepository) FindAcademicYears() ([]model.AcademicYear, error) {
+	var years []model.AcademicYear
+	err := r.db.Preload("Semesters", func(db *gorm.DB) *gorm.DB {
+		return db.Order("start_date ASC")
+	}).Order("start_date DESC").Find(&years).Error
+	return years, err
+}
+
+func (r *MasterRepository) FindAcademicYearByID(id string) (*model.AcademicYear, error) {
+	var year model.AcademicYear
+	if !uuidPattern.MatchString(id) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	err := r.db.Preload("Semesters", func(db *gorm.DB) *gorm.DB {
+		return db.Order("start_date ASC")
+	}).Where("id = ?", id).First(&year).Error
+	return &year, err
+}
+
+// FindCurrentAcademicYear: tahun akademik yang sedang berjalan pada tanggal tertentu
+func (r *MasterRepository) FindCurrentAcademicYear(at time.Time) (*model.AcademicYear, error) {
+	var year model.AcademicYear
+	day := at.Format("2006-01-02")
+	err := r.db.Preload("Semesters", func(db *gorm.DB) *gorm.DB {
+		return db.Order("start_date ASC")
+	}).Where("start_date <= ? AND end_date >= ?", day, day).First(&year).Error
+	return &year, err
+}
+
+func (r *MasterRepository) CreateAcademicYear(year *model.AcademicYear) error {
+	return r.db.Omit("Semesters").Create(year).Error
+}
+
+// UpdateAcademicYear: jika nama berubah, kolom teks academic_year milik mahasiswa ikut diperbarui
+func (r *MasterRepository) UpdateAcademicYear(id string, updates map[string]interface{}) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		updates["updated_at"] = time.Now()
+		if err := tx.Model(&model.AcademicYear{}).Where("id = ?", id).Updates(updates).Error; err != nil {
+			return err
+		}
+		if name, ok := updates["name"]; ok {
+			return tx.Exec("UPDATE students SET academic_year = ? WHERE academic_year_id = ?", name, id).Error
+		}
+		return nil
+	})
+}
+
+// DeleteAcademicYear menghapus tahun akademik beserta semesternya
+func (r *MasterRepository) DeleteAcademicYear(id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("academic_year_id = ?", id).Delete(&model.Semester{}).Error; err != nil {
+			return err
+		}
+		return NewMasterRepository(tx).deleteWithAliases(&model.AcademicYear{}, model.MasterAcademicYear, id)
+	})
+}
+
+// IsAcademicYearOverlapping mengecek apakah rentang tanggal beririsan dengan tahun akademik lain
+func (r *MasterRepository) IsAcademicYearOverlapping(start time.Time, end time.Time, excludeID string) (bool, error) {
+	var count int64
+	query := r.db.Model(&model.AcademicYear{}).Where("start_date <= ? AND end_date >= ?", end, start)
+	if excludeID != "" {
+		query = query.Where("id <> ?", excludeID)
+	}
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+func (r *MasterRepository) FindSemesterByID(id string) (*model.Semester, error) {
+	var semester model.Semester
+	if !uuidPattern.MatchString(id) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	err := r.db.Where("id = ?", id).First(&semester).Error
+	return &semester, err
+}
+
+func (r *MasterRepository) CreateSemester(semester *model.Semester) error {
+	return r.db.Create(semester).Error
+}
+
+func (r *MasterRepository) UpdateSemester(id string, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now()
+	return r.db.Model(&model.Semester{}).Where("id = ?", id).Updates(updates).Error
+}
+
+func (r *MasterRepository) DeleteSemester(id string) error {
+	return r.db.Where("id = ?", id).Delete(&model.Semester{}).Error
+}
+
+// IsSemesterOverlapping mengecek apakah rentang tanggal beririsan dengan semester lain di tahun akademik yang sama
+func (r *MasterRepository) IsSemesterOverlapping(academicYearID string, start time.Time, end time.Time, excludeID string) (bool, error) {
+	var count int64
+	query := r.db.Model(&model.Semester{}).
+		Where("academic_year_id = ? AND start_date <= ? AND end_date >= ?", academicYearID, end, start)
+	if excludeID != "" {
+		query = query.Where("id <> ?", excludeID)
+	}
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+// --- Cek Keunikan & Pemakaian ---
+
+// IsTaken mengecek apakah nilai kolom (case insensitive) sudah dipakai baris lain, scope opsional mis. "faculty_id = ?"
+func (r *MasterRepository) IsTaken(record interface{}, column string, value string, excludeID string, scope ...interface{}) (bool, error) {
+	var count int64
+	query := r.db.Model(record).Where("LOWER("+column+") = LOWER(?)", value)
+	if excludeID != "" {
+		query = query.Where("id <> ?", excludeID)
+	}
+	if len(scope) > 0 {
+		query = query.Where(scope[0], scope[1:]...)
+	}
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+// CountUsage menghitung baris (termasuk yang di-soft delete) yang masih merujuk ke data master
+func (r *MasterRepository) CountUsage(record interface{}, column string, id string) (int64, error) {
+	var count int64
+	err := r.db.Unscoped().Model(record).Where(column+" = ?", id).Count(&count).Error
+	return count, err
+}
+
+// deleteWithAliases menghapus data master beserta alias-nya dalam satu transaksi
+func (r *MasterRepository) deleteWithAliases(record interface{}, entityType string, id string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("entity_type = ? AND target_id = ?", entityType, id).Delete(&model.MasterAlias{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", id).Delete(record).Error
+	})
+}
+
+// --- Alias ---
+
+func (r *MasterRepository) FindAliases(entityType string) ([]model.MasterAlias, error) {
+	var aliases []model.MasterAlias
+	query := r.db.Order("entity_type ASC, alias ASC")
+	if entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	err := query.Find(&aliases).Error
+	return aliases, err
+}
+
+func (r *MasterRepository) FindAliasByID(id string) (*model.MasterAlias, error) {
+	var alias model.MasterAlias
+	if !uuidPattern.MatchString(id) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	err := r.db.Where("id = ?", id).First(&alias).Error
+	return &alias, err
+}
+
+func (r *MasterRepository) FindAlias(entityType string, alias string) (*model.MasterAlias, error) {
+	var found model.MasterAlias
+	err := r.db.Where("entity_type = ? AND alias = ?", entityType, utils.NormalizeLabel(alias)).First(&found).Error
+	return &found, err
+}
+
+func (r *MasterRepository) CreateAlias(alias *model.MasterAlias) error {
+	alias.Alias = utils.NormalizeLabel(alias.Alias)
+	return r.db.Create(alias).Error
+}
+
+func (r *MasterRepository) DeleteAlias(id string) error {
+	return r.db.Where("id = ?", id).Delete(&model.MasterAlias{}).Error
+}
+
+// --- Resolve Teks -> Data Master ---
+// Urutan pencocokan: ID, kode, nama resmi, lalu alias. Return gorm.ErrRecordNotFound jika tidak ada yang cocok.
+
+func (r *MasterRepository) ResolveDepartment(label string) (*model.Department, error) {
+	var matches []model.Department
+	if err := r.resolve(&model.Department{}, model.MasterDepartment, label, true, &matches); err != nil {
+		return nil, err
+	}
+	if len(matches) > 1 {
+		return nil, ErrAmbiguousLabel
+	}
+	return &matches[0], nil
+}
+
+func (r *MasterRepository) ResolveProgramStudy(label string) (*model.ProgramStudy, error) {
+	var matches []model.ProgramStudy
+	if err := r.resolve(&model.ProgramStudy{}, model.MasterProgramStudy, label, true, &matches); err != nil {
+		return nil, err
+	}
+	if len(matches) > 1 {
+		return nil, ErrAmbiguousLabel
+	}
+	return &matches[0], nil
+}
+
+// ResolveAcademicYear juga menerima tahun angkatan, mis. "2021" -> "2021/2022"
+func (r *MasterRepository) ResolveAcademicYear(label string) (*model.AcademicYear, error) {
+	var matches []model.AcademicYear
+	err := r.resolve(&model.AcademicYear{}, model.MasterAcademicYear, label, false, &matches)
+	if errors.Is(err, gorm.ErrRecordNotFound) && entryYearPattern.MatchString(label) {
+		err = r.db.Where("name LIKE ?", label+"/%").Find(&matches).Error
+		if err == nil && len(matches) == 0 {
+			err = gorm.ErrRecordNotFound
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) > 1 {
+		return nil, ErrAmbiguousLabel
+	}
+	return &matches[0], nil
+}
+
+// resolve mengisi matches (slice pointer) dengan data master yang cocok dengan label
+func (r *MasterRepository) resolve(record interface{}, entityType string, label string, hasCode bool, matches interface{}) error {
+	label = utils.CleanLabel(label)
+	if label == "" {
+		return gorm.ErrRecordNotFound
+	}
+	normalized := utils.NormalizeLabel(label)
+
+	lookups := []func() *gorm.DB{}
+	if uuidPattern.MatchString(label) {
+		lookups = append(lookups, func() *gorm.DB { return r.db.Model(record).Where("id = ?", label) })
+	}
+	if hasCode {
+		lookups = append(lookups, func() *gorm.DB { return r.db.Model(record).Where("LOWER(code) = ?", normalized) })
+	}
+	lookups = append(lookups,
+		func() *gorm.DB { return r.db.Model(record).Where("LOWER(name) = ?", normalized) },
+		func() *gorm.DB {
+			return r.db.Model(record).Where("id IN (?)",
+				r.db.Model(&model.MasterAlias{}).Select("target_id").Where("entity_type = ? AND alias = ?", entityType, normalized))
+		},
+	)
+
+	for _, lookup := range lookups {
+		result := lookup().Find(matches)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected > 0 {
+			return nil
+		}
+	}
+	return gorm.ErrRecordNotFound
+}
+
+// --- Pemetaan Teks Bebas Lama ---
+
+// MapLegacyValues memetakan teks bebas lama (students.program_study, students.academic_year,
+// lecturers.department) yang belum punya referensi ke data master lewat kode / nama / alias.
+// Baris yang terpetakan ikut diseragamkan teksnya menjadi nama resmi. Idempotent.
+func (r *MasterRepository) MapLegacyValues() (*LegacyMappingResult, error) {
+	result := &LegacyMappingResult{}
+
+	for _, column := range legacyColumns {
+		values, err := r.unmappedValues(column)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, value := range values {
+			var id, name string
+			switch column.entityType {
+			case model.MasterProgramStudy:
+				if program, err := r.ResolveProgramStudy(value.Value); err == nil {
+					id, name = program.ID, program.Name
+				}
+			case model.MasterAcademicYear:
+				if year, err := r.ResolveAcademicYear(value.Value); err == nil {
+					id, name = year.ID, year.Name
+				}
+			case model.MasterDepartment:
+				if department, err := r.ResolveDepartment(value.Value); err == nil {
+					id, name = department.ID, department.Name
+				}
+			}
+			if id == "" {
+				result.Unmapped = append(result.Unmapped, value)
+				continue
+			}
+
+			update := r.db.Exec("UPDATE "+column.table+" SET "+column.refColumn+" = ?, "+column.textColumn+" = ? "+
+				"WHERE "+column.refColumn+" IS NULL AND "+column.textColumn+" = ?", id, name, value.Value)
+			if update.Error != nil {
+				return nil, update.Error
+			}
+
+			switch column.entityType {
+			case model.MasterProgramStudy:
+				result.ProgramStudies += update.RowsAffected
+			case model.MasterAcademicYear:
+				result.AcademicYears += update.RowsAffected
+			case model.MasterDepartment:
+				result.Departments += update.RowsAffected
+			}
+		}
+	}
+
+	if result.Unmapped == nil {
+		result.Unmapped = []UnmappedValue{}
+	}
+	return result, nil
+}
+
+// FindUnmappedValues: teks bebas lama yang belum terpetakan beserta jumlah pemakaiannya
+func (r *MasterRepository) FindUnmappedValues() ([]UnmappedValue, error) {
+	values := []UnmappedValue{}
+	for _, column := range legacyColumns {
+		found, err := r.unmappedValues(column)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, found...)
+	}
+	return values, nil
+}
+
+func (r *MasterRepository) unmappedValues(column legacyColumn) ([]UnmappedValue, error) {
+	var values []UnmappedValue
+	err := r.db.Raw("SELECT ? AS entity_type, "+column.textColumn+" AS value, COUNT(*) AS total "+
+		"FROM "+column.table+" WHERE "+column.refColumn+" IS NULL AND TRIM(COALESCE("+column.textColumn+", '')) <> '' "+
+		"GROUP BY "+column.textColumn+" ORDER BY total DESC", column.entityType).
+		Scan(&values).Error
+	return values, err
+}
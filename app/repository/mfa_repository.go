@@ -0,0 +1,99 @@
+package repository
+
+import (
+	"time"
+	"uas/app/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MFARepository struct {
+	db *gorm.DB
+}
+
+func NewMFARepository(db *gorm.DB) *MFARepository {
+	return &MFARepository{db: db}
+}
+
+// Cari konfigurasi MFA milik user
+func (r *MFARepository) FindByUserID(userID string) (*model.UserMFA, error) {
+	var mfa model.UserMFA
+	err := r.db.Where("user_id = ?", userID).First(&mfa).Error
+	return &mfa, err
+}
+
+// Simpan secret baru yang belum dikonfirmasi (enrollment ulang menimpa secret lama yang belum aktif)
+func (r *MFARepository) SavePending(userID string, secret string) error {
+	now := time.Now()
+	row := model.UserMFA{UserID: userID, Secret: secret, Enabled: false, CreatedAt: now, UpdatedAt: now}
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"secret", "enabled", "last_used_step", "updated_at"}),
+	}).Create(&row).Error
+}
+
+// Aktifkan MFA sekaligus simpan recovery code pertama dalam satu transaksi
+func (r *MFARepository) Enable(userID string, step int64, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		res := tx.Model(&model.UserMFA{}).
+			Where("user_id = ? AND enabled = ?", userID, false).
+			Updates(map[string]interface{}{"enabled": true, "confirmed_at": now, "last_used_step": step, "updated_at": now})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+// Simpan step TOTP terakhir yang dipakai. Return false jika step tersebut sudah pernah dipakai (replay).
+func (r *MFARepository) MarkStepUsed(userID string, step int64) (bool, error) {
+	res := r.db.Model(&model.UserMFA{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	return res.RowsAffected == 1, res.Error
+}
+
+// Pakai satu recovery code. Return false jika kode tidak ada / sudah dipakai.
+func (r *MFARepository) UseRecoveryCode(userID string, codeHash string) (bool, error) {
+	res := r.db.Model(&model.MFARecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, codeHash).
+		Update("used_at", time.Now())
+	return res.RowsAffected == 1, res.Error
+}
+
+// Ganti semua recovery code user dengan yang baru
+func (r *MFARepository) ReplaceRecoveryCodes(userID string, codeHashes []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return replaceRecoveryCodes(tx, userID, codeHashes)
+	})
+}
+
+// Matikan MFA (hapus secret & recovery code)
+func (r *MFARepository) Disable(userID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Delete(&model.MFARecoveryCode{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("user_id = ?", userID).Delete(&model.UserMFA{}).Error
+	})
+}
+
+func replaceRecoveryCodes(tx *gorm.DB, userID string, codeHashes []string) error {
+	if err := tx.Where("user_id = ?", userID).Delete(&model.MFARecoveryCode{}).Error; err != nil {
+		return err
+	}
+
+	codes := make([]model.MFARecoveryCode, 0, len(codeHashes))
+	for _, hash := range codeHashes {
+		codes = append(codes, model.MFARecoveryCode{UserID: userID, CodeHash: hash})
+	}
+	if len(codes) == 0 {
+		return nil
+	}
+	return tx.Create(&codes).Error
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"time"
+	"uas/app/model"
+
+	"gorm.io/gorm"
+)
+
+type PasswordResetRepository struct {
+	db *gorm.DB
+}
+
+func NewPasswordResetRepository(db *gorm.DB) *PasswordResetRepository {
+	return &PasswordResetRepository{db: db}
+}
+
+// Simpan token reset baru, token lama milik user yang belum dipakai otomatis dibatalkan
+func (r *PasswordResetRepository) Create(token *model.PasswordResetToken) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.PasswordResetToken{}).
+			Where("user_id = ? AND used_at IS NULL", token.UserID).
+			Update("used_at", time.Now()).Error; err != nil {
+			return err
+		}
+		return tx.Create(token).Error
+	})
+}
+
+// Cari token yang belum dipakai dan belum kedaluwarsa
+func (r *PasswordResetRepository) FindValidByHash(hash string) (*model.PasswordResetToken, error) {
+	var token model.PasswordResetToken
+	err := r.db.Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", hash, time.Now()).First(&token).Error
+	return &token, err
+}
+
+// Tandai token sudah dipakai. Return false jika token sudah lebih dulu dipakai request lain.
+func (r *PasswordResetRepository) MarkUsed(id string) (bool, error) {
+	res := r.db.Model(&model.PasswordResetToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	return res.RowsAffected == 1, res.Error
+}
@@ -0,0 +1,76 @@
+package repository
+
+import (
+	"time"
+	"uas/app/model"
+
+	"gorm.io/gorm"
+)
+
+type RefreshTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewRefreshTokenRepository(db *gorm.DB) *RefreshTokenRepository {
+	return &RefreshTokenRepository{db: db}
+}
+
+// Simpan refresh token baru (hash saja, bukan token asli)
+func (r *RefreshTokenRepository) Create(token *model.RefreshToken) error {
+	return r.db.Create(token).Error
+}
+
+// Cari refresh token berdasarkan hash-nya
+func (r *RefreshTokenRepository) FindByHash(hash string) (*model.RefreshToken, error) {
+	var token model.RefreshToken
+	err := r.db.Where("token_hash = ?", hash).First(&token).Error
+	return &token, err
+}
+
+// Rotate menandai token lama sebagai terpakai dan menyimpan penggantinya dalam satu transaksi.
+// Update lama memakai kondisi "revoked_at IS NULL", jadi jika dua request memakai token yang sama
+// secara bersamaan, hanya satu yang berhasil. Return false berarti token sudah pernah dipakai.
+func (r *RefreshTokenRepository) Rotate(oldID string, next *model.RefreshToken) (bool, error) {
+	rotated := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(next).Error; err != nil {
+			return err
+		}
+
+		res := tx.Model(&model.RefreshToken{}).
+			Where("id = ? AND revoked_at IS NULL", oldID).
+			Updates(map[string]interface{}{
+				"revoked_at":  time.Now(),
+				"replaced_by": next.ID,
+			})
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			// Batalkan token baru, token lama ternyata sudah dirotasi request lain
+			return gorm.ErrRecordNotFound
+		}
+
+		rotated = true
+		return nil
+	})
+
+	if err == gorm.ErrRecordNotFound {
+		return false, nil
+	}
+	return rotated, err
+}
+
+// Cabut seluruh token dalam satu family (dipakai saat terdeteksi reuse token lama)
+func (r *RefreshTokenRepository) RevokeFamily(familyID string) error {
+	return r.db.Model(&model.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// Cabut seluruh refresh token milik user yang dibuat sebelum waktu tertentu (logout dari semua perangkat)
+func (r *RefreshTokenRepository) RevokeAllByUser(userID string, before time.Time) error {
+	return r.db.Model(&model.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL AND created_at < ?", userID, before).
+		Update("revoked_at", time.Now()).Error
+}
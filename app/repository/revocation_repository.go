@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"log"
+	"sync"
+	"time"
+	"uas/app/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Cache di memori disinkronkan ulang dari Postgres secara berkala,
+// supaya revocation dari instance lain tetap terbaca tanpa query di setiap request.
+const revocationSyncInterval = time.Minute
+
+// RevocationRepository menyimpan daftar token yang dicabut (Postgres) beserta cache in-memory
+// yang dipakai AuthMiddleware di setiap request.
+type RevocationRepository struct {
+	db *gorm.DB
+
+	mu       sync.RWMutex
+	tokens   map[string]time.Time // jti -> expires_at
+	cutoffs  map[string]time.Time // user_id -> revoked_before
+	syncedAt time.Time
+
+	syncMu sync.Mutex
+}
+
+func NewRevocationRepository(db *gorm.DB) *RevocationRepository {
+	r := &RevocationRepository{
+		db:      db,
+		tokens:  map[string]time.Time{},
+		cutoffs: map[string]time.Time{},
+	}
+	r.sync()
+	return r
+}
+
+// RevokeToken mencabut satu access token (logout biasa)
+func (r *RevocationRepository) RevokeToken(jti string, userID string, expiresAt time.Time) error {
+	row := model.RevokedToken{JTI: jti, UserID: userID, ExpiresAt: expiresAt, RevokedAt: time.Now()}
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error; err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.tokens[jti] = expiresAt
+	r.mu.Unlock()
+	return nil
+}
+
+// RevokeUserTokensBefore mencabut semua token user yang diterbitkan sebelum waktu tertentu (logout everywhere)
+// Cutoff dibulatkan ke detik karena claim "iat" hanya berpresisi detik: token yang diterbitkan
+// di detik yang sama setelah logout (mis. login ulang) tidak boleh ikut tertolak.
+func (r *RevocationRepository) RevokeUserTokensBefore(userID string, before time.Time) error {
+	before = before.Truncate(time.Second)
+	row := model.UserTokenCutoff{UserID: userID, RevokedBefore: before, UpdatedAt: time.Now()}
+	err := r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}},
+		// Cutoff hanya boleh maju, jangan sampai memulihkan token yang sudah dicabut
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "revoked_before"}, Value: gorm.Expr("GREATEST(user_token_cutoffs.revoked_before, EXCLUDED.revoked_before)")},
+			{Column: clause.Column{Name: "updated_at"}, Value: row.UpdatedAt},
+		},
+	}).Create(&row).Error
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	if current, ok := r.cutoffs[userID]; !ok || before.After(current) {
+		r.cutoffs[userID] = before
+	}
+	r.mu.Unlock()
+	return nil
+}
+
+// IsRevoked mengecek apakah token (jti) milik user yang diterbitkan pada issuedAt sudah dicabut
+func (r *RevocationRepository) IsRevoked(jti string, userID string, issuedAt time.Time) bool {
+	r.syncIfStale()
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if jti != "" {
+		if _, ok := r.tokens[jti]; ok {
+			return true
+		}
+	}
+	// Cutoff lama di DB mungkin masih berpresisi nanodetik, samakan dengan presisi iat
+	if cutoff, ok := r.cutoffs[userID]; ok && issuedAt.Before(cutoff.Truncate(time.Second)) {
+		return true
+	}
+	return false
+}
+
+func (r *RevocationRepository) syncIfStale() {
+	r.mu.RLock()
+	stale := time.Since(r.syncedAt) > revocationSyncInterval
+	r.mu.RUnlock()
+
+	if stale {
+		r.sync()
+	}
+}
+
+// sync memuat ulang cache dari database dan membuang token yang sudah kedaluwarsa
+func (r *RevocationRepository) sync() {
+	r.syncMu.Lock()
+	defer r.syncMu.Unlock()
+
+	// Request lain mungkin sudah melakukan sync selagi kita menunggu lock
+	r.mu.RLock()
+	fresh := time.Since(r.syncedAt) <= revocationSyncInterval
+	r.mu.RUnlock()
+	if fresh {
+		return
+	}
+
+	now := time.Now()
+
+	// Bersihkan baris yang token-nya sudah expired (tidak perlu dicek lagi)
+	if err := r.db.Where("expires_at < ?", now).Delete(&model.RevokedToken{}).Error; err != nil {
+		log.Println("⚠️  Gagal membersihkan revoked_tokens:", err)
+	}
+
+	var tokens []model.RevokedToken
+	var cutoffs []model.UserTokenCutoff
+	errTokens := r.db.Where("expires_at >= ?", now).Find(&tokens).Error
+	errCutoffs := r.db.Find(&cutoffs).Error
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Tetap tandai sudah sync walau gagal, agar DB yang sedang bermasalah tidak dibanjiri query.
+	// Cache lama tetap dipakai sampai sync berikutnya berhasil.
+	r.syncedAt = now
+	if errTokens != nil || errCutoffs != nil {
+		log.Println("⚠️  Gagal sinkronisasi revocation cache:", errTokens, errCutoffs)
+		return
+	}
+
+	r.tokens = make(map[string]time.Time, len(tokens))
+	for _, t := range tokens {
+		r.tokens[t.JTI] = t.ExpiresAt
+	}
+	r.cutoffs = make(map[string]time.Time, len(cutoffs))
+	for _, c := range cutoffs {
+		r.cutoffs[c.UserID] = c.RevokedBefore
+	}
+}
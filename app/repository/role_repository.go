@@ -1,17 +1,53 @@
 package repository
 
 import (
+	"sync"
+	"time"
 	"uas/app/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// Lama cache state otorisasi per user. Perubahan lewat SQL manual (trigger menaikkan versi)
+// paling lambat terbaca setelah TTL ini, perubahan lewat API langsung meng-invalidate cache.
+const authzCacheTTL = 30 * time.Second
+
+// AuthzState adalah kondisi role & permission user terkini, dibandingkan dengan stamp di token
+type AuthzState struct {
+	UserID      string
+	RoleID      string
+	RoleName    string
+	UserVersion int
+	RoleVersion int
+	IsActive    bool // false jika akun dinonaktifkan Admin
+	Permissions []string
+}
+
+type cachedAuthzState struct {
+	state    AuthzState
+	loadedAt time.Time
+}
+
+type cachedRolePermissions struct {
+	version     int
+	permissions []string
+}
+
 type RoleRepository struct {
 	db *gorm.DB
+
+	mu        sync.RWMutex
+	users     map[string]cachedAuthzState      // user_id -> state
+	rolePerms map[string]cachedRolePermissions // role_id -> permissions pada versi tertentu
 }
 
 func NewRoleRepository(db *gorm.DB) *RoleRepository {
-	return &RoleRepository{db: db}
+	return &RoleRepository{
+		db:        db,
+		users:     map[string]cachedAuthzState{},
+		rolePerms: map[string]cachedRolePermissions{},
+	}
 }
 
 // Mencari Role berdasarkan nama (misal: untuk default role saat register)
@@ -21,6 +57,13 @@ func (r *RoleRepository) FindByName(name string) (*model.Role, error) {
 	return &role, err
 }
 
+// Mencari Role berdasarkan ID
+func (r *RoleRepository) FindByID(id string) (*model.Role, error) {
+	var role model.Role
+	err := r.db.Where("id = ?", id).First(&role).Error
+	return &role, err
+}
+
 // Mengambil Permission yang dimiliki oleh sebuah Role (untuk Middleware RBAC)
 func (r *RoleRepository) GetPermissionsByRoleID(roleID string) ([]model.Permission, error) {
 	var permissions []model.Permission
@@ -32,4 +75,341 @@ func (r *RoleRepository) GetPermissionsByRoleID(roleID string) ([]model.Permissi
 		Find(&permissions).Error
 		
 	return permissions, err
-}
\ No newline at end of file
+}
+
+// --- Administrasi Role & Permission ---
+// Semua perubahan ditulis bersama entry audit dalam satu transaksi (tidak ada perubahan tanpa jejak).
+// Versi permission role dinaikkan oleh trigger DB, cache di-invalidate oleh service.
+
+// Semua role, urut nama
+func (r *RoleRepository) FindAll() ([]model.Role, error) {
+	var roles []model.Role
+	err := r.db.Order("name ASC").Find(&roles).Error
+	return roles, err
+}
+
+// PermissionKeysByRole mengembalikan permission ("resource:action") setiap role: role_id -> keys
+func (r *RoleRepository) PermissionKeysByRole() (map[string][]string, error) {
+	return permissionKeysByRole(r.db)
+}
+
+// CountUsersByRole menghitung user aktif (tidak di trash) per role: role_id -> jumlah
+func (r *RoleRepository) CountUsersByRole(activeOnly bool) (map[string]int64, error) {
+	return countUsersByRole(r.db, activeOnly)
+}
+
+func permissionKeysByRole(db *gorm.DB) (map[string][]string, error) {
+	var rows []struct {
+		RoleID string
+		model.Permission
+	}
+	err := db.Table("role_permissions").
+		Select("role_permissions.role_id, permissions.*").
+		Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	keys := map[string][]string{}
+	for _, row := range rows {
+		keys[row.RoleID] = append(keys[row.RoleID], row.Permission.Key())
+	}
+	return keys, nil
+}
+
+func countUsersByRole(db *gorm.DB, activeOnly bool) (map[string]int64, error) {
+	var rows []struct {
+		RoleID string
+		Total  int64
+	}
+	query := db.Model(&model.User{}).Select("role_id, COUNT(*) AS total").Group("role_id")
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+	if err := query.Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := map[string]int64{}
+	for _, row := range rows {
+		counts[row.RoleID] = row.Total
+	}
+	return counts, nil
+}
+
+// RolePermissionGuard dijalankan di dalam transaksi sebelum permission dilepas / role dihapus,
+// dengan permission setiap role (role_id -> keys) & jumlah user aktif per role saat itu.
+// Return error untuk membatalkan perubahan.
+type RolePermissionGuard func(keys map[string][]string, activeUsers map[string]int64) error
+
+// runRolePermissionGuard mengunci semua baris role_permissions (FOR UPDATE) lalu menjalankan guard,
+// sehingga dua perubahan yang berjalan bersamaan tidak bisa sama-sama lolos guard.
+func runRolePermissionGuard(tx *gorm.DB, guard RolePermissionGuard) error {
+	if guard == nil {
+		return nil
+	}
+
+	var locked []model.RolePermission
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Find(&locked).Error; err != nil {
+		return err
+	}
+
+	keys, err := permissionKeysByRole(tx)
+	if err != nil {
+		return err
+	}
+	counts, err := countUsersByRole(tx, true)
+	if err != nil {
+		return err
+	}
+	return guard(keys, counts)
+}
+
+// CreateRole membuat role baru beserta permission awalnya
+func (r *RoleRepository) CreateRole(role *model.Role, permissionIDs []string, audit *model.AuditLog) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(role).Error; err != nil {
+			return err
+		}
+		if err := attachPermissions(tx, role.ID, permissionIDs); err != nil {
+			return err
+		}
+		audit.TargetID = role.ID
+		return tx.Create(audit).Error
+	})
+}
+
+// UpdateRole mengubah data role (nama, deskripsi, wajib MFA)
+func (r *RoleRepository) UpdateRole(roleID string, updates map[string]interface{}, audit *model.AuditLog) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Role{}).Where("id = ?", roleID).Updates(updates).Error; err != nil {
+			return err
+		}
+		return tx.Create(audit).Error
+	})
+}
+
+// DeleteRole menghapus role beserta relasi permission-nya (guard dijalankan lebih dulu, boleh nil)
+func (r *RoleRepository) DeleteRole(roleID string, guard RolePermissionGuard, audit *model.AuditLog) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := runRolePermissionGuard(tx, guard); err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ?", roleID).Delete(&model.RolePermission{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("id = ?", roleID).Delete(&model.Role{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(audit).Error
+	})
+}
+
+// AttachPermissions menambahkan permission ke role (yang sudah terpasang diabaikan)
+func (r *RoleRepository) AttachPermissions(roleID string, permissionIDs []string, audit *model.AuditLog) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := attachPermissions(tx, roleID, permissionIDs); err != nil {
+			return err
+		}
+		return tx.Create(audit).Error
+	})
+}
+
+// DetachPermission melepas satu permission dari role (guard dijalankan lebih dulu, boleh nil)
+func (r *RoleRepository) DetachPermission(roleID string, permissionID string, guard RolePermissionGuard, audit *model.AuditLog) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := runRolePermissionGuard(tx, guard); err != nil {
+			return err
+		}
+		result := tx.Where("role_id = ? AND permission_id = ?", roleID, permissionID).Delete(&model.RolePermission{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Create(audit).Error
+	})
+}
+
+func attachPermissions(tx *gorm.DB, roleID string, permissionIDs []string) error {
+	if len(permissionIDs) == 0 {
+		return nil
+	}
+	rows := make([]model.RolePermission, 0, len(permissionIDs))
+	for _, id := range permissionIDs {
+		rows = append(rows, model.RolePermission{RoleID: roleID, PermissionID: id})
+	}
+	return tx.Omit("Role", "Permission").Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+// Semua permission, urut resource & action
+func (r *RoleRepository) FindAllPermissions() ([]model.Permission, error) {
+	var permissions []model.Permission
+	err := r.db.Order("resource ASC, action ASC").Find(&permissions).Error
+	return permissions, err
+}
+
+// Mencari permission berdasarkan ID
+func (r *RoleRepository) FindPermissionByID(id string) (*model.Permission, error) {
+	var permission model.Permission
+	err := r.db.Where("id = ?", id).First(&permission).Error
+	return &permission, err
+}
+
+// FindPermissionsByIDs mengambil permission berdasarkan daftar ID (ID yang tidak ada diabaikan)
+func (r *RoleRepository) FindPermissionsByIDs(ids []string) ([]model.Permission, error) {
+	var permissions []model.Permission
+	err := r.db.Where("id IN ?", ids).Find(&permissions).Error
+	return permissions, err
+}
+
+// Cek apakah permission dengan resource & action (atau nama) tersebut sudah ada
+func (r *RoleRepository) IsPermissionTaken(name string, resource string, action string) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.Permission{}).
+		Where("name = ? OR (resource = ? AND action = ?)", name, resource, action).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// Jumlah role yang memakai permission
+func (r *RoleRepository) CountRolesWithPermission(permissionID string) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.RolePermission{}).Where("permission_id = ?", permissionID).Count(&count).Error
+	return count, err
+}
+
+// CreatePermission membuat permission baru
+func (r *RoleRepository) CreatePermission(permission *model.Permission, audit *model.AuditLog) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(permission).Error; err != nil {
+			return err
+		}
+		audit.TargetID = permission.ID
+		return tx.Create(audit).Error
+	})
+}
+
+// UpdatePermissionDescription mengubah deskripsi permission (resource & action tidak bisa diubah)
+func (r *RoleRepository) UpdatePermissionDescription(permissionID string, description string, audit *model.AuditLog) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Permission{}).Where("id = ?", permissionID).Update("description", description).Error; err != nil {
+			return err
+		}
+		return tx.Create(audit).Error
+	})
+}
+
+// DeletePermission menghapus permission yang tidak dipakai role mana pun
+func (r *RoleRepository) DeletePermission(permissionID string, audit *model.AuditLog) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ?", permissionID).Delete(&model.Permission{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(audit).Error
+	})
+}
+
+// --- Cache Versi Permission (Stamp) ---
+
+// GetAuthzState mengambil state otorisasi user dari cache (maks. authzCacheTTL), dipakai middleware
+func (r *RoleRepository) GetAuthzState(userID string) (*AuthzState, error) {
+	r.mu.RLock()
+	cached, ok := r.users[userID]
+	r.mu.RUnlock()
+
+	if ok && time.Since(cached.loadedAt) < authzCacheTTL {
+		state := cached.state
+		return &state, nil
+	}
+	return r.RefreshAuthzState(userID)
+}
+
+// RefreshAuthzState membaca ulang state dari database (dipakai saat login/refresh agar token selalu segar)
+func (r *RoleRepository) RefreshAuthzState(userID string) (*AuthzState, error) {
+	var row struct {
+		UserID      string
+		RoleID      string
+		RoleName    string
+		UserVersion int
+		RoleVersion int
+		IsActive    bool
+	}
+	// User yang sudah di-soft delete dianggap tidak ada
+	err := r.db.Table("users").
+		Select("users.id AS user_id, users.role_id, roles.name AS role_name, users.permission_version AS user_version, roles.permission_version AS role_version, users.is_active").
+		Joins("JOIN roles ON roles.id = users.role_id").
+		Where("users.id = ? AND users.deleted_at IS NULL", userID).
+		Take(&row).Error
+	if err != nil {
+		return nil, err
+	}
+
+	permissions, err := r.permissionNames(row.RoleID, row.RoleVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	state := AuthzState{
+		UserID:      row.UserID,
+		RoleID:      row.RoleID,
+		RoleName:    row.RoleName,
+		UserVersion: row.UserVersion,
+		RoleVersion: row.RoleVersion,
+		IsActive:    row.IsActive,
+		Permissions: permissions,
+	}
+
+	r.mu.Lock()
+	r.users[userID] = cachedAuthzState{state: state, loadedAt: time.Now()}
+	r.mu.Unlock()
+
+	return &state, nil
+}
+
+// InvalidateUser membuang cache state user (misal setelah role user diganti lewat API)
+func (r *RoleRepository) InvalidateUser(userID string) {
+	r.mu.Lock()
+	delete(r.users, userID)
+	r.mu.Unlock()
+}
+
+// InvalidateRole membuang cache permission role beserta state semua user pemilik role tersebut
+func (r *RoleRepository) InvalidateRole(roleID string) {
+	r.mu.Lock()
+	delete(r.rolePerms, roleID)
+	for userID, cached := range r.users {
+		if cached.state.RoleID == roleID {
+			delete(r.users, userID)
+		}
+	}
+	r.mu.Unlock()
+}
+
+// permissionNames mengambil nama permission role, di-cache selama versi role belum berubah
+func (r *RoleRepository) permissionNames(roleID string, version int) ([]string, error) {
+	r.mu.RLock()
+	cached, ok := r.rolePerms[roleID]
+	r.mu.RUnlock()
+	if ok && cached.version == version {
+		return cached.permissions, nil
+	}
+
+	permsData, err := r.GetPermissionsByRoleID(roleID)
+	if err != nil {
+		return nil, err
+	}
+
+	permissions := make([]string, 0, len(permsData))
+	for _, p := range permsData {
+		permissions = append(permissions, p.Key())
+	}
+
+	r.mu.Lock()
+	r.rolePerms[roleID] = cachedRolePermissions{version: version, permissions: permissions}
+	r.mu.Unlock()
+
+	return permissions, nil
+}
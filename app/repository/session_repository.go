@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"sync"
+	"time"
+	"uas/app/model"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// Status sesi di-cache sebentar agar AuthMiddleware tidak query di setiap request.
+	// Pencabutan dari instance lain terbaca paling lambat setelah interval ini.
+	sessionCacheTTL = 30 * time.Second
+	// last_seen_at hanya ditulis ulang jika sudah lewat interval ini
+	sessionTouchInterval = time.Minute
+)
+
+type sessionCacheEntry struct {
+	active    bool
+	checkedAt time.Time
+	touchedAt time.Time
+}
+
+type SessionRepository struct {
+	db *gorm.DB
+
+	mu    sync.Mutex
+	cache map[string]*sessionCacheEntry
+}
+
+func NewSessionRepository(db *gorm.DB) *SessionRepository {
+	return &SessionRepository{db: db, cache: map[string]*sessionCacheEntry{}}
+}
+
+// Simpan sesi baru (saat login berhasil)
+func (r *SessionRepository) Create(session *model.Session) error {
+	if session.LastSeenAt.IsZero() {
+		session.LastSeenAt = time.Now()
+	}
+	return r.db.Create(session).Error
+}
+
+// Cari sesi berdasarkan ID
+func (r *SessionRepository) FindByID(id string) (*model.Session, error) {
+	var session model.Session
+	err := r.db.Where("id = ?", id).First(&session).Error
+	return &session, err
+}
+
+// List sesi aktif milik user (terakhir dipakai di atas). Sesi yang tidak dipakai
+// lebih lama dari masa berlaku refresh token dianggap sudah berakhir.
+func (r *SessionRepository) FindActiveByUser(userID string, idleTimeout time.Duration) ([]model.Session, error) {
+	var sessions []model.Session
+	err := r.db.
+		Where("user_id = ? AND revoked_at IS NULL AND last_seen_at > ?", userID, time.Now().Add(-idleTimeout)).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// Cabut satu sesi milik user. Return false jika sesi tidak ditemukan / sudah dicabut.
+func (r *SessionRepository) Revoke(id string, userID string) (bool, error) {
+	res := r.db.Model(&model.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		return false, res.Error
+	}
+
+	r.forget(id)
+	return res.RowsAffected == 1, nil
+}
+
+// Cabut semua sesi user yang dibuat sebelum waktu tertentu, kecuali exceptID (boleh kosong).
+// Return ID sesi yang dicabut agar refresh token family-nya bisa ikut dicabut.
+func (r *SessionRepository) RevokeAllByUser(userID string, before time.Time, exceptID string) ([]string, error) {
+	query := r.db.Model(&model.Session{}).
+		Where("user_id = ? AND revoked_at IS NULL AND created_at < ?", userID, before)
+	if exceptID != "" {
+		query = query.Where("id <> ?", exceptID)
+	}
+
+	var ids []string
+	if err := query.Pluck("id", &ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return ids, nil
+	}
+
+	if err := r.db.Model(&model.Session{}).Where("id IN ?", ids).Update("revoked_at", time.Now()).Error; err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		r.forget(id)
+	}
+	return ids, nil
+}
+
+// Catat aktivitas terakhir sesi (dipanggil saat refresh token)
+func (r *SessionRepository) Touch(id string, ip string, userAgent string) error {
+	return r.db.Model(&model.Session{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{"last_seen_at": time.Now(), "ip_address": ip, "user_agent": userAgent}).Error
+}
+
+// IsActive dipakai AuthMiddleware di setiap request: cek sesi belum dicabut (cache),
+// sekaligus memperbarui last_seen_at paling sering sekali per sessionTouchInterval.
+func (r *SessionRepository) IsActive(id string, ip string) bool {
+	now := time.Now()
+
+	r.mu.Lock()
+	entry, ok := r.cache[id]
+	if !ok || now.Sub(entry.checkedAt) > sessionCacheTTL {
+		r.mu.Unlock()
+
+		var session model.Session
+		err := r.db.Select("id", "revoked_at").Where("id = ?", id).First(&session).Error
+		active := err == nil && session.RevokedAt == nil
+
+		r.mu.Lock()
+		if entry, ok = r.cache[id]; !ok {
+			entry = &sessionCacheEntry{}
+			r.cache[id] = entry
+		}
+		entry.active = active
+		entry.checkedAt = now
+	}
+
+	active := entry.active
+	touch := active && now.Sub(entry.touchedAt) > sessionTouchInterval
+	if touch {
+		entry.touchedAt = now
+	}
+	r.purgeLocked(now)
+	r.mu.Unlock()
+
+	if touch {
+		_ = r.db.Model(&model.Session{}).
+			Where("id = ?", id).
+			Updates(map[string]interface{}{"last_seen_at": now, "ip_address": ip}).Error
+	}
+	return active
+}
+
+// forget membuang sesi dari cache, agar pencabutan langsung berlaku di instance ini
+func (r *SessionRepository) forget(id string) {
+	r.mu.Lock()
+	if entry, ok := r.cache[id]; ok {
+		entry.active = false
+		entry.checkedAt = time.Now()
+	}
+	r.mu.Unlock()
+}
+
+// purgeLocked membersihkan entry cache yang sudah lama tidak dipakai (caller memegang r.mu)
+func (r *SessionRepository) purgeLocked(now time.Time) {
+	if len(r.cache) < 10000 {
+		return
+	}
+	for id, entry := range r.cache {
+		if now.Sub(entry.checkedAt) > sessionCacheTTL {
+			delete(r.cache, id)
+		}
+	}
+}
@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"strings"
+	"uas/app/model"
+)
+
+// --- Direktori Mahasiswa & Dosen ---
+
+// StudentFilter adalah filter list mahasiswa. UserID / AdvisorID juga dipakai untuk membatasi data sesuai role.
+type StudentFilter struct {
+	ProgramStudyID string
+	AcademicYearID string
+	DepartmentID   string
+	FacultyID      string
+	AdvisorID      string
+	UserID         string
+	IsActive       *bool
+}
+
+// LecturerFilter adalah filter list dosen
+type LecturerFilter struct {
+	DepartmentID string
+	FacultyID    string
+	LecturerID   string // lecturers.id
+	UserID       string
+	IsActive     *bool
+}
+
+// Kolom sorting yang diizinkan (whitelist)
+var studentSortColumns = map[string]string{
+	"created_at":    "students.created_at",
+	"student_id":    "students.student_id",
+	"studentId":     "students.student_id",
+	"nim":           "students.student_id",
+	"full_name":     "users.full_name",
+	"fullName":      "users.full_name",
+	"program_study": "students.program_study",
+	"programStudy":  "students.program_study",
+	"academic_year": "students.academic_year",
+	"academicYear":  "students.academic_year",
+}
+
+var lecturerSortColumns = map[string]string{
+	"created_at":  "lecturers.created_at",
+	"lecturer_id": "lecturers.lecturer_id",
+	"lecturerId":  "lecturers.lecturer_id",
+	"nip":         "lecturers.lecturer_id",
+	"full_name":   "users.full_name",
+	"fullName":    "users.full_name",
+	"department":  "lecturers.department",
+}
+
+// FindStudents: list mahasiswa dengan pagination, filter, search (NIM / nama) & sort
+func (r *UserRepository) FindStudents(param model.PaginationParam, filter StudentFilter) ([]model.Student, int64, error) {
+	var students []model.Student
+	var total int64
+
+	// 1. Start Query (user yang sudah di trash tidak ikut)
+	query := r.db.Model(&model.Student{}).
+		Preload("User").
+		Preload("Advisor.User").
+		Preload("ProgramStudyRef.Department.Faculty").
+		Preload("AcademicYearRef").
+		Joins("JOIN users ON users.id = students.user_id AND users.deleted_at IS NULL")
+
+	// 2. Filter (jurusan & fakultas lewat program studi)
+	if filter.ProgramStudyID != "" {
+		query = query.Where("students.program_study_id = ?", filter.ProgramStudyID)
+	}
+	if filter.AcademicYearID != "" {
+		query = query.Where("students.academic_year_id = ?", filter.AcademicYearID)
+	}
+	if filter.DepartmentID != "" || filter.FacultyID != "" {
+		query = query.Joins("JOIN program_studies ON program_studies.id = students.program_study_id").
+			Joins("JOIN departments ON departments.id = program_studies.department_id")
+		if filter.DepartmentID != "" {
+			query = query.Where("departments.id = ?", filter.DepartmentID)
+		}
+		if filter.FacultyID != "" {
+			query = query.Where("departments.faculty_id = ?", filter.FacultyID)
+		}
+	}
+	if filter.AdvisorID != "" {
+		query = query.Where("students.advisor_id = ?", filter.AdvisorID)
+	}
+	if filter.UserID != "" {
+		query = query.Where("students.user_id = ?", filter.UserID)
+	}
+	if filter.IsActive != nil {
+		query = query.Where("users.is_active = ?", *filter.IsActive)
+	}
+
+	// 3. Search NIM / nama (Case Insensitive)
+	if param.Search != "" {
+		searchLower := "%" + strings.ToLower(param.Search) + "%"
+		query = query.Where("LOWER(students.student_id) LIKE ? OR LOWER(users.full_name) LIKE ?", searchLower, searchLower)
+	}
+
+	// 4. Count Total
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 5. Sorting & Pagination
+	query = query.Order(sortClause(studentSortColumns, param, "students.created_at"))
+	offset := (param.Page - 1) * param.Limit
+	err := query.Limit(param.Limit).Offset(offset).Find(&students).Error
+	return students, total, err
+}
+
+// FindLecturers: list dosen dengan pagination, filter, search (NIP / nama) & sort
+func (r *UserRepository) FindLecturers(param model.PaginationParam, filter LecturerFilter) ([]model.Lecturer, int64, error) {
+	var lecturers []model.Lecturer
+	var total int64
+
+	query := r.db.Model(&model.Lecturer{}).
+		Preload("User").
+		Preload("DepartmentRef.Faculty").
+		Joins("JOIN users ON users.id = lecturers.user_id AND users.deleted_at IS NULL")
+
+	if filter.DepartmentID != "" {
+		query = query.Where("lecturers.department_id = ?", filter.DepartmentID)
+	}
+	if filter.FacultyID != "" {
+		query = query.Joins("JOIN departments ON departments.id = lecturers.department_id").
+			Where("departments.faculty_id = ?", filter.FacultyID)
+	}
+	if filter.LecturerID != "" {
+		query = query.Where("lecturers.id = ?", filter.LecturerID)
+	}
+	if filter.UserID != "" {
+		query = query.Where("lecturers.user_id = ?", filter.UserID)
+	}
+	if filter.IsActive != nil {
+		query = query.Where("users.is_active = ?", *filter.IsActive)
+	}
+
+	if param.Search != "" {
+		searchLower := "%" + strings.ToLower(param.Search) + "%"
+		query = query.Where("LOWER(lecturers.lecturer_id) LIKE ? OR LOWER(users.full_name) LIKE ?", searchLower, searchLower)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	query = query.Order(sortClause(lecturerSortColumns, param, "lecturers.created_at"))
+	offset := (param.Page - 1) * param.Limit
+	err := query.Limit(param.Limit).Offset(offset).Find(&lecturers).Error
+	return lecturers, total, err
+}
+
+// CountAdviseesByLecturers menghitung mahasiswa bimbingan per dosen: lecturer_id -> jumlah
+func (r *UserRepository) CountAdviseesByLecturers(lecturerIDs []string) (map[string]int64, error) {
+	var rows []struct {
+		LecturerID string
+		Total      int64
+	}
+	err := r.db.Model(&model.Student{}).
+		Select("advisor_id AS lecturer_id, COUNT(*) AS total").
+		Where("advisor_id IN ?", lecturerIDs).
+		Group("advisor_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.LecturerID] = row.Total
+	}
+	return counts, nil
+}
+
+// CountPendingByLecturers menghitung prestasi mahasiswa bimbingan yang menunggu verifikasi per dosen
+func (r *UserRepository) CountPendingByLecturers(lecturerIDs []string) (map[string]int64, error) {
+	var rows []struct {
+		LecturerID string
+		Total      int64
+	}
+	err := r.db.Model(&model.AchievementReference{}).
+		Select("students.advisor_id AS lecturer_id, COUNT(*) AS total").
+		Joins("JOIN students ON students.id = achievement_references.student_id AND students.deleted_at IS NULL").
+		Where("students.advisor_id IN ? AND achievement_references.status = ?", lecturerIDs, "submitted").
+		Group("students.advisor_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.LecturerID] = row.Total
+	}
+	return counts, nil
+}
+
+// CountAchievementsByStatus menghitung prestasi mahasiswa per status: status -> jumlah
+func (r *UserRepository) CountAchievementsByStatus(studentID string) (map[string]int64, error) {
+	var rows []struct {
+		Status string
+		Total  int64
+	}
+	err := r.db.Model(&model.AchievementReference{}).
+		Select("status, COUNT(*) AS total").
+		Where("student_id = ?", studentID).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Total
+	}
+	return counts, nil
+}
+
+// sortClause menyusun ORDER BY dari whitelist kolom (nilai sortBy dari query string tidak boleh masuk langsung ke SQL)
+func sortClause(columns map[string]string, param model.PaginationParam, fallback string) string {
+	orderBy, ok := columns[param.SortBy]
+	if !ok {
+		orderBy = fallback
+	}
+	orderDir := "DESC"
+	if strings.ToUpper(param.Order) == "ASC" {
+		orderDir = "ASC"
+	}
+	return orderBy + " " + orderDir
+}
@@ -1,9 +1,13 @@
 package repository
 
 import (
+	"errors"
+	"strings"
+	"time"
 	"uas/app/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository struct {
@@ -14,6 +18,14 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
+// Transaction menjalankan fn dengan UserRepository yang terikat pada satu transaksi.
+// Transaction bersarang memakai SAVEPOINT, sehingga satu bagian yang gagal bisa di-rollback sendiri.
+func (r *UserRepository) Transaction(fn func(repo *UserRepository) error) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return fn(&UserRepository{db: tx})
+	})
+}
+
 // Create User baru (Register)
 func (r *UserRepository) Create(user *model.User) error {
 	return r.db.Create(user).Error
@@ -39,13 +51,537 @@ func (r *UserRepository) FindByID(id string) (*model.User, error) {
 func (r *UserRepository) FindStudentByUserID(userID string) (*model.Student, error) {
 	var student model.Student
 	// Preload Advisor (Dosen Wali) jika perlu
-	err := r.db.Preload("User").Preload("Advisor.User").Where("user_id = ?", userID).First(&student).Error
+	err := r.db.Preload("User").Preload("Advisor.User").Preload("ProgramStudyRef").Preload("AcademicYearRef").Where("user_id = ?", userID).First(&student).Error
 	return &student, err
 }
 
 // Cari Data Dosen berdasarkan UserID (Untuk verifikasi)
 func (r *UserRepository) FindLecturerByUserID(userID string) (*model.Lecturer, error) {
 	var lecturer model.Lecturer
-	err := r.db.Preload("User").Where("user_id = ?", userID).First(&lecturer).Error
+	err := r.db.Preload("User").Preload("DepartmentRef").Where("user_id = ?", userID).First(&lecturer).Error
 	return &lecturer, err
-}
\ No newline at end of file
+}
+
+// Hitung jumlah mahasiswa bimbingan seorang Dosen Wali
+func (r *UserRepository) CountAdvisees(lecturerID string) (int64, error) {
+	var count int64
+	err := r.db.Model(&model.Student{}).Where("advisor_id = ?", lecturerID).Count(&count).Error
+	return count, err
+}
+
+// Cek apakah username / email sudah dipakai user lain
+func (r *UserRepository) IsUsernameOrEmailTaken(username string, email string, excludeUserID string) (bool, error) {
+	// Hanya nilai yang diisi yang dicek (nilai kosong akan cocok dengan baris ber-username/email kosong)
+	var conditions []string
+	var args []interface{}
+	if username != "" {
+		conditions = append(conditions, "username = ?")
+		args = append(args, username)
+	}
+	if email != "" {
+		conditions = append(conditions, "email = ?")
+		args = append(args, email)
+	}
+	if len(conditions) == 0 {
+		return false, nil
+	}
+
+	var count int64
+	// Unscoped: user di trash tetap memegang username/email-nya (agar bisa di-restore)
+	query := r.db.Unscoped().Model(&model.User{}).Where("("+strings.Join(conditions, " OR ")+")", args...)
+	if excludeUserID != "" {
+		query = query.Where("id <> ?", excludeUserID)
+	}
+	err := query.Count(&count).Error
+	return count > 0, err
+}
+
+// Update data profil user (hanya kolom yang dikirim)
+func (r *UserRepository) UpdateProfile(userID string, updates map[string]interface{}) error {
+	updates["updated_at"] = time.Now()
+	return r.db.Model(&model.User{}).Where("id = ?", userID).Updates(updates).Error
+}
+// --- Helper Proteksi Login ---
+
+// Tambah counter login gagal secara atomic. Jika counter mencapai threshold,
+// akun dikunci sampai lockUntil dan counter dimulai lagi dari nol.
+func (r *UserRepository) RegisterFailedLogin(userID string, threshold int, lockUntil time.Time) error {
+	return r.db.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"failed_login_attempts": gorm.Expr("CASE WHEN failed_login_attempts + 1 >= ? THEN 0 ELSE failed_login_attempts + 1 END", threshold),
+		"locked_until":          gorm.Expr("CASE WHEN failed_login_attempts + 1 >= ? THEN ?::timestamptz ELSE locked_until END", threshold, lockUntil),
+		"last_failed_login_at":  time.Now(),
+	}).Error
+}
+
+// Reset counter login gagal & buka kunci akun (login sukses / unlock oleh admin)
+func (r *UserRepository) ResetLoginFailures(userID string) error {
+	return r.db.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"failed_login_attempts": 0,
+		"last_failed_login_at":  nil,
+		"locked_until":          nil,
+	}).Error
+}
+
+// Simpan password hash baru (tanpa mencatat riwayat, mis. rehash password yang sama dengan parameter baru)
+func (r *UserRepository) UpdatePassword(userID string, passwordHash string) error {
+	return r.db.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"password_hash": passwordHash,
+		"updated_at":    time.Now(),
+	}).Error
+}
+
+// ChangePassword mengganti password user dan memindahkan hash lama ke riwayat dalam satu transaksi.
+// Riwayat dipangkas agar hanya menyimpan keep hash terakhir.
+func (r *UserRepository) ChangePassword(userID string, passwordHash string, keep int) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var user model.User
+		if err := tx.Select("id", "password_hash").Where("id = ?", userID).First(&user).Error; err != nil {
+			return err
+		}
+
+		if keep > 0 {
+			if err := tx.Create(&model.PasswordHistory{UserID: userID, PasswordHash: user.PasswordHash}).Error; err != nil {
+				return err
+			}
+
+			// Hapus riwayat di luar keep baris terbaru
+			if err := tx.Where("user_id = ? AND id NOT IN (?)", userID,
+				tx.Model(&model.PasswordHistory{}).Select("id").Where("user_id = ?", userID).Order("created_at DESC").Limit(keep),
+			).Delete(&model.PasswordHistory{}).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"password_hash": passwordHash,
+			"updated_at":    time.Now(),
+		}).Error
+	})
+}
+
+// RecentPasswordHashes mengembalikan hash password saat ini diikuti riwayatnya (terbaru dulu), maksimal n
+func (r *UserRepository) RecentPasswordHashes(userID string, n int) ([]string, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+
+	var user model.User
+	if err := r.db.Select("id", "password_hash").Where("id = ?", userID).First(&user).Error; err != nil {
+		return nil, err
+	}
+
+	var history []string
+	if err := r.db.Model(&model.PasswordHistory{}).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(n-1).
+		Pluck("password_hash", &history).Error; err != nil {
+		return nil, err
+	}
+	return append([]string{user.PasswordHash}, history...), nil
+}
+
+// Cari Data Mahasiswa berdasarkan NIM
+func (r *UserRepository) FindStudentByNIM(nim string) (*model.Student, error) {
+	var student model.Student
+	err := r.db.Preload("User.Role").Where("student_id = ?", nim).First(&student).Error
+	return &student, err
+}
+
+// Cari Data Dosen berdasarkan NIP
+func (r *UserRepository) FindLecturerByNIP(nip string) (*model.Lecturer, error) {
+	var lecturer model.Lecturer
+	err := r.db.Preload("User.Role").Where("lecturer_id = ?", nip).First(&lecturer).Error
+	return &lecturer, err
+}
+
+// FindStudentIDOwner mencari pemegang NIM termasuk yang ada di trash (Unscoped), karena kolom student_id unique.
+// trashed = true jika NIM dipegang profil / user yang sudah dihapus (harus di-restore, bukan dibuat ulang).
+func (r *UserRepository) FindStudentIDOwner(nim string) (found bool, trashed bool, err error) {
+	var student model.Student
+	err = r.db.Unscoped().Preload("User", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).Where("student_id = ?", nim).First(&student).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	return true, student.DeletedAt.Valid || student.User.DeletedAt.Valid, nil
+}
+
+// FindLecturerIDOwner sama seperti FindStudentIDOwner untuk NIP dosen
+func (r *UserRepository) FindLecturerIDOwner(nip string) (found bool, trashed bool, err error) {
+	var lecturer model.Lecturer
+	err = r.db.Unscoped().Preload("User", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).Where("lecturer_id = ?", nip).First(&lecturer).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	return true, lecturer.DeletedAt.Valid || lecturer.User.DeletedAt.Valid, nil
+}
+
+// CreateWithProfile membuat user beserta profil Mahasiswa/Dosen (jika ada) dalam satu transaksi
+func (r *UserRepository) CreateWithProfile(user *model.User, student *model.Student, lecturer *model.Lecturer) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Role").Create(user).Error; err != nil {
+			return err
+		}
+
+		if student != nil {
+			student.UserID = user.ID
+			if err := tx.Omit("User", "Advisor").Create(student).Error; err != nil {
+				return err
+			}
+			if student.AdvisorID != nil {
+				if err := recordAdvisorChange(tx, student.ID, student.AdvisorID, nil, "Initial assignment", time.Now()); err != nil {
+					return err
+				}
+			}
+		}
+
+		if lecturer != nil {
+			lecturer.UserID = user.ID
+			if err := tx.Omit("User").Create(lecturer).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// --- Manajemen User (Admin) ---
+
+// UserFilter adalah filter tambahan untuk list user
+type UserFilter struct {
+	RoleID   string
+	IsActive *bool
+}
+
+// Kolom yang boleh dipakai untuk sorting (whitelist, nilai sortBy dari query string tidak boleh masuk langsung ke SQL)
+var userSortColumns = map[string]string{
+	"created_at": "users.created_at",
+	"updated_at": "users.updated_at",
+	"username":   "users.username",
+	"email":      "users.email",
+	"full_name":  "users.full_name",
+	"fullName":   "users.full_name",
+	"role":       "roles.name",
+}
+
+// List user dengan pagination, search (username/email/nama) & sort
+func (r *UserRepository) FindAll(param model.PaginationParam, filter UserFilter) ([]model.User, int64, error) {
+	var users []model.User
+	var total int64
+
+	// 1. Start Query
+	query := r.db.Model(&model.User{}).
+		Preload("Role").
+		Joins("LEFT JOIN roles ON roles.id = users.role_id")
+
+	// 2. Filter
+	if filter.RoleID != "" {
+		query = query.Where("users.role_id = ?", filter.RoleID)
+	}
+	if filter.IsActive != nil {
+		query = query.Where("users.is_active = ?", *filter.IsActive)
+	}
+
+	// 3. Search (Case Insensitive)
+	if param.Search != "" {
+		searchLower := "%" + strings.ToLower(param.Search) + "%"
+		query = query.Where("LOWER(users.username) LIKE ? OR LOWER(users.email) LIKE ? OR LOWER(users.full_name) LIKE ?", searchLower, searchLower, searchLower)
+	}
+
+	// 4. Count Total (Sebelum Limit/Offset)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// 5. Sorting
+	orderBy, ok := userSortColumns[param.SortBy]
+	if !ok {
+		orderBy = "users.created_at"
+	}
+	orderDir := "DESC"
+	if strings.ToUpper(param.Order) == "ASC" {
+		orderDir = "ASC"
+	}
+	query = query.Order(orderBy + " " + orderDir)
+
+	// 6. Pagination
+	offset := (param.Page - 1) * param.Limit
+	err := query.Limit(param.Limit).Offset(offset).Find(&users).Error
+	return users, total, err
+}
+
+// Cek apakah Dosen (lecturers.id) ada
+func (r *UserRepository) FindLecturerByID(id string) (*model.Lecturer, error) {
+	var lecturer model.Lecturer
+	err := r.db.Preload("User.Role").Where("id = ?", id).First(&lecturer).Error
+	return &lecturer, err
+}
+
+// Cek apakah mahasiswa sudah punya prestasi (prestasi tidak boleh kehilangan pemiliknya)
+func (r *UserRepository) StudentHasAchievements(studentID string) (bool, error) {
+	var count int64
+	err := r.db.Model(&model.AchievementReference{}).Where("student_id = ?", studentID).Count(&count).Error
+	return count > 0, err
+}
+
+// Cek apakah role masih dipakai user (termasuk user di trash, agar tetap bisa di-restore)
+func (r *UserRepository) IsRoleInUse(roleID string) (bool, error) {
+	var count int64
+	err := r.db.Unscoped().Model(&model.User{}).Where("role_id = ?", roleID).Count(&count).Error
+	return count > 0, err
+}
+
+// UpdateWithProfile mengubah data user beserta profil Mahasiswa/Dosen dalam satu transaksi.
+// Map yang kosong/nil dilewati.
+func (r *UserRepository) UpdateWithProfile(userID string, userUpdates, studentUpdates, lecturerUpdates map[string]interface{}) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if len(userUpdates) > 0 {
+			userUpdates["updated_at"] = time.Now()
+			if err := tx.Model(&model.User{}).Where("id = ?", userID).Updates(userUpdates).Error; err != nil {
+				return err
+			}
+		}
+		if len(studentUpdates) > 0 {
+			if err := tx.Model(&model.Student{}).Where("user_id = ?", userID).Updates(studentUpdates).Error; err != nil {
+				return err
+			}
+		}
+		if len(lecturerUpdates) > 0 {
+			if err := tx.Model(&model.Lecturer{}).Where("user_id = ?", userID).Updates(lecturerUpdates).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ChangeRole mengganti role user sekaligus menyesuaikan profilnya dalam satu transaksi:
+// student/lecturer yang belum punya ID dibuat baru, profil yang nil dihapus (jika ada).
+func (r *UserRepository) ChangeRole(userID string, roleID string, student *model.Student, lecturer *model.Lecturer) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"role_id":    roleID,
+			"updated_at": time.Now(),
+		}).Error; err != nil {
+			return err
+		}
+
+		// Profil lama dihapus permanen (bukan soft delete), agar NIM/NIP bisa dipakai lagi
+		if student == nil {
+			if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&model.Student{}).Error; err != nil {
+				return err
+			}
+		} else if student.ID == "" {
+			student.UserID = userID
+			if err := tx.Omit("User", "Advisor").Create(student).Error; err != nil {
+				return err
+			}
+			if student.AdvisorID != nil {
+				if err := recordAdvisorChange(tx, student.ID, student.AdvisorID, nil, "Initial assignment", time.Now()); err != nil {
+					return err
+				}
+			}
+		}
+
+		if lecturer == nil {
+			if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&model.Lecturer{}).Error; err != nil {
+				return err
+			}
+		} else if lecturer.ID == "" {
+			lecturer.UserID = userID
+			if err := tx.Omit("User").Create(lecturer).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Delete memindahkan user beserta profilnya ke trash (soft delete) dan mencabut semua API token-nya.
+// Prestasi & riwayat verifikasi tetap utuh.
+func (r *UserRepository) Delete(userID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.APIToken{}).
+			Where("user_id = ? AND revoked_at IS NULL", userID).
+			Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&model.Student{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", userID).Delete(&model.Lecturer{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("id = ?", userID).Delete(&model.User{}).Error
+	})
+}
+
+// Restore mengembalikan user dari trash beserta profilnya
+func (r *UserRepository) Restore(userID string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&model.Student{}).Where("user_id = ?", userID).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Model(&model.Lecturer{}).Where("user_id = ?", userID).Update("deleted_at", nil).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+			"deleted_at": nil,
+			"updated_at": time.Now(),
+		}).Error
+	})
+}
+
+// FindDeletedByID mencari user yang ada di trash
+func (r *UserRepository) FindDeletedByID(id string) (*model.User, error) {
+	var user model.User
+	err := r.db.Unscoped().Preload("Role").Where("id = ? AND deleted_at IS NOT NULL", id).First(&user).Error
+	return &user, err
+}
+
+// FindDeleted: list user di trash dengan pagination & search, terbaru dihapus di atas
+func (r *UserRepository) FindDeleted(param model.PaginationParam) ([]model.User, int64, error) {
+	var users []model.User
+	var total int64
+
+	query := r.db.Unscoped().Model(&model.User{}).Preload("Role").Where("users.deleted_at IS NOT NULL")
+	if param.Search != "" {
+		searchLower := "%" + strings.ToLower(param.Search) + "%"
+		query = query.Where("LOWER(users.username) LIKE ? OR LOWER(users.email) LIKE ? OR LOWER(users.full_name) LIKE ?", searchLower, searchLower, searchLower)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (param.Page - 1) * param.Limit
+	err := query.Order("users.deleted_at DESC").Limit(param.Limit).Offset(offset).Find(&users).Error
+	return users, total, err
+}
+
+// SetActive mengaktifkan / menonaktifkan akun user
+func (r *UserRepository) SetActive(userID string, active bool) error {
+	return r.db.Model(&model.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"is_active":  active,
+		"updated_at": time.Now(),
+	}).Error
+}
+
+// --- Dosen Wali (riwayat bimbingan) ---
+
+// Cari mahasiswa berdasarkan students.id beserta user & dosen wali-nya
+func (r *UserRepository) FindStudentByID(id string) (*model.Student, error) {
+	var student model.Student
+	err := r.db.Preload("User").Preload("Advisor.User").Preload("ProgramStudyRef.Department.Faculty").Preload("AcademicYearRef").Where("id = ?", id).First(&student).Error
+	return &student, err
+}
+
+// AssignAdvisor mengganti dosen wali mahasiswa (nil = tanpa dosen wali) dan mencatat riwayatnya.
+// Return false jika dosen wali tidak berubah.
+func (r *UserRepository) AssignAdvisor(studentID string, lecturerID *string, assignedBy *string, reason string) (bool, error) {
+	changed := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		// Kunci baris mahasiswa agar dua perubahan bersamaan tidak membuat periode yang tumpang tindih
+		var student model.Student
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", studentID).First(&student).Error; err != nil {
+			return err
+		}
+		if sameAdvisor(student.AdvisorID, lecturerID) {
+			return nil
+		}
+
+		if err := tx.Model(&model.Student{}).Where("id = ?", studentID).Update("advisor_id", lecturerID).Error; err != nil {
+			return err
+		}
+		changed = true
+		return recordAdvisorChange(tx, studentID, lecturerID, assignedBy, reason, time.Now())
+	})
+	return changed, err
+}
+
+// ReassignAdvisees memindahkan semua mahasiswa bimbingan dari satu dosen ke dosen lain.
+// Return ID mahasiswa yang dipindahkan.
+func (r *UserRepository) ReassignAdvisees(fromLecturerID string, toLecturerID string, assignedBy *string, reason string) ([]string, error) {
+	var studentIDs []string
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&model.Student{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("advisor_id = ?", fromLecturerID).
+			Pluck("id", &studentIDs).Error; err != nil {
+			return err
+		}
+		if len(studentIDs) == 0 {
+			return nil
+		}
+
+		if err := tx.Model(&model.Student{}).Where("id IN ?", studentIDs).Update("advisor_id", toLecturerID).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&model.AdvisorAssignment{}).
+			Where("student_id IN ? AND effective_to IS NULL", studentIDs).
+			Update("effective_to", now).Error; err != nil {
+			return err
+		}
+
+		rows := make([]model.AdvisorAssignment, 0, len(studentIDs))
+		for _, id := range studentIDs {
+			rows = append(rows, model.AdvisorAssignment{
+				StudentID:     id,
+				LecturerID:    toLecturerID,
+				EffectiveFrom: now,
+				AssignedBy:    assignedBy,
+				Reason:        reason,
+			})
+		}
+		return tx.Omit("Lecturer").CreateInBatches(&rows, 500).Error
+	})
+	return studentIDs, err
+}
+
+// FindAdvisorHistory mengambil riwayat dosen wali mahasiswa, terbaru di atas.
+// Dosen yang sudah dihapus (trash) tetap ditampilkan.
+func (r *UserRepository) FindAdvisorHistory(studentID string) ([]model.AdvisorAssignment, error) {
+	var history []model.AdvisorAssignment
+	err := r.db.
+		Preload("Lecturer", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Lecturer.User", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Where("student_id = ?", studentID).
+		Order("effective_from DESC").
+		Find(&history).Error
+	return history, err
+}
+
+// recordAdvisorChange menutup periode bimbingan yang sedang berjalan dan membuka periode baru
+// (jika lecturerID tidak nil). Dipanggil di dalam transaksi yang mengubah students.advisor_id.
+func recordAdvisorChange(tx *gorm.DB, studentID string, lecturerID *string, assignedBy *string, reason string, at time.Time) error {
+	if err := tx.Model(&model.AdvisorAssignment{}).
+		Where("student_id = ? AND effective_to IS NULL", studentID).
+		Update("effective_to", at).Error; err != nil {
+		return err
+	}
+	if lecturerID == nil {
+		return nil
+	}
+	return tx.Omit("Lecturer").Create(&model.AdvisorAssignment{
+		StudentID:     studentID,
+		LecturerID:    *lecturerID,
+		EffectiveFrom: at,
+		AssignedBy:    assignedBy,
+		Reason:        reason,
+	}).Error
+}
+
+func sameAdvisor(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"errors"
+	"time"
+	"uas/app/model"
+	"uas/app/repository"
+	"uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// --- LIFECYCLE PRESTASI ---
+//
+//	draft --submit--> submitted --verify--> verified --revoke--> revoked
+//	  ^                  |   \
+//	  |               withdraw reject
+//	  |                  v      v
+//	  +------revise--- draft  rejected
+//
+// Semua perpindahan status prestasi harus lewat tabel transisi di bawah. Setiap transisi
+// menentukan siapa yang boleh menjalankannya dan kolom apa saja yang diisi / dikosongkan.
+
+// achievementActor adalah peran user terhadap satu prestasi (bisa lebih dari satu sekaligus)
+type achievementActor int
+
+const (
+	actorOwner   achievementActor = 1 << iota // Mahasiswa pemilik prestasi
+	actorAdvisor                              // Dosen wali mahasiswa saat ini (permission achievement:verify)
+	actorAdmin                                // Admin (permission user:manage)
+)
+
+// Nama aksi transisi
+const (
+	actionSubmit   = "submit"
+	actionWithdraw = "withdraw"
+	actionVerify   = "verify"
+	actionReject   = "reject"
+	actionRevise   = "revise" // Prestasi rejected diubah pemiliknya, kembali menjadi draft
+	actionRevoke   = "revoke"
+)
+
+type achievementTransition struct {
+	From         string
+	To           string
+	Past         string // Bentuk lampau aksi untuk pesan error, mis. "verified"
+	Actors       achievementActor
+	ActorLabel   string   // Untuk pesan error, mis. "the owner"
+	Stamps       []string // Kolom waktu yang diisi waktu transisi
+	ActorColumn  string   // Kolom yang diisi user pelaku, mis. verified_by
+	Clears       []string // Kolom yang dikosongkan
+	NoteRequired bool
+	NoteColumn   string // Kolom tempat catatan disimpan (selain di riwayat)
+	Snapshot     bool   // Simpan dosen wali mahasiswa saat ini di advisor_id
+	Points       bool   // Simpan poin dari dosen wali
+}
+
+var achievementTransitions = map[string]achievementTransition{
+	actionSubmit: {
+		From: model.AchievementDraft, To: model.AchievementSubmitted, Past: "submitted",
+		Actors: actorOwner, ActorLabel: "the owner",
+		Stamps: []string{"submitted_at"},
+	},
+	actionWithdraw: {
+		From: model.AchievementSubmitted, To: model.AchievementDraft, Past: "withdrawn",
+		Actors: actorOwner, ActorLabel: "the owner",
+		Clears: []string{"submitted_at"},
+	},
+	actionVerify: {
+		From: model.AchievementSubmitted, To: model.AchievementVerified, Past: "verified",
+		Actors: actorAdvisor, ActorLabel: "the student's advisor",
+		Stamps: []string{"verified_at"}, ActorColumn: "verified_by",
+		Snapshot: true, Points: true,
+	},
+	actionReject: {
+		From: model.AchievementSubmitted, To: model.AchievementRejected, Past: "rejected",
+		Actors: actorAdvisor, ActorLabel: "the student's advisor",
+		Stamps: []string{"verified_at"}, ActorColumn: "verified_by",
+		NoteRequired: true, NoteColumn: "rejection_note", Snapshot: true,
+	},
+	actionRevise: {
+		From: model.AchievementRejected, To: model.AchievementDraft, Past: "revised",
+		Actors: actorOwner, ActorLabel: "the owner",
+		Clears: []string{"submitted_at", "verified_at", "verified_by", "advisor_id", "rejection_note"},
+	},
+	actionRevoke: {
+		From: model.AchievementVerified, To: model.AchievementRevoked, Past: "revoked",
+		Actors: actorAdmin, ActorLabel: "an admin",
+		Stamps: []string{"revoked_at"}, ActorColumn: "revoked_by",
+		NoteRequired: true, Clears: []string{"points"},
+	},
+}
+
+// Status yang masih boleh diubah isinya / dihapus pemiliknya
+var (
+	editableStatuses  = map[string]bool{model.AchievementDraft: true, model.AchievementRejected: true}
+	deletableStatuses = map[string]bool{model.AchievementDraft: true}
+)
+
+// achievementActorOf menentukan peran user login terhadap prestasi milik student
+// (student harus di-preload dengan Advisor)
+func achievementActorOf(c *fiber.Ctx, student *model.Student) achievementActor {
+	userID := c.Locals("user_id").(string)
+	permissions, _ := c.Locals("permissions").([]string)
+
+	var actor achievementActor
+	if student.UserID == userID {
+		actor |= actorOwner
+	}
+	if student.Advisor != nil && student.Advisor.UserID == userID && utils.HasPermission(permissions, "achievement:verify") {
+		actor |= actorAdvisor
+	}
+	if utils.HasPermission(permissions, "user:manage") {
+		actor |= actorAdmin
+	}
+	return actor
+}
+
+// statusChange memvalidasi transisi (pelaku, status asal, catatan) lalu menyusun perubahan kolomnya.
+// Return status HTTP & pesan error (kosong jika transisi boleh dijalankan).
+func statusChange(c *fiber.Ctx, action string, ref *model.AchievementReference, student *model.Student, note string, points int) (*repository.StatusChange, int, string) {
+	t := achievementTransitions[action]
+
+	if achievementActorOf(c, student)&t.Actors == 0 {
+		return nil, 403, "Only " + t.ActorLabel + " can " + action + " this achievement"
+	}
+	if ref.Status != t.From {
+		return nil, 409, "Cannot " + action + " an achievement that is " + ref.Status + ", only " + t.From + " achievements can be " + t.Past
+	}
+	if t.NoteRequired && note == "" {
+		return nil, 400, "A note is required to " + action + " an achievement"
+	}
+	if t.Points && points < 0 {
+		return nil, 400, "Points must not be negative"
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{}
+	for _, column := range t.Stamps {
+		updates[column] = now
+	}
+	for _, column := range t.Clears {
+		switch column {
+		case "rejection_note":
+			updates[column] = ""
+		case "points":
+			updates[column] = 0
+		default:
+			updates[column] = nil
+		}
+	}
+
+	history := newStatusHistory(c, note, nil)
+	history.CreatedAt = now
+	if t.ActorColumn != "" {
+		updates[t.ActorColumn] = *history.ActorID
+	}
+	if t.NoteColumn != "" {
+		updates[t.NoteColumn] = note
+	}
+	if t.Snapshot {
+		updates["advisor_id"] = gorm.Expr("(SELECT students.advisor_id FROM students WHERE students.id = achievement_references.student_id)")
+	}
+	if t.Points {
+		updates["points"] = points
+		history.Points = &points
+	}
+
+	return &repository.StatusChange{From: t.From, To: t.To, Updates: updates, History: history}, 0, ""
+}
+
+// transition menjalankan satu aksi lifecycle pada prestasi. Return status HTTP & pesan error (kosong jika berhasil).
+func (s *AchievementService) transition(c *fiber.Ctx, action string, note string, points int) (int, string) {
+	ref, err := s.achRepo.FindReference(c.Params("id"))
+	if err != nil {
+		return 404, "Achievement not found"
+	}
+	student, err := s.userRepo.FindStudentByID(ref.StudentID)
+	if err != nil {
+		return 404, "Student profile not found"
+	}
+
+	change, status, msg := statusChange(c, action, ref, student, note, points)
+	if msg != "" {
+		return status, msg
+	}
+
+	if err := s.achRepo.TransitionStatus(ref.ID, *change); err != nil {
+		if errors.Is(err, repository.ErrStatusConflict) {
+			return 409, "Achievement status changed while processing the request, reload and try again"
+		}
+		return 500, err.Error()
+	}
+	return 0, ""
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"net/http/httptest"
+	"testing"
+	"uas/app/model"
+	"uas/app/repository"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Pelaku yang diuji terhadap satu prestasi milik "u-owner" dengan dosen wali "u-advisor"
+var lifecycleActors = map[string]map[string]interface{}{
+	"owner":         {"user_id": "u-owner", "permissions": []string{"achievement:create", "achievement:update", "achievement:delete"}},
+	"advisor":       {"user_id": "u-advisor", "permissions": []string{"achievement:verify"}},
+	"otherLecturer": {"user_id": "u-lecturer", "permissions": []string{"achievement:verify"}},
+	"admin":         {"user_id": "u-admin", "permissions": []string{"user:manage"}},
+	"otherStudent":  {"user_id": "u-student", "permissions": []string{"achievement:create"}},
+	// Dosen wali yang permission verify-nya dicabut tidak boleh memverifikasi
+	"advisorNoVerify": {"user_id": "u-advisor", "permissions": []string{"achievement:read"}},
+}
+
+var lifecycleStatuses = []string{
+	model.AchievementDraft,
+	model.AchievementSubmitted,
+	model.AchievementVerified,
+	model.AchievementRejected,
+	model.AchievementRevoked,
+}
+
+func lifecycleStudent() *model.Student {
+	return &model.Student{
+		ID:      "s-1",
+		UserID:  "u-owner",
+		Advisor: &model.Lecturer{ID: "l-1", UserID: "u-advisor"},
+	}
+}
+
+// runStatusChange menjalankan statusChange di dalam request fiber dengan locals pelaku
+func runStatusChange(t *testing.T, locals map[string]interface{}, action string, ref *model.AchievementReference, note string, points int) (*repository.StatusChange, int, string) {
+	t.Helper()
+
+	var change *repository.StatusChange
+	var status int
+	var msg string
+
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		for key, value := range locals {
+			c.Locals(key, value)
+		}
+		change, status, msg = statusChange(c, action, ref, lifecycleStudent(), note, points)
+		return nil
+	})
+	if _, err := app.Test(httptest.NewRequest("GET", "/", nil)); err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	return change, status, msg
+}
+
+func TestAchievementTransitions(t *testing.T) {
+	// Aturan lifecycle yang diharapkan: aksi -> status asal, status tujuan & pelaku yang boleh
+	rules := map[string]struct {
+		from   string
+		to     string
+		actors []string
+	}{
+		actionSubmit:   {model.AchievementDraft, model.AchievementSubmitted, []string{"owner"}},
+		actionWithdraw: {model.AchievementSubmitted, model.AchievementDraft, []string{"owner"}},
+		actionVerify:   {model.AchievementSubmitted, model.AchievementVerified, []string{"advisor"}},
+		actionReject:   {model.AchievementSubmitted, model.AchievementRejected, []string{"advisor"}},
+		actionRevise:   {model.AchievementRejected, model.AchievementDraft, []string{"owner"}},
+		actionRevoke:   {model.AchievementVerified, model.AchievementRevoked, []string{"admin"}},
+	}
+	if len(rules) != len(achievementTransitions) {
+		t.Fatalf("expected %d transitions, table has %d", len(rules), len(achievementTransitions))
+	}
+
+	for action, rule := range rules {
+		for actorName, locals := range lifecycleActors {
+			for _, from := range lifecycleStatuses {
+				allowedActor := false
+				for _, a := range rule.actors {
+					allowedActor = allowedActor || a == actorName
+				}
+
+				ref := &model.AchievementReference{ID: "a-1", StudentID: "s-1", Status: from}
+				change, status, msg := runStatusChange(t, locals, action, ref, "catatan", 10)
+
+				switch {
+				case !allowedActor:
+					if status != 403 || change != nil {
+						t.Errorf("%s by %s from %s: expected 403, got %d %q", action, actorName, from, status, msg)
+					}
+				case from != rule.from:
+					if status != 409 || change != nil {
+						t.Errorf("%s by %s from %s: expected 409, got %d %q", action, actorName, from, status, msg)
+					}
+				default:
+					if msg != "" || change == nil {
+						t.Fatalf("%s by %s from %s: expected success, got %d %q", action, actorName, from, status, msg)
+					}
+					if change.From != rule.from || change.To != rule.to {
+						t.Errorf("%s: expected %s -> %s, got %s -> %s", action, rule.from, rule.to, change.From, change.To)
+					}
+					if change.History == nil || change.History.ActorID == nil || *change.History.ActorID != locals["user_id"] {
+						t.Errorf("%s: history actor not recorded", action)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestAchievementTransitionColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		actor   string
+		action  string
+		from    string
+		points  int
+		set     []string // Kolom yang harus diisi
+		cleared []string // Kolom yang harus dikosongkan
+		absent  []string // Kolom yang tidak boleh disentuh
+	}{
+		{"submit stamps submitted_at only", "owner", actionSubmit, model.AchievementDraft, 0,
+			[]string{"submitted_at"}, nil, []string{"verified_at", "verified_by", "points"}},
+		{"withdraw clears submitted_at", "owner", actionWithdraw, model.AchievementSubmitted, 0,
+			nil, []string{"submitted_at"}, []string{"verified_at"}},
+		{"verify stamps verifier and points", "advisor", actionVerify, model.AchievementSubmitted, 25,
+			[]string{"verified_at", "verified_by", "advisor_id", "points"}, nil, []string{"rejection_note"}},
+		{"reject stamps verifier and note", "advisor", actionReject, model.AchievementSubmitted, 0,
+			[]string{"verified_at", "verified_by", "advisor_id", "rejection_note"}, nil, []string{"points"}},
+		{"revise resets review columns", "owner", actionRevise, model.AchievementRejected, 0,
+			nil, []string{"submitted_at", "verified_at", "verified_by", "advisor_id", "rejection_note"}, nil},
+		{"revoke stamps revoker and zeroes points", "admin", actionRevoke, model.AchievementVerified, 0,
+			[]string{"revoked_at", "revoked_by"}, []string{"points"}, []string{"verified_at", "verified_by"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ref := &model.AchievementReference{ID: "a-1", StudentID: "s-1", Status: tt.from}
+			change, status, msg := runStatusChange(t, lifecycleActors[tt.actor], tt.action, ref, "catatan", tt.points)
+			if msg != "" {
+				t.Fatalf("unexpected error %d %q", status, msg)
+			}
+
+			for _, column := range tt.set {
+				if value, ok := change.Updates[column]; !ok || value == nil || value == "" {
+					t.Errorf("expected %s to be set, got %v", column, value)
+				}
+			}
+			for _, column := range tt.cleared {
+				value, ok := change.Updates[column]
+				if !ok || (value != nil && value != "" && value != 0) {
+					t.Errorf("expected %s to be cleared, got %v", column, value)
+				}
+			}
+			for _, column := range tt.absent {
+				if _, ok := change.Updates[column]; ok {
+					t.Errorf("expected %s to be untouched", column)
+				}
+			}
+		})
+	}
+
+	t.Run("verify records points in history", func(t *testing.T) {
+		ref := &model.AchievementReference{ID: "a-1", StudentID: "s-1", Status: model.AchievementSubmitted}
+		change, _, _ := runStatusChange(t, lifecycleActors["advisor"], actionVerify, ref, "", 25)
+		if change.Updates["points"] != 25 || change.History.Points == nil || *change.History.Points != 25 {
+			t.Errorf("expected 25 points, got %v / %v", change.Updates["points"], change.History.Points)
+		}
+		if change.Updates["verified_by"] != "u-advisor" {
+			t.Errorf("expected verified_by u-advisor, got %v", change.Updates["verified_by"])
+		}
+	})
+}
+
+func TestAchievementTransitionValidation(t *testing.T) {
+	submitted := &model.AchievementReference{ID: "a-1", StudentID: "s-1", Status: model.AchievementSubmitted}
+	verified := &model.AchievementReference{ID: "a-1", StudentID: "s-1", Status: model.AchievementVerified}
+
+	tests := []struct {
+		name   string
+		actor  string
+		action string
+		ref    *model.AchievementReference
+		note   string
+		points int
+		status int
+	}{
+		{"reject requires a note", "advisor", actionReject, submitted, "", 0, 400},
+		{"revoke requires a note", "admin", actionRevoke, verified, "", 0, 400},
+		{"verify rejects negative points", "advisor", actionVerify, submitted, "", -1, 400},
+		{"verify allows zero points", "advisor", actionVerify, submitted, "", 0, 0},
+		{"withdraw needs no note", "owner", actionWithdraw, submitted, "", 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, status, msg := runStatusChange(t, lifecycleActors[tt.actor], tt.action, tt.ref, tt.note, tt.points)
+			if status != tt.status {
+				t.Errorf("expected status %d, got %d %q", tt.status, status, msg)
+			}
+		})
+	}
+}
+
+func TestAchievementTransitionImpersonation(t *testing.T) {
+	locals := map[string]interface{}{
+		"user_id":         "u-owner",
+		"permissions":     []string{"achievement:create"},
+		"impersonator_id": "u-helpdesk",
+	}
+	ref := &model.AchievementReference{ID: "a-1", StudentID: "s-1", Status: model.AchievementDraft}
+
+	change, _, msg := runStatusChange(t, locals, actionSubmit, ref, "", 0)
+	if msg != "" {
+		t.Fatalf("unexpected error %q", msg)
+	}
+	if change.History.ImpersonatorID == nil || *change.History.ImpersonatorID != "u-helpdesk" {
+		t.Errorf("expected impersonator to be recorded, got %v", change.History.ImpersonatorID)
+	}
+}
+
+func TestAchievementEditableStatuses(t *testing.T) {
+	tests := []struct {
+		status    string
+		editable  bool
+		deletable bool
+	}{
+		{model.AchievementDraft, true, true},
+		{model.AchievementSubmitted, false, false},
+		{model.AchievementVerified, false, false},
+		{model.AchievementRejected, true, false},
+		{model.AchievementRevoked, false, false},
+	}
+
+	for _, tt := range tests {
+		if editableStatuses[tt.status] != tt.editable {
+			t.Errorf("%s: expected editable=%v", tt.status, tt.editable)
+		}
+		if deletableStatuses[tt.status] != tt.deletable {
+			t.Errorf("%s: expected deletable=%v", tt.status, tt.deletable)
+		}
+	}
+}
@@ -1,14 +1,15 @@
 package service
 
 import (
-	"math"
+	"errors"
+	"strings"
 	"uas/app/model"
 	"uas/app/repository"
-	// "strings"
 	// "time"
 
 	"github.com/gofiber/fiber/v2"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gorm.io/gorm"
 )
 
 type AchievementService struct {
@@ -59,11 +60,11 @@ func (s *AchievementService) Submit(c *fiber.Ctx) error {
 	pgData := model.AchievementReference{
 		StudentID: student.ID,
 		Title:     req.Title,   // Untuk keperluan Search/Sort (Modul 6)
-		Status:    "draft",     // Status Awal
+		Status:    model.AchievementDraft, // Status Awal
 	}
 
 	// 5. Simpan (Hybrid Transaction)
-	if err := s.achRepo.Create(c.Context(), &mongoData, &pgData); err != nil {
+	if err := s.achRepo.Create(c.Context(), &mongoData, &pgData, newStatusHistory(c, "", nil)); err != nil {
 		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
 	}
 
@@ -76,39 +77,123 @@ func (s *AchievementService) Submit(c *fiber.Ctx) error {
 }
 
 // FR-004: Submit untuk Verifikasi
-// Desc: Mengubah status 'draft' -> 'submitted'
+// Desc: Mengubah status 'draft' -> 'submitted' (hanya pemilik, lihat achievement_lifecycle.go)
 func (s *AchievementService) RequestVerification(c *fiber.Ctx) error {
+	if status, msg := s.transition(c, actionSubmit, "", 0); msg != "" {
+		return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+	}
+
+	// (Optional) Create Notification untuk Dosen Wali (TODO: Implement Notification Service)
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Prestasi diajukan untuk verifikasi"})
+}
+
+// Withdraw Pengajuan
+// Desc: Mahasiswa menarik kembali pengajuan yang belum diproses, 'submitted' -> 'draft'
+func (s *AchievementService) Withdraw(c *fiber.Ctx) error {
+	if status, msg := s.transition(c, actionWithdraw, "", 0); msg != "" {
+		return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Pengajuan prestasi ditarik kembali menjadi draft"})
+}
+
+// Update Prestasi
+// Desc: Mahasiswa mengubah prestasi miliknya selama status 'draft' atau 'rejected'.
+// Prestasi 'rejected' kembali menjadi 'draft' dan catatan penolakannya dihapus.
+func (s *AchievementService) Update(c *fiber.Ctx) error {
 	id := c.Params("id")
 	userID := c.Locals("user_id").(string)
 
-	// 1. Validasi Kepemilikan (Apakah user ini pemilik prestasi?)
-	// Logic sederhana: Cek di repo atau ambil studentID user ini
+	// 1. Parse Input (field yang tidak dikirim tidak berubah)
+	var req struct {
+		AchievementType *string                        `json:"achievementType"`
+		Title           *string                        `json:"title"`
+		Description     *string                        `json:"description"`
+		Details         *model.AchievementDetails      `json:"details"`
+		Attachments     *[]model.AchievementAttachment `json:"attachments"`
+		Tags            *[]string                      `json:"tags"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid input"})
+	}
+
+	// 2. Validasi Kepemilikan & Status
 	student, err := s.userRepo.FindStudentByUserID(userID)
 	if err != nil {
 		return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "Unauthorized"})
 	}
 
-	// 2. Cek Status Sekarang (Harus Draft)
-	ref, _, err := s.achRepo.FindDetail(c.Context(), id)
+	ref, content, err := s.achRepo.FindDetail(c.Context(), id)
 	if err != nil {
-		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Achievement not found"})
+		if ref == nil {
+			return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Achievement not found"})
+		}
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
 	}
 	if ref.StudentID != student.ID {
 		return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "Not your achievement"})
 	}
-	if ref.Status != "draft" {
-		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Only draft can be submitted"})
+	if !editableStatuses[ref.Status] {
+		return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: "Cannot update an achievement that is " + ref.Status + ", only draft or rejected achievements can be updated"})
+	}
+
+	// Prestasi rejected kembali menjadi draft (transisi revise)
+	var change *repository.StatusChange
+	if ref.Status == model.AchievementRejected {
+		var status int
+		var msg string
+		if change, status, msg = statusChange(c, actionRevise, ref, student, "", 0); msg != "" {
+			return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+		}
+	}
+
+	// 3. Mapping perubahan ke dokumen Mongo
+	if req.AchievementType != nil {
+		content.AchievementType = strings.TrimSpace(*req.AchievementType)
+	}
+	if req.Title != nil {
+		content.Title = strings.TrimSpace(*req.Title)
+	}
+	if req.Description != nil {
+		content.Description = *req.Description
+	}
+	if req.Details != nil {
+		content.Details = *req.Details
+	}
+	if req.Attachments != nil {
+		content.Attachments = *req.Attachments
+	}
+	if req.Tags != nil {
+		content.Tags = *req.Tags
 	}
 
-	// 3. Update Status ke 'submitted'
-	// Note: 'submitted_at' diupdate di repo
-	if err := s.achRepo.UpdateStatus(id, "submitted", "", "", 0); err != nil {
+	if content.Title == "" || len(content.Title) > 255 {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Title must be 1-255 characters"})
+	}
+	if content.AchievementType == "" {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Achievement type is required"})
+	}
+
+	// 4. Simpan (Mongo & Postgres bersamaan, dengan kompensasi)
+	if err := s.achRepo.UpdateContent(c.Context(), ref, content, change); err != nil {
+		if errors.Is(err, repository.ErrStatusConflict) {
+			return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: "Achievement status changed, only draft or rejected achievements can be updated"})
+		}
 		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
 	}
 
-	// 4. (Optional) Create Notification untuk Dosen Wali (TODO: Implement Notification Service)
+	updated, content, err := s.achRepo.FindDetail(c.Context(), id)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
 
-	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Prestasi diajukan untuk verifikasi"})
+	return c.JSON(model.WebResponse{
+		Code:    200,
+		Status:  "success",
+		Message: "Prestasi berhasil diperbarui",
+		Data:    fiber.Map{"meta": updated, "content": content},
+	})
 }
 
 // FR-005: Hapus Prestasi
@@ -118,10 +203,13 @@ func (s *AchievementService) Delete(c *fiber.Ctx) error {
 	userID := c.Locals("user_id").(string)
 
 	// 1. Validasi Kepemilikan
-	student, _ := s.userRepo.FindStudentByUserID(userID)
-	
+	student, err := s.userRepo.FindStudentByUserID(userID)
+	if err != nil {
+		return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "Forbidden"})
+	}
+
 	// 2. Cek Detail & Status
-	ref, _, err := s.achRepo.FindDetail(c.Context(), id)
+	ref, err := s.achRepo.FindReference(id)
 	if err != nil {
 		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Not found"})
 	}
@@ -130,10 +218,16 @@ func (s *AchievementService) Delete(c *fiber.Ctx) error {
 	if ref.StudentID != student.ID {
 		return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "Forbidden"})
 	}
+	if !deletableStatuses[ref.Status] {
+		return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: "Cannot delete an achievement that is " + ref.Status + ", only draft achievements can be deleted"})
+	}
 
-	// 3. Hapus (Repo akan validasi status 'draft')
+	// 3. Hapus (Repo menghapus hanya jika status masih 'draft')
 	if err := s.achRepo.Delete(c.Context(), id); err != nil {
-		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: err.Error()})
+		if errors.Is(err, repository.ErrStatusConflict) {
+			return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: "Achievement status changed, only draft achievements can be deleted"})
+		}
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
 	}
 
 	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Prestasi draft berhasil dihapus"})
@@ -154,7 +248,7 @@ func (s *AchievementService) GetAdviseeAchievements(c *fiber.Ctx) error {
 	}
 
 	// 2. Parse Parameter Pagination (Modul 6)
-	param := s.parsePagination(c)
+	param := parsePagination(c)
 
 	// 3. Get Data dengan Filter AdvisorID
 	data, total, err := s.achRepo.FindAll(param, "", lecturer.ID) // Filter by Advisor ID
@@ -162,49 +256,41 @@ func (s *AchievementService) GetAdviseeAchievements(c *fiber.Ctx) error {
 		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
 	}
 
-	return s.sendPaginationResponse(c, data, total, param)
+	return sendPaginationResponse(c, data, total, param)
 }
 
 // FR-007: Verify Prestasi
-// Desc: Dosen approve prestasi, status -> 'verified', set points
+// Desc: Dosen wali mahasiswa approve prestasi, status 'submitted' -> 'verified', set points
 func (s *AchievementService) Verify(c *fiber.Ctx) error {
-	id := c.Params("id")
-	
 	// Input Body: Bisa jadi dosen ingin memberi poin spesifik
 	var req struct {
 		Points int `json:"points"`
 	}
 	c.BodyParser(&req)
 
-	userID := c.Locals("user_id").(string) // ID User Dosen
-
-	// 1. Update Status 
-	// Status: verified, VerifiedBy: userID, Points: req.Points
-	if err := s.achRepo.UpdateStatus(id, "verified", userID, "", req.Points); err != nil {
-		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	// Status: verified, VerifiedBy: user login (dosen), Points: req.Points
+	if status, msg := s.transition(c, actionVerify, "", req.Points); msg != "" {
+		return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
 	}
 
 	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Prestasi berhasil diverifikasi"})
 }
 
 // FR-008: Reject Prestasi
-// Desc: Dosen tolak prestasi dengan catatan
+// Desc: Dosen wali mahasiswa tolak prestasi dengan catatan, status 'submitted' -> 'rejected'
 func (s *AchievementService) Reject(c *fiber.Ctx) error {
-	id := c.Params("id")
-	userID := c.Locals("user_id").(string)
-
 	// 1. Parse Rejection Note [cite: 1723]
 	var req struct {
 		Note string `json:"note" validate:"required"`
 	}
-	if err := c.BodyParser(&req); err != nil || req.Note == "" {
+	if err := c.BodyParser(&req); err != nil || strings.TrimSpace(req.Note) == "" {
 		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Rejection note is required"})
 	}
 
 	// 2. Update Status [cite: 1726-1727]
-	// Status: rejected, VerifiedBy: userID, Note: req.Note
-	if err := s.achRepo.UpdateStatus(id, "rejected", userID, req.Note, 0); err != nil {
-		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	// Status: rejected, VerifiedBy: user login (dosen), Note: req.Note
+	if status, msg := s.transition(c, actionReject, strings.TrimSpace(req.Note), 0); msg != "" {
+		return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
 	}
 
 	// 3. (Optional) Notify Mahasiswa [cite: 1728]
@@ -212,6 +298,69 @@ func (s *AchievementService) Reject(c *fiber.Ctx) error {
 	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Prestasi ditolak"})
 }
 
+// Revoke Verifikasi (Admin)
+// Desc: Admin mencabut verifikasi yang keliru, status 'verified' -> 'revoked', poin dinolkan. Alasan wajib diisi.
+func (s *AchievementService) Revoke(c *fiber.Ctx) error {
+	var req struct {
+		Note string `json:"note"`
+	}
+	if err := c.BodyParser(&req); err != nil || strings.TrimSpace(req.Note) == "" {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Revocation note is required"})
+	}
+
+	if status, msg := s.transition(c, actionRevoke, strings.TrimSpace(req.Note), 0); msg != "" {
+		return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Verifikasi prestasi dicabut"})
+}
+
+// Riwayat Status Prestasi
+// Desc: Semua perpindahan status (siapa, kapan, catatan & poin), urut dari yang paling lama.
+// Bisa dilihat pemilik, dosen wali-nya saat ini, dan Admin (user:manage).
+func (s *AchievementService) GetHistory(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	// Cukup metadata Postgres, isi prestasi di Mongo tidak diperlukan
+	ref, err := s.achRepo.FindReference(id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Achievement not found"})
+		}
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+
+	student, err := s.userRepo.FindStudentByID(ref.StudentID)
+	if err != nil || !canViewStudent(c, student) {
+		return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "Access denied"})
+	}
+
+	history, err := s.achRepo.FindStatusHistory(ref.ID)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+
+	data := make([]fiber.Map, 0, len(history))
+	for _, h := range history {
+		entry := fiber.Map{
+			"id":             h.ID,
+			"fromStatus":     h.FromStatus,
+			"toStatus":       h.ToStatus,
+			"note":           h.Note,
+			"points":         h.Points,
+			"actor":          nil,
+			"impersonatorId": h.ImpersonatorID,
+			"createdAt":      h.CreatedAt,
+		}
+		if h.Actor != nil {
+			entry["actor"] = fiber.Map{"id": h.Actor.ID, "fullName": h.Actor.FullName}
+		}
+		data = append(data, entry)
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Achievement history retrieved successfully", Data: data})
+}
+
 // ==========================================
 // 4.4 MANAJEMEN SISTEM (ADMIN)
 // ==========================================
@@ -220,7 +369,7 @@ func (s *AchievementService) Reject(c *fiber.Ctx) error {
 // Desc: Admin melihat SEMUA prestasi dengan filter/sorting
 func (s *AchievementService) GetAll(c *fiber.Ctx) error {
 	// 1. Parse Pagination (Modul 6)
-	param := s.parsePagination(c)
+	param := parsePagination(c)
 
 	// 2. Logic Filter Berdasarkan Role Login (Reuse Logic)
 	userRole := c.Locals("role").(string)
@@ -241,7 +390,7 @@ func (s *AchievementService) GetAll(c *fiber.Ctx) error {
 		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
 	}
 
-	return s.sendPaginationResponse(c, data, total, param)
+	return sendPaginationResponse(c, data, total, param)
 }
 
 // ==========================================
@@ -279,40 +428,6 @@ func (s *AchievementService) GetStatistics(c *fiber.Ctx) error {
 	})
 }
 
-// ==========================================
-// HELPER FUNCTIONS (Untuk Modul 6)
-// ==========================================
-
-func (s *AchievementService) parsePagination(c *fiber.Ctx) model.PaginationParam {
-	return model.PaginationParam{
-		Page:   c.QueryInt("page", 1),
-		Limit:  c.QueryInt("limit", 10),
-		SortBy: c.Query("sortBy", "created_at"),
-		Order:  c.Query("order", "desc"),
-		Search: c.Query("search", ""),
-	}
-}
-
-func (s *AchievementService) sendPaginationResponse(c *fiber.Ctx, data interface{}, total int64, param model.PaginationParam) error {
-	totalPages := int(math.Ceil(float64(total) / float64(param.Limit)))
-	
-	return c.JSON(model.WebResponse{
-		Code:    200,
-		Status:  "success",
-		Message: "Data retrieved successfully",
-		Data:    data,
-		Meta: &model.MetaInfo{
-			Page:      param.Page,
-			Limit:     param.Limit,
-			TotalData: total,
-			TotalPage: totalPages,
-			SortBy:    param.SortBy,
-			Order:     param.Order,
-			Search:    param.Search,
-		},
-	})
-}
-
 // --- Placeholder Features ---
 
 func (s *AchievementService) GetDetail(c *fiber.Ctx) error {
@@ -324,14 +439,6 @@ func (s *AchievementService) GetDetail(c *fiber.Ctx) error {
 	return c.JSON(model.WebResponse{Code: 200, Status: "success", Data: fiber.Map{"meta": ref, "content": content}})
 }
 
-func (s *AchievementService) Update(c *fiber.Ctx) error {
-	return c.Status(501).JSON(model.WebResponse{Code: 501, Status: "error", Message: "Update feature not implemented"})
-}
-
-func (s *AchievementService) GetHistory(c *fiber.Ctx) error {
-	return c.Status(501).JSON(model.WebResponse{Code: 501, Status: "error", Message: "History not implemented"})
-}
-
 func (s *AchievementService) UploadAttachment(c *fiber.Ctx) error {
 	return c.Status(501).JSON(model.WebResponse{Code: 501, Status: "error", Message: "Upload not implemented"})
 }
@@ -343,4 +450,15 @@ func (s *AchievementService) GetStudentAchievements(c *fiber.Ctx) error {
 
 func (s *AchievementService) GetStudentStatistics(c *fiber.Ctx) error {
 	return c.Status(501).JSON(model.WebResponse{Code: 501, Status: "error", Message: "Student stats not implemented"})
-}
\ No newline at end of file
+}
+
+// newStatusHistory menyiapkan baris riwayat status dengan pelaku = user login
+// (beserta helpdesk yang sedang impersonate, jika ada)
+func newStatusHistory(c *fiber.Ctx, note string, points *int) *model.AchievementStatusHistory {
+	actorID := c.Locals("user_id").(string)
+	entry := &model.AchievementStatusHistory{ActorID: &actorID, Note: note, Points: points}
+	if impersonator, ok := c.Locals("impersonator_id").(string); ok && impersonator != "" {
+		entry.ImpersonatorID = &impersonator
+	}
+	return entry
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"strings"
+	"time"
+	"uas/app/model"
+	"uas/app/repository"
+	"uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Masa berlaku API token (hari)
+const (
+	apiTokenDefaultDays = 90
+	apiTokenMaxDays     = 365
+)
+
+type APITokenService struct {
+	tokenRepo *repository.APITokenRepository
+	userRepo  *repository.UserRepository
+	roleRepo  *repository.RoleRepository
+}
+
+func NewAPITokenService(tokenRepo *repository.APITokenRepository, userRepo *repository.UserRepository, roleRepo *repository.RoleRepository) *APITokenService {
+	return &APITokenService{
+		tokenRepo: tokenRepo,
+		userRepo:  userRepo,
+		roleRepo:  roleRepo,
+	}
+}
+
+// ==========================================
+// TOKEN MILIK SENDIRI
+// ==========================================
+
+// List personal access token milik user yang login
+func (s *APITokenService) ListMine(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	return s.listTokens(c, userID)
+}
+
+// Buat personal access token untuk diri sendiri.
+// Hanya dari sesi login interaktif (route memakai InteractiveOnly), bukan API token / impersonation.
+func (s *APITokenService) CreateMine(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	return s.createToken(c, userID, userID)
+}
+
+// Cabut personal access token milik sendiri
+func (s *APITokenService) RevokeMine(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	return s.revokeToken(c, userID, c.Params("id"))
+}
+
+// ==========================================
+// SERVICE ACCOUNT (ADMIN)
+// ==========================================
+
+// Buat user non-manusia untuk integrasi (tanpa password, hanya bisa memakai API token)
+func (s *APITokenService) CreateServiceAccount(c *fiber.Ctx) error {
+	var req struct {
+		Username string `json:"username"`
+		Email    string `json:"email"`
+		FullName string `json:"fullName"`
+		RoleID   string `json:"roleId"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.Username == "" || req.RoleID == "" {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Username and roleId are required"})
+	}
+
+	if req.Email == "" {
+		req.Email = req.Username + "@service.local"
+	}
+	if req.FullName == "" {
+		req.FullName = req.Username
+	}
+
+	// Password acak yang tidak pernah diberikan ke siapa pun (login password juga diblokir)
+	randomPassword, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to create service account"})
+	}
+	hash, err := utils.HashPassword(randomPassword)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to create service account"})
+	}
+
+	user := model.User{
+		Username:         req.Username,
+		Email:            req.Email,
+		FullName:         req.FullName,
+		PasswordHash:     hash,
+		RoleID:           req.RoleID,
+		IsActive:         true,
+		IsServiceAccount: true,
+	}
+	if err := s.userRepo.Create(&user); err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Failed to create service account: " + err.Error()})
+	}
+
+	return c.Status(201).JSON(model.WebResponse{Code: 201, Status: "success", Message: "Service account created", Data: user})
+}
+
+// List API token milik user tertentu (admin)
+func (s *APITokenService) ListForUser(c *fiber.Ctx) error {
+	return s.listTokens(c, c.Params("id"))
+}
+
+// Buat API token untuk service account (admin). Token untuk user manusia hanya bisa dibuat oleh user itu sendiri.
+func (s *APITokenService) CreateForServiceAccount(c *fiber.Ctx) error {
+	ownerID := c.Params("id")
+	owner, err := s.userRepo.FindByID(ownerID)
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "User not found"})
+	}
+	if !owner.IsServiceAccount {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Tokens can only be issued on behalf of service accounts"})
+	}
+
+	adminID := c.Locals("user_id").(string)
+	return s.createToken(c, ownerID, adminID)
+}
+
+// Cabut API token milik user tertentu (admin)
+func (s *APITokenService) RevokeForUser(c *fiber.Ctx) error {
+	return s.revokeToken(c, c.Params("id"), c.Params("tokenId"))
+}
+
+// --- Helper ---
+
+func (s *APITokenService) listTokens(c *fiber.Ctx, userID string) error {
+	tokens, err := s.tokenRepo.FindByUserID(userID)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Data retrieved successfully", Data: tokens})
+}
+
+func (s *APITokenService) createToken(c *fiber.Ctx, ownerID string, createdBy string) error {
+	var req struct {
+		Name          string   `json:"name"`
+		Scopes        []string `json:"scopes"`
+		ExpiresInDays int      `json:"expiresInDays"`
+	}
+	if err := c.BodyParser(&req); err != nil || strings.TrimSpace(req.Name) == "" || len(req.Scopes) == 0 {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Name and at least one scope are required"})
+	}
+
+	if req.ExpiresInDays == 0 {
+		req.ExpiresInDays = apiTokenDefaultDays
+	}
+	if req.ExpiresInDays < 0 || req.ExpiresInDays > apiTokenMaxDays {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "expiresInDays must be between 1 and 365"})
+	}
+
+	// 1. Scope harus subset dari permission pemilik token
+	authz, err := s.roleRepo.RefreshAuthzState(ownerID)
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "User not found"})
+	}
+	for _, scope := range req.Scopes {
+		if !utils.HasPermission(authz.Permissions, scope) {
+			return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "Scope exceeds owner permissions: " + scope})
+		}
+	}
+
+	// 2. Buat token, simpan hash-nya
+	raw, err := utils.GenerateAPIToken()
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to generate token"})
+	}
+
+	token := model.APIToken{
+		UserID:    ownerID,
+		Name:      strings.TrimSpace(req.Name),
+		Prefix:    raw[:len(utils.APITokenPrefix)+4],
+		TokenHash: utils.HashToken(raw),
+		Scopes:    req.Scopes,
+		ExpiresAt: time.Now().AddDate(0, 0, req.ExpiresInDays),
+		CreatedBy: createdBy,
+	}
+	if err := s.tokenRepo.Create(&token); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+
+	// 3. Token asli hanya ditampilkan sekali
+	return c.Status(201).JSON(model.WebResponse{
+		Code:    201,
+		Status:  "success",
+		Message: "API token created. Copy it now, it will not be shown again",
+		Data:    fiber.Map{"token": raw, "apiToken": token},
+	})
+}
+
+func (s *APITokenService) revokeToken(c *fiber.Ctx, userID string, tokenID string) error {
+	revoked, err := s.tokenRepo.Revoke(tokenID, userID)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+	if !revoked {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "API token not found"})
+	}
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "API token revoked"})
+}
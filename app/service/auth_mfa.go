@@ -0,0 +1,332 @@
+package service
+
+import (
+	"errors"
+	"os"
+	"time"
+	"uas/app/model"
+	"uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Jumlah recovery code yang dibuat setiap kali enrollment / regenerate
+const mfaRecoveryCodeCount = 10
+
+// ==========================================
+// MFA: LANGKAH KEDUA LOGIN
+// ==========================================
+
+// Verifikasi kode TOTP (atau recovery code) setelah password benar
+func (s *AuthService) VerifyLoginMFA(c *fiber.Ctx) error {
+	var req struct {
+		ChallengeToken string `json:"challengeToken"`
+		Code           string `json:"code"`
+		RecoveryCode   string `json:"recoveryCode"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.ChallengeToken == "" || (req.Code == "" && req.RecoveryCode == "") {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Challenge token and code are required"})
+	}
+
+	// 1. Validasi challenge token
+	claims, err := utils.ParseMFAChallengeToken(req.ChallengeToken, utils.PurposeMFAChallenge)
+	if err != nil {
+		return c.Status(401).JSON(model.WebResponse{Code: 401, Status: "error", Message: "Invalid or expired challenge token"})
+	}
+
+	user, err := s.userRepo.FindByID(claims.UserID)
+	if err != nil || !user.IsActive {
+		return c.Status(401).JSON(model.WebResponse{Code: 401, Status: "error", Message: "Invalid or expired challenge token"})
+	}
+
+	// 2. Kode salah dihitung ke counter lockout yang sama dengan password salah
+	if loginBlocked(user, time.Now()) {
+		return c.Status(401).JSON(model.WebResponse{Code: 401, Status: "error", Message: "Invalid MFA code"})
+	}
+
+	ok, err := s.verifySecondFactor(user.ID, req.Code, req.RecoveryCode)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+	if !ok {
+		_ = s.userRepo.RegisterFailedLogin(user.ID, loginLockThreshold, time.Now().Add(loginLockDuration))
+		return c.Status(401).JSON(model.WebResponse{Code: 401, Status: "error", Message: "Invalid MFA code"})
+	}
+
+	// 3. Terbitkan token final
+	return s.loginSuccess(c, user, nil)
+}
+
+// Enrollment saat login (role wajib MFA tapi user belum punya), memakai challenge token "mfa_enroll"
+func (s *AuthService) EnrollLoginMFA(c *fiber.Ctx) error {
+	var req struct {
+		ChallengeToken string `json:"challengeToken"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.ChallengeToken == "" {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Challenge token is required"})
+	}
+
+	claims, err := utils.ParseMFAChallengeToken(req.ChallengeToken, utils.PurposeMFAEnroll)
+	if err != nil {
+		return c.Status(401).JSON(model.WebResponse{Code: 401, Status: "error", Message: "Invalid or expired challenge token"})
+	}
+
+	return s.startEnrollment(c, claims.UserID)
+}
+
+// Konfirmasi enrollment saat login. Jika kode benar, MFA aktif dan token final langsung diberikan.
+func (s *AuthService) ConfirmLoginMFA(c *fiber.Ctx) error {
+	var req struct {
+		ChallengeToken string `json:"challengeToken"`
+		Code           string `json:"code"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.ChallengeToken == "" || req.Code == "" {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Challenge token and code are required"})
+	}
+
+	claims, err := utils.ParseMFAChallengeToken(req.ChallengeToken, utils.PurposeMFAEnroll)
+	if err != nil {
+		return c.Status(401).JSON(model.WebResponse{Code: 401, Status: "error", Message: "Invalid or expired challenge token"})
+	}
+
+	user, err := s.userRepo.FindByID(claims.UserID)
+	if err != nil || !user.IsActive {
+		return c.Status(401).JSON(model.WebResponse{Code: 401, Status: "error", Message: "Invalid or expired challenge token"})
+	}
+
+	codes, status, err := s.confirmEnrollment(user.ID, req.Code)
+	if err != nil {
+		return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: err.Error()})
+	}
+
+	// Recovery code hanya ditampilkan sekali, ikut dikirim bersama token login
+	return s.loginSuccess(c, user, fiber.Map{"recoveryCodes": codes})
+}
+
+// ==========================================
+// MFA: PENGATURAN AKUN (BUTUH LOGIN)
+// ==========================================
+
+// Mulai enrollment: buat secret baru + URI provisioning (untuk QR code)
+func (s *AuthService) EnrollMFA(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	return s.startEnrollment(c, userID)
+}
+
+// Konfirmasi enrollment dengan kode pertama dari aplikasi authenticator
+func (s *AuthService) ConfirmMFA(c *fiber.Ctx) error {
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Code is required"})
+	}
+
+	userID := c.Locals("user_id").(string)
+	codes, status, err := s.confirmEnrollment(userID, req.Code)
+	if err != nil {
+		return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: err.Error()})
+	}
+
+	return c.JSON(model.WebResponse{
+		Code:    200,
+		Status:  "success",
+		Message: "MFA enabled. Store the recovery codes in a safe place, they are shown only once",
+		Data:    fiber.Map{"recoveryCodes": codes},
+	})
+}
+
+// Matikan MFA (butuh password saat ini + kode TOTP / recovery code). Tidak bisa jika role mewajibkan MFA.
+func (s *AuthService) DisableMFA(c *fiber.Ctx) error {
+	var req struct {
+		CurrentPassword string `json:"currentPassword"`
+		Code            string `json:"code"`
+		RecoveryCode    string `json:"recoveryCode"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.CurrentPassword == "" || (req.Code == "" && req.RecoveryCode == "") {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Current password and code are required"})
+	}
+
+	userID := c.Locals("user_id").(string)
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "User not found"})
+	}
+	if user.Role.MFARequired {
+		return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "MFA is mandatory for your role"})
+	}
+
+	// Password & kode salah dihitung ke counter lockout yang sama dengan login
+	if loginBlocked(user, time.Now()) {
+		return c.Status(429).JSON(model.WebResponse{Code: 429, Status: "error", Message: "Too many failed attempts. Please try again later"})
+	}
+	if !utils.CheckPasswordHash(req.CurrentPassword, user.PasswordHash) {
+		_ = s.userRepo.RegisterFailedLogin(user.ID, loginLockThreshold, time.Now().Add(loginLockDuration))
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Current password is incorrect"})
+	}
+	if status, msg := s.checkSecondFactor(user, req.Code, req.RecoveryCode); msg != "" {
+		return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+	}
+
+	if err := s.mfaRepo.Disable(userID); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "MFA disabled"})
+}
+
+// Buat ulang recovery code (kode lama tidak berlaku lagi)
+func (s *AuthService) RegenerateRecoveryCodes(c *fiber.Ctx) error {
+	var req struct {
+		Code string `json:"code"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.Code == "" {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Code is required"})
+	}
+
+	userID := c.Locals("user_id").(string)
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "User not found"})
+	}
+	if status, msg := s.checkSecondFactor(user, req.Code, ""); msg != "" {
+		return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to generate recovery codes"})
+	}
+	if err := s.mfaRepo.ReplaceRecoveryCodes(userID, hashes); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Recovery codes regenerated", Data: fiber.Map{"recoveryCodes": codes}})
+}
+
+// Admin: Reset MFA user yang kehilangan device (user harus enroll ulang saat login berikutnya)
+func (s *AuthService) ResetUserMFA(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if _, err := s.userRepo.FindByID(id); err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "User not found"})
+	}
+
+	if err := s.mfaRepo.Disable(id); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+	if err := s.revokeAllTokens(id, time.Now()); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to revoke existing sessions"})
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "User MFA has been reset"})
+}
+
+// --- Helper MFA ---
+
+func (s *AuthService) startEnrollment(c *fiber.Ctx, userID string) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil || !user.IsActive {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "User not found"})
+	}
+
+	if mfa, err := s.mfaRepo.FindByUserID(userID); err == nil && mfa.Enabled {
+		return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: "MFA is already enabled"})
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to generate MFA secret"})
+	}
+	if err := s.mfaRepo.SavePending(userID, secret); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+
+	issuer := os.Getenv("MFA_ISSUER")
+	if issuer == "" {
+		issuer = "Sistem Prestasi"
+	}
+
+	return c.JSON(model.WebResponse{
+		Code:    200,
+		Status:  "success",
+		Message: "Scan the provisioning URI with your authenticator app, then confirm with a code",
+		Data: fiber.Map{
+			"secret":          secret,
+			"provisioningUri": utils.TOTPProvisioningURI(issuer, user.Email, secret),
+		},
+	})
+}
+
+// confirmEnrollment mengaktifkan MFA jika kode cocok dengan secret yang pending, return recovery code
+func (s *AuthService) confirmEnrollment(userID string, code string) ([]string, int, error) {
+	mfa, err := s.mfaRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, 400, errors.New("MFA enrollment has not been started")
+	}
+	if mfa.Enabled {
+		return nil, 409, errors.New("MFA is already enabled")
+	}
+
+	step, ok := utils.ValidateTOTP(mfa.Secret, code, time.Now(), mfa.LastUsedStep)
+	if !ok {
+		return nil, 401, errors.New("Invalid MFA code")
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, 500, errors.New("Failed to generate recovery codes")
+	}
+	if err := s.mfaRepo.Enable(userID, step, hashes); err != nil {
+		return nil, 500, err
+	}
+	return codes, 200, nil
+}
+
+// verifySecondFactor mengecek kode TOTP (anti replay) atau recovery code (sekali pakai)
+func (s *AuthService) verifySecondFactor(userID, code, recoveryCode string) (bool, error) {
+	mfa, err := s.mfaRepo.FindByUserID(userID)
+	if err != nil || !mfa.Enabled {
+		return false, nil
+	}
+
+	if code != "" {
+		step, ok := utils.ValidateTOTP(mfa.Secret, code, time.Now(), mfa.LastUsedStep)
+		if !ok {
+			return false, nil
+		}
+		return s.mfaRepo.MarkStepUsed(userID, step)
+	}
+
+	return s.mfaRepo.UseRecoveryCode(userID, utils.HashToken(utils.NormalizeRecoveryCode(recoveryCode)))
+}
+
+// checkSecondFactor dipakai endpoint pengaturan MFA (user sudah login): kode salah dihitung ke counter
+// lockout login agar access token curian tidak bisa dipakai menebak kode. Return status & pesan error (kosong jika valid).
+func (s *AuthService) checkSecondFactor(user *model.User, code, recoveryCode string) (int, string) {
+	if loginBlocked(user, time.Now()) {
+		return 429, "Too many failed attempts. Please try again later"
+	}
+
+	ok, err := s.verifySecondFactor(user.ID, code, recoveryCode)
+	if err != nil {
+		return 500, err.Error()
+	}
+	if !ok {
+		_ = s.userRepo.RegisterFailedLogin(user.ID, loginLockThreshold, time.Now().Add(loginLockDuration))
+		return 401, "Invalid MFA code"
+	}
+	return 0, ""
+}
+
+func newRecoveryCodes() ([]string, []string, error) {
+	codes, err := utils.GenerateRecoveryCodes(mfaRecoveryCodeCount)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hashes := make([]string, 0, len(codes))
+	for _, code := range codes {
+		hashes = append(hashes, utils.HashToken(utils.NormalizeRecoveryCode(code)))
+	}
+	return codes, hashes, nil
+}
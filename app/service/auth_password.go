@@ -0,0 +1,55 @@
+package service
+
+import (
+	"errors"
+	"uas/app/model"
+	"uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// validateNewPassword mengecek password baru terhadap policy (panjang, daftar bocor, N password terakhir).
+// userID boleh kosong untuk user yang belum dibuat (tidak ada riwayat).
+func (s *AuthService) validateNewPassword(userID string, password string) error {
+	var previous []string
+	if userID != "" {
+		hashes, err := s.userRepo.RecentPasswordHashes(userID, utils.CurrentPasswordPolicy().HistorySize)
+		if err != nil {
+			return err
+		}
+		previous = hashes
+	}
+	return utils.ValidatePassword(password, previous)
+}
+
+// storePassword meng-hash password baru dengan hasher aktif dan menyimpan hash lama ke riwayat
+func (s *AuthService) storePassword(userID string, password string) error {
+	hash, err := utils.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	// Password saat ini sudah terhitung satu dari N password terakhir
+	return s.userRepo.ChangePassword(userID, hash, utils.CurrentPasswordPolicy().HistorySize-1)
+}
+
+// rehashIfNeeded meng-hash ulang password (yang baru saja terverifikasi) jika hash lama
+// memakai algoritma/parameter yang sudah tidak dipakai. Kegagalan tidak membatalkan login.
+func (s *AuthService) rehashIfNeeded(user *model.User, password string) {
+	if !utils.PasswordNeedsRehash(user.PasswordHash) {
+		return
+	}
+	if hash, err := utils.HashPassword(password); err == nil {
+		if s.userRepo.UpdatePassword(user.ID, hash) == nil {
+			user.PasswordHash = hash
+		}
+	}
+}
+
+// sendPasswordError membalas 400 untuk pelanggaran policy, 500 untuk error lain
+func sendPasswordError(c *fiber.Ctx, err error) error {
+	var policyErr *utils.PasswordPolicyError
+	if errors.As(err, &policyErr) {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: policyErr.Reason})
+	}
+	return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to update password"})
+}
@@ -1,7 +1,12 @@
 package service
 
 import (
-	// "errors"
+	"errors"
+	"log"
+	"net/mail"
+	"strconv"
+	"strings"
+	"time"
 	"uas/app/model"
 	"uas/app/repository"
 	"uas/utils"
@@ -10,14 +15,38 @@ import (
 )
 
 type AuthService struct {
-	userRepo *repository.UserRepository
-	roleRepo *repository.RoleRepository // Perlu repo role untuk ambil permissions
+	userRepo       *repository.UserRepository
+	roleRepo       *repository.RoleRepository // Perlu repo role untuk ambil permissions
+	refreshRepo    *repository.RefreshTokenRepository
+	revocationRepo *repository.RevocationRepository
+	attemptRepo    *repository.LoginAttemptRepository
+	resetRepo      *repository.PasswordResetRepository
+	mfaRepo        *repository.MFARepository
+	sessionRepo    *repository.SessionRepository
+	mailer         utils.Mailer
 }
 
-func NewAuthService(userRepo *repository.UserRepository, roleRepo *repository.RoleRepository) *AuthService {
+func NewAuthService(
+	userRepo *repository.UserRepository,
+	roleRepo *repository.RoleRepository,
+	refreshRepo *repository.RefreshTokenRepository,
+	revocationRepo *repository.RevocationRepository,
+	attemptRepo *repository.LoginAttemptRepository,
+	resetRepo *repository.PasswordResetRepository,
+	mfaRepo *repository.MFARepository,
+	sessionRepo *repository.SessionRepository,
+	mailer utils.Mailer,
+) *AuthService {
 	return &AuthService{
-		userRepo: userRepo,
-		roleRepo: roleRepo,
+		userRepo:       userRepo,
+		roleRepo:       roleRepo,
+		refreshRepo:    refreshRepo,
+		revocationRepo: revocationRepo,
+		attemptRepo:    attemptRepo,
+		resetRepo:      resetRepo,
+		mfaRepo:        mfaRepo,
+		sessionRepo:    sessionRepo,
+		mailer:         mailer,
 	}
 }
 
@@ -33,90 +62,619 @@ func (s *AuthService) Login(c *fiber.Ctx) error {
 		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid request body"})
 	}
 
+	ip := c.IP()
+	now := time.Now()
+
+	// Throttle per IP (tidak terkait akun tertentu, aman dibalas 429)
+	if failures, err := s.attemptRepo.CountFailuresByIP(ip, now.Add(-loginIPWindow)); err == nil && failures >= loginIPMaxFailures {
+		c.Set("Retry-After", strconv.Itoa(int(loginIPWindow.Seconds())))
+		return c.Status(429).JSON(model.WebResponse{Code: 429, Status: "error", Message: "Too many login attempts. Please try again later"})
+	}
+
 	// 2. Sistem memvalidasi kredensial (Cari user by Email)
 	user, err := s.userRepo.FindByEmail(req.Email)
 	if err != nil {
-		return c.Status(401).JSON(model.WebResponse{Code: 401, Status: "error", Message: "Invalid email or password"})
+		utils.CheckPasswordHash(req.Password, dummyPasswordHash())
+		s.recordLoginAttempt(nil, req.Email, ip, false)
+		return c.Status(401).JSON(model.WebResponse{Code: 401, Status: "error", Message: invalidLoginMessage})
+	}
+
+	// Akun terkunci / masih backoff / service account (hanya boleh API token):
+	// balas dengan pesan yang sama, password tidak dicek
+	if user.IsServiceAccount || loginBlocked(user, now) {
+		utils.CheckPasswordHash(req.Password, dummyPasswordHash())
+		s.recordLoginAttempt(&user.ID, req.Email, ip, false)
+		return c.Status(401).JSON(model.WebResponse{Code: 401, Status: "error", Message: invalidLoginMessage})
 	}
 
 	// Cek Password
 	if !utils.CheckPasswordHash(req.Password, user.PasswordHash) {
-		return c.Status(401).JSON(model.WebResponse{Code: 401, Status: "error", Message: "Invalid email or password"})
+		_ = s.userRepo.RegisterFailedLogin(user.ID, loginLockThreshold, now.Add(loginLockDuration))
+		s.recordLoginAttempt(&user.ID, req.Email, ip, false)
+		return c.Status(401).JSON(model.WebResponse{Code: 401, Status: "error", Message: invalidLoginMessage})
 	}
 
+	s.recordLoginAttempt(&user.ID, req.Email, ip, true)
+
+	// Hash lama (algoritma/cost yang sudah diganti) di-upgrade selagi password asli tersedia
+	s.rehashIfNeeded(user, req.Password)
+
 	// 3. Sistem mengecek status aktif user
 	if !user.IsActive {
 		return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "User account is inactive"})
 	}
 
-	// 4. Sistem generate JWT token dengan role dan permissions
-	// Ambil permissions dari database berdasarkan RoleID user
-	permsData, err := s.roleRepo.GetPermissionsByRoleID(user.RoleID)
+	// 4. Jika user memakai MFA (atau role mewajibkan MFA), token final baru diberikan setelah langkah kedua
+	return s.completeLogin(c, user)
+}
+
+// completeLogin: lanjut ke MFA challenge jika diperlukan, jika tidak langsung terbitkan token
+func (s *AuthService) completeLogin(c *fiber.Ctx, user *model.User) error {
+	mfa, err := s.mfaRepo.FindByUserID(user.ID)
+	mfaEnabled := err == nil && mfa.Enabled
+
+	if mfaEnabled || user.Role.MFARequired {
+		purpose := utils.PurposeMFAChallenge
+		message := "MFA verification required"
+		if !mfaEnabled {
+			purpose = utils.PurposeMFAEnroll
+			message = "MFA enrollment required for this role"
+		}
+
+		challenge, err := utils.GenerateMFAChallengeToken(user.ID, purpose)
+		if err != nil {
+			return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to generate token"})
+		}
+
+		return c.JSON(model.WebResponse{
+			Code:    200,
+			Status:  "success",
+			Message: message,
+			Data: fiber.Map{
+				"mfaRequired":           mfaEnabled,
+				"mfaEnrollmentRequired": !mfaEnabled,
+				"challengeToken":        challenge,
+				"expiresIn":             int(utils.MFAChallengeTTL.Seconds()),
+			},
+		})
+	}
+
+	return s.loginSuccess(c, user, nil)
+}
+
+// loginSuccess menerbitkan access + refresh token (sesi baru) dan mengembalikan profil singkat user.
+// extra berisi data tambahan untuk response (misal recovery code MFA), boleh nil.
+func (s *AuthService) loginSuccess(c *fiber.Ctx, user *model.User, extra fiber.Map) error {
+	if user.FailedLoginAttempts > 0 || user.LockedUntil != nil {
+		_ = s.userRepo.ResetLoginFailures(user.ID)
+	}
+
+	// Sistem generate JWT token dengan role dan permissions (+ refresh token)
+	// Setiap login = satu sesi baru, ID sesi sekaligus menjadi family refresh token
+	familyID, err := utils.GenerateUUID()
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to generate token"})
+	}
+
+	session := &model.Session{
+		ID:        familyID,
+		UserID:    user.ID,
+		UserAgent: truncateUserAgent(c.Get(fiber.HeaderUserAgent)),
+		IPAddress: c.IP(),
+	}
+	if err := s.sessionRepo.Create(session); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to create session"})
+	}
+
+	tokens, err := s.issueTokens(user, familyID)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+
+	// Return token dan user profile
+	data := fiber.Map{
+		"token":        tokens.AccessToken,
+		"refreshToken": tokens.RefreshToken,
+		"expiresIn":    int(utils.AccessTokenTTL.Seconds()),
+		"sessionId":    familyID,
+		"user": fiber.Map{
+			"id":          user.ID,
+			"username":    user.Username,
+			"fullName":    user.FullName,
+			"role":        user.Role.Name,
+			"permissions": tokens.Permissions,
+		},
+	}
+	for k, v := range extra {
+		data[k] = v
+	}
+
+	return c.JSON(model.WebResponse{
+		Code:    200,
+		Status:  "success",
+		Message: "Login successful",
+		Data:    data,
+	})
+}
+
+// Refresh Token (Rotasi)
+// Desc: Tukar refresh token dengan access token baru + refresh token baru.
+// Refresh token lama langsung tidak berlaku. Jika token lama dipakai lagi (reuse),
+// berarti token kemungkinan dicuri, maka seluruh family token tersebut dicabut.
+func (s *AuthService) RefreshToken(c *fiber.Ctx) error {
+	var req struct {
+		RefreshToken string `json:"refreshToken"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.RefreshToken == "" {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Refresh token is required"})
+	}
+
+	// 1. Cari token berdasarkan hash
+	stored, err := s.refreshRepo.FindByHash(utils.HashToken(req.RefreshToken))
+	if err != nil {
+		return c.Status(401).JSON(model.WebResponse{Code: 401, Status: "error", Message: "Invalid refresh token"})
+	}
+
+	// 2. Deteksi reuse: token yang sudah dirotasi/dicabut dipakai lagi
+	// Sesi (Session.ID == FamilyID) ikut dicabut agar access token dengan sid tersebut langsung ditolak
+	if stored.RevokedAt != nil {
+		_ = s.revokeSession(stored.FamilyID, stored.UserID)
+		return c.Status(401).JSON(model.WebResponse{Code: 401, Status: "error", Message: "Refresh token has been revoked"})
+	}
+
+	if time.Now().After(stored.ExpiresAt) {
+		return c.Status(401).JSON(model.WebResponse{Code: 401, Status: "error", Message: "Refresh token expired"})
+	}
+
+	// 3. Pastikan user masih ada & aktif
+	user, err := s.userRepo.FindByID(stored.UserID)
+	if err != nil || !user.IsActive {
+		_ = s.revokeSession(stored.FamilyID, stored.UserID)
+		return c.Status(401).JSON(model.WebResponse{Code: 401, Status: "error", Message: "Invalid refresh token"})
+	}
+
+	// 4. Rotasi: buat token baru di family yang sama, tandai token lama terpakai
+	// Permission selalu dibaca ulang agar perubahan role langsung masuk ke token baru
+	authz, err := s.roleRepo.RefreshAuthzState(user.ID)
 	if err != nil {
 		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to load permissions"})
 	}
 
-	// Convert struct permission ke slice string (misal: ["achievement:create", "user:read"])
-	var permissions []string
-	for _, p := range permsData {
-		permissions = append(permissions, p.Name)
+	refreshToken, next, err := s.newRefreshToken(user.ID, stored.FamilyID)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to generate token"})
 	}
 
-	// Generate Token
-	token, err := utils.GenerateToken(user.ID, user.Role.Name, permissions)
+	rotated, err := s.refreshRepo.Rotate(stored.ID, next)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to rotate refresh token"})
+	}
+	if !rotated {
+		// Request lain sudah memakai token ini lebih dulu -> perlakukan sebagai reuse
+		_ = s.revokeSession(stored.FamilyID, stored.UserID)
+		return c.Status(401).JSON(model.WebResponse{Code: 401, Status: "error", Message: "Refresh token has been revoked"})
+	}
+
+	accessToken, err := generateAccessToken(authz, stored.FamilyID)
 	if err != nil {
 		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to generate token"})
 	}
 
-	// 5. Return token dan user profile
+	// Catat aktivitas terakhir sesi (perangkat/IP bisa berubah, mis. pindah jaringan)
+	_ = s.sessionRepo.Touch(stored.FamilyID, c.IP(), truncateUserAgent(c.Get(fiber.HeaderUserAgent)))
+
 	return c.JSON(model.WebResponse{
 		Code:    200,
 		Status:  "success",
-		Message: "Login successful",
+		Message: "Token refreshed",
 		Data: fiber.Map{
-			"token": token,
-			"user": fiber.Map{
-				"id":          user.ID,
-				"username":    user.Username,
-				"fullName":    user.FullName,
-				"role":        user.Role.Name,
-				"permissions": permissions,
-			},
+			"token":        accessToken,
+			"refreshToken": refreshToken,
+			"expiresIn":    int(utils.AccessTokenTTL.Seconds()),
 		},
 	})
-	
 }
 
-// --- Placeholder Auth ---
-func (s *AuthService) RefreshToken(c *fiber.Ctx) error {
-	return c.Status(501).JSON(fiber.Map{"message": "Refresh Token Not Implemented"})
+// JWKS
+// Desc: Public key untuk memverifikasi token kita, dipakai service kampus lain (tanpa perlu secret)
+func (s *AuthService) JWKS(c *fiber.Ctx) error {
+	c.Set("Cache-Control", "public, max-age=300")
+	return c.JSON(utils.PublicJWKS())
+}
+
+// Lupa Password
+// Desc: Kirim link reset ke email user. Response selalu sama (200) agar tidak membocorkan email terdaftar.
+func (s *AuthService) ForgotPassword(c *fiber.Ctx) error {
+	var req struct {
+		Email string `json:"email"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.Email == "" {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Email is required"})
+	}
+
+	response := model.WebResponse{Code: 200, Status: "success", Message: "If the email is registered, a reset link has been sent"}
+
+	user, err := s.userRepo.FindByEmail(req.Email)
+	if err != nil || !user.IsActive {
+		return c.JSON(response)
+	}
+
+	// 1. Buat token acak, simpan hash-nya saja
+	rawToken, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to generate reset token"})
+	}
+
+	if err := s.resetRepo.Create(&model.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(rawToken),
+		ExpiresAt: time.Now().Add(passwordResetTTL),
+	}); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to save reset token"})
+	}
+
+	// 2. Kirim email (kegagalan kirim hanya dicatat, response tetap sama)
+	if err := s.mailer.Send(passwordResetMail(user, rawToken)); err != nil {
+		log.Println("⚠️  Gagal mengirim email reset password:", err)
+	}
+
+	return c.JSON(response)
+}
+
+// Reset Password
+// Desc: Ganti password memakai token reset (sekali pakai), lalu cabut semua sesi yang ada
+func (s *AuthService) ResetPassword(c *fiber.Ctx) error {
+	var req struct {
+		Token       string `json:"token"`
+		NewPassword string `json:"newPassword"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.Token == "" || req.NewPassword == "" {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Token and new password are required"})
+	}
+
+	// 1. Validasi token (belum dipakai & belum expired)
+	resetToken, err := s.resetRepo.FindValidByHash(utils.HashToken(req.Token))
+	if err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid or expired reset token"})
+	}
+
+	// Cek policy sebelum token dipakai, agar user bisa mencoba password lain dengan token yang sama
+	if err := s.validateNewPassword(resetToken.UserID, req.NewPassword); err != nil {
+		return sendPasswordError(c, err)
+	}
+
+	used, err := s.resetRepo.MarkUsed(resetToken.ID)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+	if !used {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid or expired reset token"})
+	}
+
+	// 2. Simpan password baru (hash lama masuk riwayat)
+	if err := s.storePassword(resetToken.UserID, req.NewPassword); err != nil {
+		return sendPasswordError(c, err)
+	}
+	_ = s.userRepo.ResetLoginFailures(resetToken.UserID)
+
+	// 3. Cabut semua sesi (access token & refresh token) yang sudah ada
+	if err := s.revokeAllTokens(resetToken.UserID, time.Now()); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to revoke existing sessions"})
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Password has been reset"})
+}
+
+// Admin: Buka kunci akun yang terkunci karena terlalu banyak login gagal
+func (s *AuthService) UnlockUser(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if _, err := s.userRepo.FindByID(id); err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "User not found"})
+	}
+
+	if err := s.userRepo.ResetLoginFailures(id); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "User account unlocked"})
+}
+
+// recordLoginAttempt mencatat percobaan login, gagal mencatat tidak boleh menggagalkan login
+func (s *AuthService) recordLoginAttempt(userID *string, email string, ip string, success bool) {
+	_ = s.attemptRepo.Record(&model.LoginAttempt{
+		UserID:    userID,
+		Email:     email,
+		IPAddress: ip,
+		Success:   success,
+	})
+}
+
+// --- Helper Token ---
+
+// revokeAllTokens mencabut semua sesi, access token & refresh token user yang diterbitkan sebelum waktu tertentu
+func (s *AuthService) revokeAllTokens(userID string, before time.Time) error {
+	if err := s.revocationRepo.RevokeUserTokensBefore(userID, before); err != nil {
+		return err
+	}
+	if _, err := s.sessionRepo.RevokeAllByUser(userID, before, ""); err != nil {
+		return err
+	}
+	return s.refreshRepo.RevokeAllByUser(userID, before)
+}
+
+type issuedTokens struct {
+	AccessToken  string
+	RefreshToken string
+	Permissions  []string
+}
+
+// issueTokens membuat access token + refresh token baru untuk user dalam family tertentu
+func (s *AuthService) issueTokens(user *model.User, familyID string) (*issuedTokens, error) {
+	// Ambil permissions terbaru (beserta versinya) dari database berdasarkan RoleID user
+	authz, err := s.roleRepo.RefreshAuthzState(user.ID)
+	if err != nil {
+		return nil, errors.New("Failed to load permissions")
+	}
+
+	accessToken, err := generateAccessToken(authz, familyID)
+	if err != nil {
+		return nil, errors.New("Failed to generate token")
+	}
+
+	refreshToken, record, err := s.newRefreshToken(user.ID, familyID)
+	if err != nil {
+		return nil, errors.New("Failed to generate token")
+	}
+	if err := s.refreshRepo.Create(record); err != nil {
+		return nil, errors.New("Failed to save refresh token")
+	}
+
+	return &issuedTokens{
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		Permissions:  authz.Permissions,
+	}, nil
+}
+
+// generateAccessToken membuat access token dari state otorisasi terkini (permissions + stamp versi) untuk sesi tertentu
+func generateAccessToken(authz *repository.AuthzState, sessionID string) (string, error) {
+	return utils.GenerateToken(authz.UserID, authz.RoleName, authz.Permissions, utils.PermissionStamp{
+		UserVersion: authz.UserVersion,
+		RoleVersion: authz.RoleVersion,
+	}, sessionID)
+}
+
+// newRefreshToken membuat refresh token acak beserta record (hash) yang akan disimpan
+func (s *AuthService) newRefreshToken(userID, familyID string) (string, *model.RefreshToken, error) {
+	raw, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return "", nil, err
+	}
+	id, err := utils.GenerateUUID()
+	if err != nil {
+		return "", nil, err
+	}
+
+	return raw, &model.RefreshToken{
+		ID:        id,
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: utils.HashToken(raw),
+		ExpiresAt: time.Now().Add(utils.RefreshTokenTTL),
+	}, nil
 }
 
+// Logout
+// Desc: Cabut access token yang sedang dipakai (jti) dan refresh token yang dikirim.
+// Jika allDevices = true, semua token user yang diterbitkan sebelum "before" (default: sekarang) ikut dicabut.
 func (s *AuthService) Logout(c *fiber.Ctx) error {
-	return c.Status(501).JSON(fiber.Map{"message": "Logout Not Implemented"})
+	var req struct {
+		RefreshToken string     `json:"refreshToken"`
+		AllDevices   bool       `json:"allDevices"`
+		Before       *time.Time `json:"before"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid request body"})
+		}
+	}
+
+	userID := c.Locals("user_id").(string)
+	jti, _ := c.Locals("jti").(string)
+	expiresAt, _ := c.Locals("token_exp").(time.Time)
+	sessionID, _ := c.Locals("session_id").(string)
+
+	// 1. Cabut access token & sesi saat ini
+	if jti != "" {
+		if err := s.revocationRepo.RevokeToken(jti, userID, expiresAt); err != nil {
+			return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to revoke token"})
+		}
+	}
+
+	// Mengakhiri impersonation hanya mencabut token impersonation, sesi milik user & admin tidak disentuh
+	if _, impersonating := c.Locals("impersonator_id").(string); impersonating {
+		return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Impersonation ended"})
+	}
+	if sessionID != "" {
+		if err := s.revokeSession(sessionID, userID); err != nil {
+			return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to revoke session"})
+		}
+	}
+
+	// 2. Cabut refresh token (beserta family/sesi-nya) jika dikirim
+	if req.RefreshToken != "" {
+		stored, err := s.refreshRepo.FindByHash(utils.HashToken(req.RefreshToken))
+		if err == nil && stored.UserID == userID && stored.FamilyID != sessionID {
+			if err := s.revokeSession(stored.FamilyID, userID); err != nil {
+				return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to revoke refresh token"})
+			}
+		}
+	}
+
+	// 3. Logout everywhere
+	if req.AllDevices {
+		before := time.Now()
+		if req.Before != nil {
+			if req.Before.After(before) {
+				return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Timestamp 'before' cannot be in the future"})
+			}
+			before = *req.Before
+		}
+
+		if err := s.revokeAllTokens(userID, before); err != nil {
+			return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to revoke tokens"})
+		}
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Logout successful"})
 }
 
+// Get Profile
+// Desc: Data user yang sedang login beserta role, permissions, dan profil Mahasiswa/Dosen
 func (s *AuthService) GetProfile(c *fiber.Ctx) error {
-	userID := c.Locals("user_id")
-	// Logic ambil profile user dari Repo
-	return c.Status(200).JSON(fiber.Map{"message": "Profile Data", "userId": userID})
-}
-
-// --- Placeholder User Management (Admin) ---
-func (s *AuthService) GetAllUsers(c *fiber.Ctx) error { return notImplemented(c) }
-func (s *AuthService) GetUserDetail(c *fiber.Ctx) error { return notImplemented(c) }
-func (s *AuthService) CreateUser(c *fiber.Ctx) error { return notImplemented(c) }
-func (s *AuthService) UpdateUser(c *fiber.Ctx) error { return notImplemented(c) }
-func (s *AuthService) DeleteUser(c *fiber.Ctx) error { return notImplemented(c) }
-func (s *AuthService) UpdateUserRole(c *fiber.Ctx) error { return notImplemented(c) }
-
-// --- Placeholder Students & Lecturers ---
-func (s *AuthService) GetAllStudents(c *fiber.Ctx) error { return notImplemented(c) }
-func (s *AuthService) GetStudentDetail(c *fiber.Ctx) error { return notImplemented(c) }
-func (s *AuthService) UpdateStudentAdvisor(c *fiber.Ctx) error { return notImplemented(c) }
-func (s *AuthService) GetAllLecturers(c *fiber.Ctx) error { return notImplemented(c) }
-
-// Helper Internal
-func notImplemented(c *fiber.Ctx) error {
-	return c.Status(501).JSON(fiber.Map{"status": "error", "message": "Feature not implemented yet"})
-}
\ No newline at end of file
+	userID := c.Locals("user_id").(string)
+	permissions, _ := c.Locals("permissions").([]string)
+
+	// 1. Ambil user + role
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "User not found"})
+	}
+
+	data := fiber.Map{
+		"id":          user.ID,
+		"username":    user.Username,
+		"email":       user.Email,
+		"fullName":    user.FullName,
+		"isActive":    user.IsActive,
+		"role":        user.Role.Name,
+		"permissions": permissions,
+		"createdAt":   user.CreatedAt,
+	}
+
+	// 2. Data tambahan sesuai role (Mahasiswa: NIM, prodi, dosen wali; Dosen: NIP, jumlah bimbingan)
+	addProfileData(s.userRepo, userID, data)
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Data: data})
+}
+
+// Update Profile
+// Desc: User mengubah data dirinya sendiri. NIM/NIP, role & data akademik hanya bisa diubah Admin.
+func (s *AuthService) UpdateProfile(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+
+	var req struct {
+		Username        *string `json:"username"`
+		Email           *string `json:"email"`
+		FullName        *string `json:"fullName"`
+		CurrentPassword string  `json:"currentPassword"` // Wajib jika email diganti
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid request body"})
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "User not found"})
+	}
+
+	// 1. Validasi field yang dikirim
+	updates := map[string]interface{}{}
+	if req.FullName != nil {
+		fullName := strings.TrimSpace(*req.FullName)
+		if fullName == "" || len(fullName) > 100 {
+			return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Full name must be 1-100 characters"})
+		}
+		updates["full_name"] = fullName
+	}
+	if req.Username != nil {
+		username := strings.TrimSpace(*req.Username)
+		if username == "" || len(username) > 50 {
+			return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Username must be 1-50 characters"})
+		}
+		updates["username"] = username
+	}
+	if req.Email != nil {
+		email := strings.TrimSpace(*req.Email)
+		if _, err := mail.ParseAddress(email); err != nil || len(email) > 100 {
+			return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid email address"})
+		}
+		// Email dipakai untuk reset password, jadi mengganti email wajib konfirmasi password saat ini
+		// (access token curian saja tidak cukup untuk mengambil alih akun lewat forgot-password)
+		if !strings.EqualFold(email, user.Email) {
+			if req.CurrentPassword == "" {
+				return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Current password is required to change email"})
+			}
+			if !utils.CheckPasswordHash(req.CurrentPassword, user.PasswordHash) {
+				_ = s.userRepo.RegisterFailedLogin(user.ID, loginLockThreshold, time.Now().Add(loginLockDuration))
+				return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Current password is incorrect"})
+			}
+		}
+		updates["email"] = email
+	}
+	if len(updates) == 0 {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "No editable fields provided"})
+	}
+
+	// 2. Username / email harus unik
+	username, _ := updates["username"].(string)
+	email, _ := updates["email"].(string)
+	if username != "" || email != "" {
+		taken, err := s.userRepo.IsUsernameOrEmailTaken(username, email, userID)
+		if err != nil {
+			return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+		}
+		if taken {
+			return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: "Username or email is already used"})
+		}
+	}
+
+	// 3. Simpan
+	if err := s.userRepo.UpdateProfile(user.ID, updates); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to update profile"})
+	}
+
+	return s.GetProfile(c)
+}
+
+// Change Password
+// Desc: Ganti password dengan menyertakan password saat ini. Sesi lain (perangkat lain) ikut dicabut.
+// Hanya dari sesi login interaktif (route memakai InteractiveOnly).
+func (s *AuthService) ChangePassword(c *fiber.Ctx) error {
+	var req struct {
+		CurrentPassword string `json:"currentPassword"`
+		NewPassword     string `json:"newPassword"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.CurrentPassword == "" || req.NewPassword == "" {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Current password and new password are required"})
+	}
+
+	userID := c.Locals("user_id").(string)
+	sessionID, _ := c.Locals("session_id").(string)
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "User not found"})
+	}
+
+	// 1. Verifikasi password saat ini (dihitung sebagai percobaan gagal agar tidak bisa di-brute-force)
+	if !utils.CheckPasswordHash(req.CurrentPassword, user.PasswordHash) {
+		_ = s.userRepo.RegisterFailedLogin(user.ID, loginLockThreshold, time.Now().Add(loginLockDuration))
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Current password is incorrect"})
+	}
+
+	// 2. Password baru harus lolos policy (termasuk tidak sama dengan N password terakhir)
+	if err := s.validateNewPassword(user.ID, req.NewPassword); err != nil {
+		return sendPasswordError(c, err)
+	}
+	if err := s.storePassword(user.ID, req.NewPassword); err != nil {
+		return sendPasswordError(c, err)
+	}
+
+	// 3. Logout dari perangkat lain, sesi ini tetap aktif
+	if _, err := s.revokeSessionsExcept(user.ID, sessionID); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Password changed, but failed to revoke other sessions"})
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Password changed successfully"})
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"time"
+	"uas/app/model"
+	"uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// sessionView adalah data sesi yang dikirim ke client (ditandai sesi yang sedang dipakai)
+type sessionView struct {
+	model.Session
+	Current bool `json:"current"`
+}
+
+// List Sesi Aktif Saya
+// Desc: Daftar perangkat tempat user sedang login
+func (s *AuthService) ListSessions(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	currentID, _ := c.Locals("session_id").(string)
+	return s.sendSessions(c, userID, currentID)
+}
+
+// Cabut Sesi Saya
+// Desc: Logout perangkat lain secara remote (access token sesi tsb langsung ditolak AuthRequired)
+func (s *AuthService) RevokeSession(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	return s.revokeSessionResponse(c, c.Params("id"), userID)
+}
+
+// Cabut Semua Sesi Saya Kecuali Sesi Ini
+func (s *AuthService) RevokeOtherSessions(c *fiber.Ctx) error {
+	userID := c.Locals("user_id").(string)
+	currentID, _ := c.Locals("session_id").(string)
+
+	count, err := s.revokeSessionsExcept(userID, currentID)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to revoke sessions"})
+	}
+
+	return c.JSON(model.WebResponse{
+		Code:    200,
+		Status:  "success",
+		Message: "Other sessions revoked",
+		Data:    fiber.Map{"revoked": count},
+	})
+}
+
+// (Admin) List Sesi Aktif User
+func (s *AuthService) ListUserSessions(c *fiber.Ctx) error {
+	userID := c.Params("id")
+	if _, err := s.userRepo.FindByID(userID); err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "User not found"})
+	}
+	return s.sendSessions(c, userID, "")
+}
+
+// (Admin) Cabut Satu Sesi User (mis. sesi yang terindikasi disusupi)
+func (s *AuthService) RevokeUserSession(c *fiber.Ctx) error {
+	return s.revokeSessionResponse(c, c.Params("sessionId"), c.Params("id"))
+}
+
+// (Admin) Cabut Semua Sesi User
+// Desc: Sama dengan "logout everywhere", termasuk access token yang belum expired
+func (s *AuthService) RevokeAllUserSessions(c *fiber.Ctx) error {
+	userID := c.Params("id")
+	if _, err := s.userRepo.FindByID(userID); err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "User not found"})
+	}
+
+	if err := s.revokeAllTokens(userID, time.Now()); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to revoke sessions"})
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "All sessions revoked"})
+}
+
+// --- Helper Sesi ---
+
+func (s *AuthService) sendSessions(c *fiber.Ctx, userID string, currentID string) error {
+	sessions, err := s.sessionRepo.FindActiveByUser(userID, utils.RefreshTokenTTL)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to fetch sessions"})
+	}
+
+	views := make([]sessionView, 0, len(sessions))
+	for _, session := range sessions {
+		views = append(views, sessionView{Session: session, Current: session.ID == currentID})
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Data: views})
+}
+
+func (s *AuthService) revokeSessionResponse(c *fiber.Ctx, sessionID string, userID string) error {
+	session, err := s.sessionRepo.FindByID(sessionID)
+	if err != nil || session.UserID != userID || session.RevokedAt != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Session not found"})
+	}
+
+	if err := s.revokeSession(sessionID, userID); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to revoke session"})
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Session revoked"})
+}
+
+// revokeSession mencabut sesi beserta seluruh refresh token family-nya
+func (s *AuthService) revokeSession(sessionID string, userID string) error {
+	if _, err := s.sessionRepo.Revoke(sessionID, userID); err != nil {
+		return err
+	}
+	return s.refreshRepo.RevokeFamily(sessionID)
+}
+
+// revokeSessionsExcept mencabut semua sesi user kecuali satu sesi (biasanya sesi yang sedang dipakai)
+func (s *AuthService) revokeSessionsExcept(userID string, exceptID string) (int, error) {
+	ids, err := s.sessionRepo.RevokeAllByUser(userID, time.Now(), exceptID)
+	if err != nil {
+		return 0, err
+	}
+	for _, id := range ids {
+		if err := s.refreshRepo.RevokeFamily(id); err != nil {
+			return 0, err
+		}
+	}
+	return len(ids), nil
+}
+
+// truncateUserAgent membatasi panjang User-Agent sesuai kolom user_sessions.user_agent
+func truncateUserAgent(userAgent string) string {
+	if len(userAgent) > 255 {
+		return userAgent[:255]
+	}
+	return userAgent
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"log"
+	"strings"
+	"uas/app/model"
+	"uas/app/repository"
+	"uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Permission yang menandakan akun admin, akun dengan permission ini tidak boleh di-impersonate
+var adminPermissions = []string{"user:manage", "user:impersonate"}
+
+// ImpersonationService memungkinkan helpdesk melihat aplikasi persis seperti user tertentu
+type ImpersonationService struct {
+	userRepo  *repository.UserRepository
+	roleRepo  *repository.RoleRepository
+	auditRepo *repository.AuditRepository
+}
+
+func NewImpersonationService(userRepo *repository.UserRepository, roleRepo *repository.RoleRepository, auditRepo *repository.AuditRepository) *ImpersonationService {
+	return &ImpersonationService{userRepo: userRepo, roleRepo: roleRepo, auditRepo: auditRepo}
+}
+
+// Impersonate User
+// Desc: Terbitkan token berumur pendek atas nama user lain (claim "act" berisi admin).
+// Permission token = irisan permission target dengan permission admin, jadi tidak pernah ada elevasi.
+func (s *ImpersonationService) Impersonate(c *fiber.Ctx) error {
+	actorID := c.Locals("user_id").(string)
+	actorPermissions, _ := c.Locals("permissions").([]string)
+	sessionID, _ := c.Locals("session_id").(string)
+	targetID := c.Params("id")
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := c.BodyParser(&req); err != nil || strings.TrimSpace(req.Reason) == "" {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Reason is required for impersonation"})
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if len(reason) > 500 {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Reason must be at most 500 characters"})
+	}
+
+	if targetID == actorID {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Cannot impersonate yourself"})
+	}
+
+	// 1. Validasi target
+	target, err := s.userRepo.FindByID(targetID)
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "User not found"})
+	}
+	if !target.IsActive || target.IsServiceAccount {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Only active human users can be impersonated"})
+	}
+
+	targetAuthz, err := s.roleRepo.RefreshAuthzState(target.ID)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to load permissions"})
+	}
+
+	// 2. Tidak boleh impersonate admin lain
+	if target.Role.Name == model.RoleAdmin || utils.HasAnyPermission(targetAuthz.Permissions, adminPermissions...) {
+		return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "Administrators cannot be impersonated"})
+	}
+
+	// 3. Batasi permission agar tidak melebihi milik admin yang melakukan impersonate
+	permissions := make([]string, 0, len(targetAuthz.Permissions))
+	for _, permission := range targetAuthz.Permissions {
+		if utils.HasPermission(actorPermissions, permission) {
+			permissions = append(permissions, permission)
+		}
+	}
+
+	token, expiresAt, err := utils.GenerateImpersonationToken(target.ID, targetAuthz.RoleName, permissions, utils.PermissionStamp{
+		UserVersion: targetAuthz.UserVersion,
+		RoleVersion: targetAuthz.RoleVersion,
+	}, actorID, sessionID)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to generate token"})
+	}
+
+	// 4. Audit: mulai impersonation (request selanjutnya dicatat oleh AuthMiddleware)
+	onBehalf := target.ID
+	if err := s.auditRepo.Create(&model.AuditLog{
+		ActorID:    actorID,
+		OnBehalf:   &onBehalf,
+		Action:     "impersonation.start",
+		TargetType: "user",
+		TargetID:   target.ID,
+		Method:     c.Method(),
+		Path:       model.AuditPath(c.OriginalURL()),
+		StatusCode: 200,
+		IPAddress:  c.IP(),
+		Metadata:   map[string]interface{}{"reason": reason, "expiresAt": expiresAt},
+	}); err != nil {
+		// Impersonation tanpa jejak audit tidak boleh terjadi
+		log.Println("⚠️  Gagal menulis audit log impersonation:", err)
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to write audit log"})
+	}
+
+	return c.JSON(model.WebResponse{
+		Code:    200,
+		Status:  "success",
+		Message: "Impersonation started",
+		Data: fiber.Map{
+			"token":     token,
+			"expiresIn": int(utils.ImpersonationTokenTTL.Seconds()),
+			"user": fiber.Map{
+				"id":          target.ID,
+				"username":    target.Username,
+				"fullName":    target.FullName,
+				"role":        targetAuthz.RoleName,
+				"permissions": permissions,
+			},
+			"impersonatorId": actorID,
+		},
+	})
+}
@@ -0,0 +1,573 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/mail"
+	"strings"
+	"time"
+	"uas/app/model"
+	"uas/app/repository"
+	"uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Jenis roster yang bisa di-import
+const (
+	ImportTypeStudent  = "student"
+	ImportTypeLecturer = "lecturer"
+)
+
+// Batas jumlah baris per file (di luar header)
+const maxImportRows = 5000
+
+// Hash sementara untuk dry run (tidak perlu menghitung bcrypt/argon2 untuk data yang akan di-rollback)
+const dryRunPasswordHash = "!dry-run"
+
+var errDryRun = errors.New("dry run")
+
+// Nama kolom yang dikenali (lowercase, spasi/strip dianggap underscore) -> field
+var importColumnAliases = map[string]string{
+	"nim":            "nim",
+	"student_id":     "nim",
+	"studentid":      "nim",
+	"nip":            "nip",
+	"lecturer_id":    "nip",
+	"lecturerid":     "nip",
+	"name":           "name",
+	"nama":           "name",
+	"full_name":      "name",
+	"fullname":       "name",
+	"nama_lengkap":   "name",
+	"email":          "email",
+	"username":       "username",
+	"program_study":  "program_study",
+	"programstudy":   "program_study",
+	"program_studi":  "program_study",
+	"prodi":          "program_study",
+	"academic_year":  "academic_year",
+	"academicyear":   "academic_year",
+	"angkatan":       "academic_year",
+	"tahun_akademik": "academic_year",
+	"advisor_nip":    "advisor_nip",
+	"advisornip":     "advisor_nip",
+	"nip_dosen_wali": "advisor_nip",
+	"dosen_wali_nip": "advisor_nip",
+	"department":     "department",
+	"departemen":     "department",
+	"jurusan":        "department",
+}
+
+// ImportOptions adalah opsi satu kali import
+type ImportOptions struct {
+	Type   string // student | lecturer
+	DryRun bool   // Validasi saja, semua perubahan di-rollback
+	Invite bool   // Kirim email undangan (link set password) alih-alih membuat password awal
+}
+
+// ImportRowResult adalah hasil per baris. Row adalah nomor baris di file (header = baris 1).
+type ImportRowResult struct {
+	Row             int      `json:"row"`
+	Key             string   `json:"key"`    // NIM / NIP
+	Action          string   `json:"action"` // created | updated | unchanged | error
+	Errors          []string `json:"errors,omitempty"`
+	InitialPassword string   `json:"initialPassword,omitempty"`
+}
+
+// ImportReport adalah ringkasan hasil import
+type ImportReport struct {
+	Type      string            `json:"type"`
+	DryRun    bool              `json:"dryRun"`
+	Invite    bool              `json:"invite"`
+	Total     int               `json:"total"`
+	Created   int               `json:"created"`
+	Updated   int               `json:"updated"`
+	Unchanged int               `json:"unchanged"`
+	Failed    int               `json:"failed"`
+	Rows      []ImportRowResult `json:"rows"`
+}
+
+// importRow adalah satu baris roster yang sudah dipetakan ke field
+type importRow struct {
+	line   int
+	fields map[string]string
+}
+
+func (r importRow) get(field string) string {
+	return strings.TrimSpace(r.fields[field])
+}
+
+// ImportService meng-import roster Mahasiswa / Dosen dari CSV/XLSX (upsert berdasarkan NIM/NIP)
+type ImportService struct {
+	userRepo   *repository.UserRepository
+	roleRepo   *repository.RoleRepository
+	masterRepo *repository.MasterRepository // Prodi, angkatan & jurusan di file dicocokkan ke data master
+	resetRepo  *repository.PasswordResetRepository
+	mailer     utils.Mailer
+}
+
+func NewImportService(userRepo *repository.UserRepository, roleRepo *repository.RoleRepository, masterRepo *repository.MasterRepository, resetRepo *repository.PasswordResetRepository, mailer utils.Mailer) *ImportService {
+	return &ImportService{userRepo: userRepo, roleRepo: roleRepo, masterRepo: masterRepo, resetRepo: resetRepo, mailer: mailer}
+}
+
+// Import Users (Admin)
+// Desc: Upload roster (multipart field "file", .csv / .xlsx).
+// Query: type=student|lecturer, dryRun=true (validasi saja), invite=true (kirim email undangan).
+func (s *ImportService) ImportUsers(c *fiber.Ctx) error {
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "File is required (multipart field 'file')"})
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Failed to read file"})
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Failed to read file"})
+	}
+
+	rows, err := utils.ReadSpreadsheet(fileHeader.Filename, data)
+	if err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: err.Error()})
+	}
+
+	report, err := s.Import(rows, ImportOptions{
+		Type:   c.Query("type", c.FormValue("type")),
+		DryRun: c.QueryBool("dryRun", c.FormValue("dryRun") == "true"),
+		Invite: c.QueryBool("invite", c.FormValue("invite") == "true"),
+	})
+	if err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: err.Error()})
+	}
+
+	message := "Import completed"
+	if report.DryRun {
+		message = "Dry run completed, no changes were saved"
+	}
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: message, Data: report})
+}
+
+// Import memproses roster (baris pertama = header). Setiap baris diproses dalam savepoint sendiri,
+// jadi baris yang gagal tidak membatalkan baris lain. Error yang dikembalikan hanya untuk kesalahan
+// file secara keseluruhan (header tidak valid, tipe tidak dikenal, dll).
+func (s *ImportService) Import(rows [][]string, opts ImportOptions) (*ImportReport, error) {
+	// 1. Validasi opsi & header
+	var roleName, keyField string
+	switch opts.Type {
+	case ImportTypeStudent:
+		roleName, keyField = model.RoleMahasiswa, "nim"
+	case ImportTypeLecturer:
+		roleName, keyField = model.RoleDosenWali, "nip"
+	default:
+		return nil, fmt.Errorf("type must be %q or %q", ImportTypeStudent, ImportTypeLecturer)
+	}
+
+	records, err := mapImportRows(rows, keyField)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := s.roleRepo.FindByName(roleName)
+	if err != nil {
+		return nil, fmt.Errorf("role %s is not configured", roleName)
+	}
+
+	report := &ImportReport{Type: opts.Type, DryRun: opts.DryRun, Invite: opts.Invite, Total: len(records)}
+	results := make([]ImportRowResult, len(records))
+
+	// 2. Validasi format per baris & duplikat di dalam file
+	seenKeys := map[string]int{}
+	seenEmails := map[string]int{}
+	for i, row := range records {
+		result := ImportRowResult{Row: row.line, Key: row.get(keyField)}
+		result.Errors = validateImportRow(row, keyField)
+
+		if prev, ok := seenKeys[result.Key]; ok && result.Key != "" {
+			result.Errors = append(result.Errors, fmt.Sprintf("duplicate %s, already used on row %d", strings.ToUpper(keyField), prev))
+		} else {
+			seenKeys[result.Key] = row.line
+		}
+		email := strings.ToLower(row.get("email"))
+		if prev, ok := seenEmails[email]; ok && email != "" {
+			result.Errors = append(result.Errors, fmt.Sprintf("duplicate email, already used on row %d", prev))
+		} else {
+			seenEmails[email] = row.line
+		}
+		results[i] = result
+	}
+
+	// 3. Upsert dalam satu transaksi, satu savepoint per baris
+	var invites []*model.User
+	err = s.userRepo.Transaction(func(repo *repository.UserRepository) error {
+		for i, row := range records {
+			if len(results[i].Errors) > 0 {
+				continue
+			}
+
+			var created *model.User
+			rowErr := repo.Transaction(func(rowRepo *repository.UserRepository) error {
+				var err error
+				if opts.Type == ImportTypeStudent {
+					created, err = s.upsertStudent(rowRepo, role, row, opts, &results[i])
+				} else {
+					created, err = s.upsertLecturer(rowRepo, role, row, opts, &results[i])
+				}
+				return err
+			})
+			if rowErr != nil {
+				results[i].Action = "error"
+				results[i].InitialPassword = ""
+				results[i].Errors = append(results[i].Errors, rowErr.Error())
+				continue
+			}
+			// Dry run tidak mengundang siapa pun (user akan di-rollback)
+			if created != nil && opts.Invite && !opts.DryRun {
+				invites = append(invites, created)
+			}
+		}
+
+		if opts.DryRun {
+			return errDryRun
+		}
+		return nil
+	})
+	if err != nil && err != errDryRun {
+		return nil, fmt.Errorf("import failed: %w", err)
+	}
+
+	// 4. Ringkasan
+	for i := range results {
+		if len(results[i].Errors) > 0 {
+			results[i].Action = "error"
+		}
+		switch results[i].Action {
+		case "created":
+			report.Created++
+		case "updated":
+			report.Updated++
+		case "unchanged":
+			report.Unchanged++
+		default:
+			report.Failed++
+		}
+	}
+	report.Rows = results
+
+	// 5. Undangan dikirim setelah commit (user sudah benar-benar ada)
+	for _, user := range invites {
+		if err := s.sendInvite(user); err != nil {
+			log.Println("⚠️  Gagal mengirim undangan ke", user.Email, ":", err)
+		}
+	}
+
+	return report, nil
+}
+
+// upsertStudent membuat / memperbarui satu mahasiswa berdasarkan NIM
+func (s *ImportService) upsertStudent(repo *repository.UserRepository, role *model.Role, row importRow, opts ImportOptions, result *ImportRowResult) (*model.User, error) {
+	nim := row.get("nim")
+
+	// Dosen wali (opsional) dicari berdasarkan NIP
+	var advisorID *string
+	if advisorNIP := row.get("advisor_nip"); advisorNIP != "" {
+		advisor, err := repo.FindLecturerByNIP(advisorNIP)
+		if err != nil {
+			return nil, fmt.Errorf("advisor with NIP %s not found", advisorNIP)
+		}
+		advisorID = &advisor.ID
+	}
+
+	// Prodi & angkatan (opsional) dicocokkan ke data master lewat kode / nama / alias
+	programID, programName, _, msg := resolveMasterLabel(s.masterRepo, model.MasterProgramStudy, "program study", row.get("program_study"))
+	if msg != "" {
+		return nil, errors.New(msg)
+	}
+	yearID, yearName, _, msg := resolveMasterLabel(s.masterRepo, model.MasterAcademicYear, "academic year", row.get("academic_year"))
+	if msg != "" {
+		return nil, errors.New(msg)
+	}
+
+	existing, err := repo.FindStudentByNIM(nim)
+	if err == nil {
+		// Update (idempotent: baris yang sama tidak mengubah apa pun)
+		userUpdates, err := s.userChanges(repo, &existing.User, row)
+		if err != nil {
+			return nil, err
+		}
+
+		studentUpdates := map[string]interface{}{}
+		if programID != nil && (existing.ProgramStudyID == nil || *existing.ProgramStudyID != *programID) {
+			studentUpdates["program_study_id"] = *programID
+			studentUpdates["program_study"] = programName
+		}
+		if yearID != nil && (existing.AcademicYearID == nil || *existing.AcademicYearID != *yearID) {
+			studentUpdates["academic_year_id"] = *yearID
+			studentUpdates["academic_year"] = yearName
+		}
+		if err := s.applyUpdates(repo, existing.UserID, userUpdates, studentUpdates, nil, result); err != nil {
+			return nil, err
+		}
+
+		// Dosen wali diganti lewat AssignAdvisor agar tercatat di riwayat
+		if advisorID != nil {
+			changed, err := repo.AssignAdvisor(existing.ID, advisorID, nil, "Import roster")
+			if err != nil {
+				return nil, err
+			}
+			if changed {
+				result.Action = "updated"
+			}
+		}
+		return nil, nil
+	}
+
+	// Create baru (NIM milik user di trash tidak bisa dipakai ulang, user tersebut harus di-restore)
+	if _, msg := checkStudentIDAvailable(repo, nim); msg != "" {
+		return nil, errors.New(msg)
+	}
+	user, err := s.newImportUser(repo, role, row, nim, opts, result)
+	if err != nil {
+		return nil, err
+	}
+	student := &model.Student{
+		StudentID:      nim,
+		ProgramStudy:   programName,
+		ProgramStudyID: programID,
+		AcademicYear:   yearName,
+		AcademicYearID: yearID,
+		AdvisorID:      advisorID,
+	}
+	if err := repo.CreateWithProfile(user, student, nil); err != nil {
+		return nil, err
+	}
+
+	result.Action = "created"
+	return user, nil
+}
+
+// upsertLecturer membuat / memperbarui satu dosen berdasarkan NIP
+func (s *ImportService) upsertLecturer(repo *repository.UserRepository, role *model.Role, row importRow, opts ImportOptions, result *ImportRowResult) (*model.User, error) {
+	nip := row.get("nip")
+
+	departmentID, departmentName, _, msg := resolveMasterLabel(s.masterRepo, model.MasterDepartment, "department", row.get("department"))
+	if msg != "" {
+		return nil, errors.New(msg)
+	}
+
+	existing, err := repo.FindLecturerByNIP(nip)
+	if err == nil {
+		userUpdates, err := s.userChanges(repo, &existing.User, row)
+		if err != nil {
+			return nil, err
+		}
+
+		lecturerUpdates := map[string]interface{}{}
+		if departmentID != nil && (existing.DepartmentID == nil || *existing.DepartmentID != *departmentID) {
+			lecturerUpdates["department_id"] = *departmentID
+			lecturerUpdates["department"] = departmentName
+		}
+
+		return nil, s.applyUpdates(repo, existing.UserID, userUpdates, nil, lecturerUpdates, result)
+	}
+
+	if _, msg := checkLecturerIDAvailable(repo, nip); msg != "" {
+		return nil, errors.New(msg)
+	}
+	user, err := s.newImportUser(repo, role, row, nip, opts, result)
+	if err != nil {
+		return nil, err
+	}
+	lecturer := &model.Lecturer{LecturerID: nip, Department: departmentName, DepartmentID: departmentID}
+	if err := repo.CreateWithProfile(user, nil, lecturer); err != nil {
+		return nil, err
+	}
+
+	result.Action = "created"
+	return user, nil
+}
+
+// userChanges membandingkan nama & email di file dengan data user yang sudah ada
+func (s *ImportService) userChanges(repo *repository.UserRepository, user *model.User, row importRow) (map[string]interface{}, error) {
+	updates := map[string]interface{}{}
+	if name := row.get("name"); name != user.FullName {
+		updates["full_name"] = name
+	}
+	if email := row.get("email"); !strings.EqualFold(email, user.Email) {
+		taken, err := repo.IsUsernameOrEmailTaken("", email, user.ID)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			return nil, fmt.Errorf("email %s is already used by another user", email)
+		}
+		updates["email"] = email
+	}
+	return updates, nil
+}
+
+func (s *ImportService) applyUpdates(repo *repository.UserRepository, userID string, userUpdates, studentUpdates, lecturerUpdates map[string]interface{}, result *ImportRowResult) error {
+	if len(userUpdates) == 0 && len(studentUpdates) == 0 && len(lecturerUpdates) == 0 {
+		result.Action = "unchanged"
+		return nil
+	}
+	if err := repo.UpdateWithProfile(userID, userUpdates, studentUpdates, lecturerUpdates); err != nil {
+		return err
+	}
+	result.Action = "updated"
+	return nil
+}
+
+// newImportUser menyiapkan user baru. Username default = NIM/NIP.
+// Tanpa undangan, password awal dibuat acak dan dikembalikan di laporan (bukan dry run).
+func (s *ImportService) newImportUser(repo *repository.UserRepository, role *model.Role, row importRow, key string, opts ImportOptions, result *ImportRowResult) (*model.User, error) {
+	username := row.get("username")
+	if username == "" {
+		username = key
+	}
+	email := row.get("email")
+
+	taken, err := repo.IsUsernameOrEmailTaken(username, email, "")
+	if err != nil {
+		return nil, err
+	}
+	if taken {
+		return nil, fmt.Errorf("username %s or email %s is already used by another user", username, email)
+	}
+
+	passwordHash := dryRunPasswordHash
+	if !opts.DryRun {
+		// Mode undangan: password acak yang tidak diberikan ke siapa pun, user membuat password lewat link
+		password, err := utils.GenerateOpaqueToken()
+		if !opts.Invite {
+			length := utils.CurrentPasswordPolicy().MinLength
+			if length < 12 {
+				length = 12
+			}
+			password, err = utils.GenerateReadablePassword(length)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		passwordHash, err = utils.HashPassword(password)
+		if err != nil {
+			return nil, err
+		}
+		if !opts.Invite {
+			result.InitialPassword = password
+		}
+	}
+
+	return &model.User{
+		Username:     username,
+		Email:        email,
+		FullName:     row.get("name"),
+		PasswordHash: passwordHash,
+		RoleID:       role.ID,
+		IsActive:     true,
+	}, nil
+}
+
+// sendInvite membuat token set-password (berlaku inviteTTL) dan mengirim email undangan
+func (s *ImportService) sendInvite(user *model.User) error {
+	rawToken, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+	if err := s.resetRepo.Create(&model.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: utils.HashToken(rawToken),
+		ExpiresAt: time.Now().Add(inviteTTL),
+	}); err != nil {
+		return err
+	}
+	return s.mailer.Send(inviteMail(user, rawToken))
+}
+
+// mapImportRows memetakan baris file ke field berdasarkan header (baris pertama). Baris kosong dilewati.
+func mapImportRows(rows [][]string, keyField string) ([]importRow, error) {
+	if len(rows) == 0 {
+		return nil, errors.New("file is empty")
+	}
+
+	columns := map[int]string{}
+	found := map[string]bool{}
+	for i, header := range rows[0] {
+		name := strings.ToLower(strings.TrimSpace(header))
+		name = strings.NewReplacer(" ", "_", "-", "_", ".", "").Replace(name)
+		if field, ok := importColumnAliases[name]; ok {
+			columns[i] = field
+			found[field] = true
+		}
+	}
+
+	for _, required := range []string{keyField, "name", "email"} {
+		if !found[required] {
+			return nil, fmt.Errorf("missing required column %q", required)
+		}
+	}
+
+	var records []importRow
+	for i, cells := range rows[1:] {
+		row := importRow{line: i + 2, fields: map[string]string{}}
+		empty := true
+		for col, value := range cells {
+			if field, ok := columns[col]; ok {
+				row.fields[field] = value
+				if strings.TrimSpace(value) != "" {
+					empty = false
+				}
+			}
+		}
+		if !empty {
+			records = append(records, row)
+		}
+	}
+
+	if len(records) > maxImportRows {
+		return nil, fmt.Errorf("file has %d rows, the maximum is %d", len(records), maxImportRows)
+	}
+	return records, nil
+}
+
+// validateImportRow mengecek format field satu baris (tanpa akses database)
+func validateImportRow(row importRow, keyField string) []string {
+	var errs []string
+
+	if key := row.get(keyField); key == "" {
+		errs = append(errs, strings.ToUpper(keyField)+" is required")
+	} else if len(key) > 20 {
+		errs = append(errs, strings.ToUpper(keyField)+" must be at most 20 characters")
+	}
+
+	if name := row.get("name"); name == "" || len(name) > 100 {
+		errs = append(errs, "name must be 1-100 characters")
+	}
+
+	if email := row.get("email"); email == "" {
+		errs = append(errs, "email is required")
+	} else if _, err := mail.ParseAddress(email); err != nil || len(email) > 100 {
+		errs = append(errs, "invalid email address")
+	}
+
+	if username := row.get("username"); len(username) > 50 {
+		errs = append(errs, "username must be at most 50 characters")
+	}
+	if len(row.get("program_study")) > 100 {
+		errs = append(errs, "program study must be at most 100 characters")
+	}
+	if len(row.get("academic_year")) > 10 {
+		errs = append(errs, "academic year must be at most 10 characters")
+	}
+	if len(row.get("department")) > 100 {
+		errs = append(errs, "department must be at most 100 characters")
+	}
+	return errs
+}
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"math"
+	"sync"
+	"time"
+	"uas/app/model"
+	"uas/utils"
+)
+
+// Aturan proteksi brute-force pada /auth/login
+const (
+	loginBackoffAfter   = 3                // mulai backoff setelah 3x gagal berturut-turut
+	loginBackoffMax     = 30 * time.Second // jeda maksimum antar percobaan
+	loginLockThreshold  = 10               // akun dikunci setelah 10x gagal
+	loginLockDuration   = 15 * time.Minute
+	loginIPWindow       = 15 * time.Minute
+	loginIPMaxFailures  = 30 // batas gagal per IP dalam window di atas
+	invalidLoginMessage = "Invalid email or password"
+)
+
+// Hash palsu untuk menyamakan waktu respon saat email tidak terdaftar,
+// supaya endpoint tidak membocorkan akun mana yang ada lewat perbedaan timing.
+// Dibuat saat pertama dipakai agar memakai hasher aktif (hasil InitPasswordHasher).
+var (
+	dummyHashOnce sync.Once
+	dummyHash     string
+)
+
+func dummyPasswordHash() string {
+	dummyHashOnce.Do(func() {
+		dummyHash, _ = utils.HashPassword("dummy-password-for-timing-only")
+	})
+	return dummyHash
+}
+
+// loginBlocked mengecek apakah akun sedang dikunci atau masih dalam masa backoff
+func loginBlocked(user *model.User, now time.Time) bool {
+	if user.LockedUntil != nil && now.Before(*user.LockedUntil) {
+		return true
+	}
+
+	if user.FailedLoginAttempts >= loginBackoffAfter && user.LastFailedLoginAt != nil {
+		return now.Before(user.LastFailedLoginAt.Add(loginBackoffDelay(user.FailedLoginAttempts)))
+	}
+	return false
+}
+
+// loginBackoffDelay: 1s, 2s, 4s, ... (maks loginBackoffMax) sesuai jumlah kegagalan
+func loginBackoffDelay(failures int) time.Duration {
+	exp := failures - loginBackoffAfter
+	if exp > 10 {
+		return loginBackoffMax
+	}
+	delay := time.Duration(math.Pow(2, float64(exp))) * time.Second
+	if delay > loginBackoffMax {
+		return loginBackoffMax
+	}
+	return delay
+}
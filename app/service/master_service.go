@@ -0,0 +1,825 @@
+package service
+
+import (
+	"errors"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"uas/app/model"
+	"uas/app/repository"
+	"uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Format tanggal pada request data master
+const masterDateLayout = "2006-01-02"
+
+// Nama tahun akademik, mis. "2024/2025"
+var academicYearNamePattern = regexp.MustCompile(`^([0-9]{4})/([0-9]{4})$`)
+
+// MasterService menangani data master akademik: fakultas, jurusan, program studi, tahun akademik & semester.
+// Semua user login bisa membaca, perubahan hanya oleh Admin (user:manage).
+type MasterService struct {
+	masterRepo *repository.MasterRepository
+}
+
+func NewMasterService(masterRepo *repository.MasterRepository) *MasterService {
+	return &MasterService{masterRepo: masterRepo}
+}
+
+// ==========================================
+// FAKULTAS
+// ==========================================
+
+// List Faculties
+func (s *MasterService) GetFaculties(c *fiber.Ctx) error {
+	faculties, err := s.masterRepo.FindFaculties()
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Faculties retrieved successfully", Data: faculties})
+}
+
+// Create Faculty
+// Desc: Body {code, name}, kode & nama harus unik
+func (s *MasterService) CreateFaculty(c *fiber.Ctx) error {
+	var req struct {
+		Code string `json:"code"`
+		Name string `json:"name"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid request body"})
+	}
+
+	faculty := &model.Faculty{Code: utils.CleanLabel(req.Code), Name: utils.CleanLabel(req.Name)}
+	if status, msg := s.checkCodeAndName(&model.Faculty{}, faculty.Code, faculty.Name, ""); msg != "" {
+		return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+	}
+
+	if err := s.masterRepo.CreateFaculty(faculty); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to create faculty: " + err.Error()})
+	}
+	return c.Status(201).JSON(model.WebResponse{Code: 201, Status: "success", Message: "Faculty created successfully", Data: faculty})
+}
+
+// Update Faculty
+func (s *MasterService) UpdateFaculty(c *fiber.Ctx) error {
+	faculty, err := s.masterRepo.FindFacultyByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Faculty not found"})
+	}
+
+	var req struct {
+		Code *string `json:"code"`
+		Name *string `json:"name"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid request body"})
+	}
+
+	code, name := faculty.Code, faculty.Name
+	if req.Code != nil {
+		code = utils.CleanLabel(*req.Code)
+	}
+	if req.Name != nil {
+		name = utils.CleanLabel(*req.Name)
+	}
+	if status, msg := s.checkCodeAndName(&model.Faculty{}, code, name, faculty.ID); msg != "" {
+		return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+	}
+
+	if err := s.masterRepo.UpdateFaculty(faculty.ID, map[string]interface{}{"code": code, "name": name}); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to update faculty: " + err.Error()})
+	}
+
+	updated, _ := s.masterRepo.FindFacultyByID(faculty.ID)
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Faculty updated successfully", Data: updated})
+}
+
+// Delete Faculty
+// Desc: Hanya jika tidak punya jurusan
+func (s *MasterService) DeleteFaculty(c *fiber.Ctx) error {
+	faculty, err := s.masterRepo.FindFacultyByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Faculty not found"})
+	}
+
+	if count, err := s.masterRepo.CountUsage(&model.Department{}, "faculty_id", faculty.ID); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	} else if count > 0 {
+		return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: "Faculty still has departments"})
+	}
+
+	if err := s.masterRepo.DeleteFaculty(faculty.ID); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to delete faculty: " + err.Error()})
+	}
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Faculty deleted successfully"})
+}
+
+// ==========================================
+// JURUSAN
+// ==========================================
+
+// List Departments
+// Desc: Filter opsional facultyId
+func (s *MasterService) GetDepartments(c *fiber.Ctx) error {
+	departments, err := s.masterRepo.FindDepartments(c.Query("facultyId"))
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Departments retrieved successfully", Data: departments})
+}
+
+// Create Department
+// Desc: Body {facultyId, code, name}, nama unik dalam satu fakultas
+func (s *MasterService) CreateDepartment(c *fiber.Ctx) error {
+	var req struct {
+		FacultyID string `json:"facultyId"`
+		Code      string `json:"code"`
+		Name      string `json:"name"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid request body"})
+	}
+
+	if _, err := s.masterRepo.FindFacultyByID(req.FacultyID); err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Faculty not found"})
+	}
+
+	department := &model.Department{FacultyID: req.FacultyID, Code: utils.CleanLabel(req.Code), Name: utils.CleanLabel(req.Name)}
+	if status, msg := s.checkCodeAndName(&model.Department{}, department.Code, department.Name, "", "faculty_id = ?", department.FacultyID); msg != "" {
+		return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+	}
+
+	if err := s.masterRepo.CreateDepartment(department); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to create department: " + err.Error()})
+	}
+
+	created, _ := s.masterRepo.FindDepartmentByID(department.ID)
+	return c.Status(201).JSON(model.WebResponse{Code: 201, Status: "success", Message: "Department created successfully", Data: created})
+}
+
+// Update Department
+// Desc: Ganti nama ikut memperbarui nama jurusan pada data dosen
+func (s *MasterService) UpdateDepartment(c *fiber.Ctx) error {
+	department, err := s.masterRepo.FindDepartmentByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Department not found"})
+	}
+
+	var req struct {
+		FacultyID *string `json:"facultyId"`
+		Code      *string `json:"code"`
+		Name      *string `json:"name"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid request body"})
+	}
+
+	facultyID, code, name := department.FacultyID, department.Code, department.Name
+	if req.FacultyID != nil && *req.FacultyID != facultyID {
+		if _, err := s.masterRepo.FindFacultyByID(*req.FacultyID); err != nil {
+			return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Faculty not found"})
+		}
+		facultyID = *req.FacultyID
+	}
+	if req.Code != nil {
+		code = utils.CleanLabel(*req.Code)
+	}
+	if req.Name != nil {
+		name = utils.CleanLabel(*req.Name)
+	}
+	if status, msg := s.checkCodeAndName(&model.Department{}, code, name, department.ID, "faculty_id = ?", facultyID); msg != "" {
+		return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+	}
+
+	updates := map[string]interface{}{"faculty_id": facultyID, "code": code}
+	if name != department.Name {
+		updates["name"] = name
+	}
+	if err := s.masterRepo.UpdateDepartment(department.ID, updates); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to update department: " + err.Error()})
+	}
+
+	updated, _ := s.masterRepo.FindDepartmentByID(department.ID)
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Department updated successfully", Data: updated})
+}
+
+// Delete Department
+// Desc: Hanya jika tidak punya program studi & tidak dirujuk dosen
+func (s *MasterService) DeleteDepartment(c *fiber.Ctx) error {
+	department, err := s.masterRepo.FindDepartmentByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Department not found"})
+	}
+
+	if count, err := s.masterRepo.CountUsage(&model.ProgramStudy{}, "department_id", department.ID); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	} else if count > 0 {
+		return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: "Department still has program studies"})
+	}
+	if count, err := s.masterRepo.CountUsage(&model.Lecturer{}, "department_id", department.ID); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	} else if count > 0 {
+		return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: "Department is still used by " + strconv.FormatInt(count, 10) + " lecturer(s)"})
+	}
+
+	if err := s.masterRepo.DeleteDepartment(department.ID); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to delete department: " + err.Error()})
+	}
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Department deleted successfully"})
+}
+
+// ==========================================
+// PROGRAM STUDI
+// ==========================================
+
+// List Program Studies
+// Desc: Filter opsional departmentId & facultyId
+func (s *MasterService) GetProgramStudies(c *fiber.Ctx) error {
+	programs, err := s.masterRepo.FindProgramStudies(c.Query("departmentId"), c.Query("facultyId"))
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Program studies retrieved successfully", Data: programs})
+}
+
+// Create Program Study
+// Desc: Body {departmentId, code, name, degree}, nama unik dalam satu jurusan
+func (s *MasterService) CreateProgramStudy(c *fiber.Ctx) error {
+	var req struct {
+		DepartmentID string `json:"departmentId"`
+		Code         string `json:"code"`
+		Name         string `json:"name"`
+		Degree       string `json:"degree"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid request body"})
+	}
+
+	if _, err := s.masterRepo.FindDepartmentByID(req.DepartmentID); err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Department not found"})
+	}
+
+	program := &model.ProgramStudy{
+		DepartmentID: req.DepartmentID,
+		Code:         utils.CleanLabel(req.Code),
+		Name:         utils.CleanLabel(req.Name),
+		Degree:       strings.ToUpper(strings.TrimSpace(req.Degree)),
+	}
+	if len(program.Degree) > 10 {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Degree must be at most 10 characters"})
+	}
+	if status, msg := s.checkCodeAndName(&model.ProgramStudy{}, program.Code, program.Name, "", "department_id = ?", program.DepartmentID); msg != "" {
+		return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+	}
+
+	if err := s.masterRepo.CreateProgramStudy(program); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to create program study: " + err.Error()})
+	}
+
+	created, _ := s.masterRepo.FindProgramStudyByID(program.ID)
+	return c.Status(201).JSON(model.WebResponse{Code: 201, Status: "success", Message: "Program study created successfully", Data: created})
+}
+
+// Update Program Study
+// Desc: Ganti nama ikut memperbarui nama prodi pada data mahasiswa
+func (s *MasterService) UpdateProgramStudy(c *fiber.Ctx) error {
+	program, err := s.masterRepo.FindProgramStudyByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Program study not found"})
+	}
+
+	var req struct {
+		DepartmentID *string `json:"departmentId"`
+		Code         *string `json:"code"`
+		Name         *string `json:"name"`
+		Degree       *string `json:"degree"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid request body"})
+	}
+
+	departmentID, code, name, degree := program.DepartmentID, program.Code, program.Name, program.Degree
+	if req.DepartmentID != nil && *req.DepartmentID != departmentID {
+		if _, err := s.masterRepo.FindDepartmentByID(*req.DepartmentID); err != nil {
+			return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Department not found"})
+		}
+		departmentID = *req.DepartmentID
+	}
+	if req.Code != nil {
+		code = utils.CleanLabel(*req.Code)
+	}
+	if req.Name != nil {
+		name = utils.CleanLabel(*req.Name)
+	}
+	if req.Degree != nil {
+		degree = strings.ToUpper(strings.TrimSpace(*req.Degree))
+	}
+	if len(degree) > 10 {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Degree must be at most 10 characters"})
+	}
+	if status, msg := s.checkCodeAndName(&model.ProgramStudy{}, code, name, program.ID, "department_id = ?", departmentID); msg != "" {
+		return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+	}
+
+	updates := map[string]interface{}{"department_id": departmentID, "code": code, "degree": degree}
+	if name != program.Name {
+		updates["name"] = name
+	}
+	if err := s.masterRepo.UpdateProgramStudy(program.ID, updates); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to update program study: " + err.Error()})
+	}
+
+	updated, _ := s.masterRepo.FindProgramStudyByID(program.ID)
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Program study updated successfully", Data: updated})
+}
+
+// Delete Program Study
+// Desc: Hanya jika tidak dirujuk mahasiswa
+func (s *MasterService) DeleteProgramStudy(c *fiber.Ctx) error {
+	program, err := s.masterRepo.FindProgramStudyByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Program study not found"})
+	}
+
+	if count, err := s.masterRepo.CountUsage(&model.Student{}, "program_study_id", program.ID); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	} else if count > 0 {
+		return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: "Program study is still used by " + strconv.FormatInt(count, 10) + " student(s)"})
+	}
+
+	if err := s.masterRepo.DeleteProgramStudy(program.ID); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to delete program study: " + err.Error()})
+	}
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Program study deleted successfully"})
+}
+
+// ==========================================
+// TAHUN AKADEMIK & SEMESTER
+// ==========================================
+
+// List Academic Years
+// Desc: Terbaru di atas, beserta semesternya
+func (s *MasterService) GetAcademicYears(c *fiber.Ctx) error {
+	years, err := s.masterRepo.FindAcademicYears()
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Academic years retrieved successfully", Data: years})
+}
+
+// Current Academic Year
+// Desc: Tahun akademik & semester yang sedang berjalan hari ini
+func (s *MasterService) GetCurrentAcademicYear(c *fiber.Ctx) error {
+	now := time.Now()
+	year, err := s.masterRepo.FindCurrentAcademicYear(now)
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "No academic year is running today"})
+	}
+
+	var current *model.Semester
+	for i := range year.Semesters {
+		semester := &year.Semesters[i]
+		if !now.Before(semester.StartDate) && now.Before(semester.EndDate.AddDate(0, 0, 1)) {
+			current = semester
+			break
+		}
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Current academic year retrieved successfully", Data: fiber.Map{
+		"academicYear": year,
+		"semester":     current,
+	}})
+}
+
+// Create Academic Year
+// Desc: Body {name: "2024/2025", startDate, endDate} (format tanggal YYYY-MM-DD), rentang tidak boleh beririsan
+func (s *MasterService) CreateAcademicYear(c *fiber.Ctx) error {
+	var req struct {
+		Name      string `json:"name"`
+		StartDate string `json:"startDate"`
+		EndDate   string `json:"endDate"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid request body"})
+	}
+
+	year := &model.AcademicYear{Name: strings.TrimSpace(req.Name)}
+	var msg string
+	if year.StartDate, year.EndDate, msg = parseDateRange(req.StartDate, req.EndDate); msg != "" {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: msg})
+	}
+	if status, msg := s.checkAcademicYear(year.Name, year.StartDate, year.EndDate, ""); msg != "" {
+		return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+	}
+
+	if err := s.masterRepo.CreateAcademicYear(year); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to create academic year: " + err.Error()})
+	}
+	return c.Status(201).JSON(model.WebResponse{Code: 201, Status: "success", Message: "Academic year created successfully", Data: year})
+}
+
+// Update Academic Year
+// Desc: Semester yang sudah ada harus tetap berada di dalam rentang tanggal baru
+func (s *MasterService) UpdateAcademicYear(c *fiber.Ctx) error {
+	year, err := s.masterRepo.FindAcademicYearByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Academic year not found"})
+	}
+
+	var req struct {
+		Name      *string `json:"name"`
+		StartDate *string `json:"startDate"`
+		EndDate   *string `json:"endDate"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid request body"})
+	}
+
+	name := year.Name
+	startRaw, endRaw := year.StartDate.Format(masterDateLayout), year.EndDate.Format(masterDateLayout)
+	if req.Name != nil {
+		name = strings.TrimSpace(*req.Name)
+	}
+	if req.StartDate != nil {
+		startRaw = *req.StartDate
+	}
+	if req.EndDate != nil {
+		endRaw = *req.EndDate
+	}
+
+	start, end, msg := parseDateRange(startRaw, endRaw)
+	if msg != "" {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: msg})
+	}
+	if status, msg := s.checkAcademicYear(name, start, end, year.ID); msg != "" {
+		return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+	}
+	for _, semester := range year.Semesters {
+		if semester.StartDate.Before(start) || semester.EndDate.After(end) {
+			return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: "Semester " + semester.Term + " would fall outside the academic year"})
+		}
+	}
+
+	updates := map[string]interface{}{"start_date": start, "end_date": end}
+	if name != year.Name {
+		updates["name"] = name
+	}
+	if err := s.masterRepo.UpdateAcademicYear(year.ID, updates); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to update academic year: " + err.Error()})
+	}
+
+	updated, _ := s.masterRepo.FindAcademicYearByID(year.ID)
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Academic year updated successfully", Data: updated})
+}
+
+// Delete Academic Year
+// Desc: Semester ikut terhapus, hanya jika tidak dirujuk mahasiswa
+func (s *MasterService) DeleteAcademicYear(c *fiber.Ctx) error {
+	year, err := s.masterRepo.FindAcademicYearByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Academic year not found"})
+	}
+
+	if count, err := s.masterRepo.CountUsage(&model.Student{}, "academic_year_id", year.ID); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	} else if count > 0 {
+		return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: "Academic year is still used by " + strconv.FormatInt(count, 10) + " student(s)"})
+	}
+
+	if err := s.masterRepo.DeleteAcademicYear(year.ID); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to delete academic year: " + err.Error()})
+	}
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Academic year deleted successfully"})
+}
+
+// Create Semester
+// Desc: Body {term: ganjil|genap|pendek, startDate, endDate}, harus di dalam tahun akademik & tidak beririsan
+func (s *MasterService) CreateSemester(c *fiber.Ctx) error {
+	year, err := s.masterRepo.FindAcademicYearByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Academic year not found"})
+	}
+
+	var req struct {
+		Term      string `json:"term"`
+		StartDate string `json:"startDate"`
+		EndDate   string `json:"endDate"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid request body"})
+	}
+
+	semester := &model.Semester{AcademicYearID: year.ID, Term: strings.ToLower(strings.TrimSpace(req.Term))}
+	var msg string
+	if semester.StartDate, semester.EndDate, msg = parseDateRange(req.StartDate, req.EndDate); msg != "" {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: msg})
+	}
+	for _, existing := range year.Semesters {
+		if existing.Term == semester.Term {
+			return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: "Semester " + semester.Term + " already exists in this academic year"})
+		}
+	}
+	if status, msg := s.checkSemester(year, semester, ""); msg != "" {
+		return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+	}
+
+	if err := s.masterRepo.CreateSemester(semester); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to create semester: " + err.Error()})
+	}
+	return c.Status(201).JSON(model.WebResponse{Code: 201, Status: "success", Message: "Semester created successfully", Data: semester})
+}
+
+// Update Semester
+// Desc: Ubah tanggal mulai / selesai (term tidak bisa diganti, hapus & buat ulang)
+func (s *MasterService) UpdateSemester(c *fiber.Ctx) error {
+	semester, err := s.masterRepo.FindSemesterByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Semester not found"})
+	}
+	year, err := s.masterRepo.FindAcademicYearByID(semester.AcademicYearID)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+
+	var req struct {
+		StartDate *string `json:"startDate"`
+		EndDate   *string `json:"endDate"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid request body"})
+	}
+
+	startRaw, endRaw := semester.StartDate.Format(masterDateLayout), semester.EndDate.Format(masterDateLayout)
+	if req.StartDate != nil {
+		startRaw = *req.StartDate
+	}
+	if req.EndDate != nil {
+		endRaw = *req.EndDate
+	}
+	var msg string
+	if semester.StartDate, semester.EndDate, msg = parseDateRange(startRaw, endRaw); msg != "" {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: msg})
+	}
+	if status, msg := s.checkSemester(year, semester, semester.ID); msg != "" {
+		return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+	}
+
+	if err := s.masterRepo.UpdateSemester(semester.ID, map[string]interface{}{"start_date": semester.StartDate, "end_date": semester.EndDate}); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to update semester: " + err.Error()})
+	}
+
+	updated, _ := s.masterRepo.FindSemesterByID(semester.ID)
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Semester updated successfully", Data: updated})
+}
+
+// Delete Semester
+func (s *MasterService) DeleteSemester(c *fiber.Ctx) error {
+	semester, err := s.masterRepo.FindSemesterByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Semester not found"})
+	}
+
+	if err := s.masterRepo.DeleteSemester(semester.ID); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to delete semester: " + err.Error()})
+	}
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Semester deleted successfully"})
+}
+
+// ==========================================
+// ALIAS & PEMETAAN TEKS LAMA
+// ==========================================
+
+// List Aliases
+// Desc: Filter opsional entityType (department | program_study | academic_year)
+func (s *MasterService) GetAliases(c *fiber.Ctx) error {
+	aliases, err := s.masterRepo.FindAliases(c.Query("entityType"))
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Aliases retrieved successfully", Data: aliases})
+}
+
+// Create Alias
+// Desc: Body {entityType, alias, targetId}, mis. "T. Informatika" -> prodi Teknik Informatika.
+// Teks lama yang cocok langsung dipetakan ulang.
+func (s *MasterService) CreateAlias(c *fiber.Ctx) error {
+	var req struct {
+		EntityType string `json:"entityType"`
+		Alias      string `json:"alias"`
+		TargetID   string `json:"targetId"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid request body"})
+	}
+
+	alias := &model.MasterAlias{EntityType: req.EntityType, Alias: utils.NormalizeLabel(req.Alias), TargetID: req.TargetID}
+	if alias.Alias == "" || len(alias.Alias) > 100 {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Alias must be 1-100 characters"})
+	}
+
+	var targetErr error
+	switch alias.EntityType {
+	case model.MasterDepartment:
+		_, targetErr = s.masterRepo.FindDepartmentByID(alias.TargetID)
+	case model.MasterProgramStudy:
+		_, targetErr = s.masterRepo.FindProgramStudyByID(alias.TargetID)
+	case model.MasterAcademicYear:
+		_, targetErr = s.masterRepo.FindAcademicYearByID(alias.TargetID)
+	default:
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "entityType must be department, program_study or academic_year"})
+	}
+	if targetErr != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Alias target not found"})
+	}
+
+	if _, err := s.masterRepo.FindAlias(alias.EntityType, alias.Alias); err == nil {
+		return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: "Alias is already used"})
+	}
+
+	if err := s.masterRepo.CreateAlias(alias); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to create alias: " + err.Error()})
+	}
+
+	result, err := s.masterRepo.MapLegacyValues()
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Alias created, but failed to map legacy values: " + err.Error()})
+	}
+	return c.Status(201).JSON(model.WebResponse{Code: 201, Status: "success", Message: "Alias created successfully", Data: fiber.Map{
+		"alias":   alias,
+		"mapping": result,
+	}})
+}
+
+// Delete Alias
+// Desc: Data yang sudah terpetakan lewat alias ini tidak berubah
+func (s *MasterService) DeleteAlias(c *fiber.Ctx) error {
+	alias, err := s.masterRepo.FindAliasByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Alias not found"})
+	}
+
+	if err := s.masterRepo.DeleteAlias(alias.ID); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to delete alias: " + err.Error()})
+	}
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Alias deleted successfully"})
+}
+
+// Unmapped Legacy Values
+// Desc: Teks bebas lama (prodi, angkatan, jurusan) yang belum terpetakan, untuk dibuatkan alias / data master
+func (s *MasterService) GetUnmappedValues(c *fiber.Ctx) error {
+	values, err := s.masterRepo.FindUnmappedValues()
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Unmapped values retrieved successfully", Data: values})
+}
+
+// Remap Legacy Values
+// Desc: Jalankan ulang pemetaan teks lama (mis. setelah menambah data master baru)
+func (s *MasterService) RemapLegacyValues(c *fiber.Ctx) error {
+	result, err := s.masterRepo.MapLegacyValues()
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to map legacy values: " + err.Error()})
+	}
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Legacy values mapped", Data: result})
+}
+
+// --- Helper ---
+
+// checkCodeAndName memvalidasi kode (unik global) & nama (unik dalam scope, mis. "faculty_id = ?")
+func (s *MasterService) checkCodeAndName(record interface{}, code string, name string, excludeID string, scope ...interface{}) (int, string) {
+	if code == "" || len(code) > 20 {
+		return 400, "Code must be 1-20 characters"
+	}
+	if name == "" || len(name) > 100 {
+		return 400, "Name must be 1-100 characters"
+	}
+
+	taken, err := s.masterRepo.IsTaken(record, "code", code, excludeID)
+	if err != nil {
+		return 500, err.Error()
+	}
+	if taken {
+		return 409, "Code is already used"
+	}
+
+	taken, err = s.masterRepo.IsTaken(record, "name", name, excludeID, scope...)
+	if err != nil {
+		return 500, err.Error()
+	}
+	if taken {
+		return 409, "Name is already used"
+	}
+	return 0, ""
+}
+
+// checkAcademicYear: nama "YYYY/YYYY" berurutan, unik & rentang tanggal tidak beririsan dengan tahun akademik lain
+func (s *MasterService) checkAcademicYear(name string, start time.Time, end time.Time, excludeID string) (int, string) {
+	match := academicYearNamePattern.FindStringSubmatch(name)
+	if match == nil {
+		return 400, "Academic year name must use the YYYY/YYYY format"
+	}
+	first, _ := strconv.Atoi(match[1])
+	second, _ := strconv.Atoi(match[2])
+	if second != first+1 {
+		return 400, "Academic year must span two consecutive years"
+	}
+
+	taken, err := s.masterRepo.IsTaken(&model.AcademicYear{}, "name", name, excludeID)
+	if err != nil {
+		return 500, err.Error()
+	}
+	if taken {
+		return 409, "Academic year already exists"
+	}
+
+	overlap, err := s.masterRepo.IsAcademicYearOverlapping(start, end, excludeID)
+	if err != nil {
+		return 500, err.Error()
+	}
+	if overlap {
+		return 409, "Date range overlaps another academic year"
+	}
+	return 0, ""
+}
+
+// checkSemester: term valid, di dalam rentang tahun akademik & tidak beririsan dengan semester lain
+func (s *MasterService) checkSemester(year *model.AcademicYear, semester *model.Semester, excludeID string) (int, string) {
+	switch semester.Term {
+	case model.SemesterGanjil, model.SemesterGenap, model.SemesterPendek:
+	default:
+		return 400, "term must be ganjil, genap or pendek"
+	}
+	if semester.StartDate.Before(year.StartDate) || semester.EndDate.After(year.EndDate) {
+		return 400, "Semester must be within the academic year (" + year.StartDate.Format(masterDateLayout) + " - " + year.EndDate.Format(masterDateLayout) + ")"
+	}
+
+	overlap, err := s.masterRepo.IsSemesterOverlapping(year.ID, semester.StartDate, semester.EndDate, excludeID)
+	if err != nil {
+		return 500, err.Error()
+	}
+	if overlap {
+		return 409, "Date range overlaps another semester"
+	}
+	return 0, ""
+}
+
+// parseDateRange membaca tanggal YYYY-MM-DD, tanggal mulai harus sebelum tanggal selesai
+func parseDateRange(startRaw string, endRaw string) (time.Time, time.Time, string) {
+	start, err := time.Parse(masterDateLayout, strings.TrimSpace(startRaw))
+	if err != nil {
+		return time.Time{}, time.Time{}, "startDate must use the YYYY-MM-DD format"
+	}
+	end, err := time.Parse(masterDateLayout, strings.TrimSpace(endRaw))
+	if err != nil {
+		return time.Time{}, time.Time{}, "endDate must use the YYYY-MM-DD format"
+	}
+	if !start.Before(end) {
+		return time.Time{}, time.Time{}, "startDate must be before endDate"
+	}
+	return start, end, ""
+}
+
+// resolveMasterLabel menerjemahkan ID / kode / nama / alias data master menjadi ID & nama resmi.
+// Teks kosong = referensi dikosongkan. Return status & pesan error (kosong jika valid).
+func resolveMasterLabel(masterRepo *repository.MasterRepository, entityType string, field string, label string) (*string, string, int, string) {
+	label = utils.CleanLabel(label)
+	if label == "" {
+		return nil, "", 0, ""
+	}
+
+	var id, name string
+	var err error
+	switch entityType {
+	case model.MasterProgramStudy:
+		var program *model.ProgramStudy
+		if program, err = masterRepo.ResolveProgramStudy(label); err == nil {
+			id, name = program.ID, program.Name
+		}
+	case model.MasterAcademicYear:
+		var year *model.AcademicYear
+		if year, err = masterRepo.ResolveAcademicYear(label); err == nil {
+			id, name = year.ID, year.Name
+		}
+	case model.MasterDepartment:
+		var department *model.Department
+		if department, err = masterRepo.ResolveDepartment(label); err == nil {
+			id, name = department.ID, department.Name
+		}
+	}
+
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, "", 400, "Unknown " + field + " " + strconv.Quote(label)
+	case errors.Is(err, repository.ErrAmbiguousLabel):
+		return nil, "", 400, field + " " + strconv.Quote(label) + " is ambiguous, use its code or id"
+	case err != nil:
+		return nil, "", 500, err.Error()
+	}
+	return &id, name, 0, ""
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+	"uas/app/model"
+	"uas/app/repository"
+	"uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Cookie penyimpan state/nonce/PKCE verifier selama user login di IdP
+const oidcStateCookie = "oidc_state"
+
+// OIDCService menangani Single Sign-On dengan identity provider kampus (authorization code + PKCE)
+type OIDCService struct {
+	provider    *utils.OIDCProvider // nil jika SSO tidak dikonfigurasi
+	userRepo    *repository.UserRepository
+	roleRepo    *repository.RoleRepository
+	authService *AuthService // Untuk menerbitkan token (termasuk MFA challenge) setelah SSO berhasil
+}
+
+func NewOIDCService(provider *utils.OIDCProvider, userRepo *repository.UserRepository, roleRepo *repository.RoleRepository, authService *AuthService) *OIDCService {
+	return &OIDCService{
+		provider:    provider,
+		userRepo:    userRepo,
+		roleRepo:    roleRepo,
+		authService: authService,
+	}
+}
+
+// Redirect user ke halaman login IdP
+func (s *OIDCService) Login(c *fiber.Ctx) error {
+	if s.provider == nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "SSO is not configured"})
+	}
+
+	// 1. Buat state, nonce & PKCE verifier, simpan di cookie bertanda tangan
+	state, err1 := utils.GenerateOpaqueToken()
+	nonce, err2 := utils.GenerateOpaqueToken()
+	verifier, challenge, err3 := utils.GeneratePKCE()
+	if err1 != nil || err2 != nil || err3 != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to start SSO login"})
+	}
+
+	stateToken, err := utils.GenerateOIDCStateToken(state, nonce, verifier)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to start SSO login"})
+	}
+
+	authURL, err := s.provider.AuthCodeURL(c.Context(), state, nonce, challenge)
+	if err != nil {
+		return c.Status(502).JSON(model.WebResponse{Code: 502, Status: "error", Message: "Identity provider unavailable"})
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     oidcStateCookie,
+		Value:    stateToken,
+		Expires:  time.Now().Add(utils.OIDCStateTTL),
+		HTTPOnly: true,
+		Secure:   strings.HasPrefix(s.provider.Config.RedirectURL, "https://"),
+		SameSite: "Lax", // Cookie harus ikut terkirim saat IdP me-redirect balik
+	})
+
+	// 2. Redirect ke IdP
+	return c.Redirect(authURL, fiber.StatusFound)
+}
+
+// Callback dari IdP: tukar code, verifikasi ID token, petakan ke user lokal, lalu terbitkan token
+func (s *OIDCService) Callback(c *fiber.Ctx) error {
+	if s.provider == nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "SSO is not configured"})
+	}
+
+	if idpErr := c.Query("error"); idpErr != "" {
+		return c.Status(401).JSON(model.WebResponse{Code: 401, Status: "error", Message: "SSO login failed: " + idpErr})
+	}
+
+	// 1. Validasi state (anti CSRF) dari cookie
+	stateClaims, err := utils.ParseOIDCStateToken(c.Cookies(oidcStateCookie))
+	c.ClearCookie(oidcStateCookie)
+	if err != nil || c.Query("state") == "" || c.Query("state") != stateClaims.State {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid or expired SSO state"})
+	}
+
+	// 2. Tukar code dengan ID token (PKCE) lalu verifikasi
+	rawIDToken, err := s.provider.Exchange(c.Context(), c.Query("code"), stateClaims.CodeVerifier)
+	if err != nil {
+		return c.Status(401).JSON(model.WebResponse{Code: 401, Status: "error", Message: "SSO login failed: " + err.Error()})
+	}
+
+	claims, err := s.provider.VerifyIDToken(c.Context(), rawIDToken, stateClaims.Nonce)
+	if err != nil {
+		return c.Status(401).JSON(model.WebResponse{Code: 401, Status: "error", Message: "Invalid ID token: " + err.Error()})
+	}
+
+	// 3. Petakan claim IdP ke user lokal (opsional: buat otomatis)
+	user, status, err := s.resolveUser(claims)
+	if err != nil {
+		return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: err.Error()})
+	}
+	if !user.IsActive || user.IsServiceAccount {
+		return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "User account is inactive"})
+	}
+
+	// 4. Lanjut seperti login biasa (MFA tetap berlaku)
+	return s.authService.completeLogin(c, user)
+}
+
+// resolveUser mencari user berdasarkan NIM, NIP, lalu email. Jika tidak ada dan JIT aktif, user dibuat baru.
+func (s *OIDCService) resolveUser(claims jwt.MapClaims) (*model.User, int, error) {
+	cfg := s.provider.Config
+	nim := claimString(claims, cfg.NIMClaim)
+	nip := claimString(claims, cfg.NIPClaim)
+	email := claimString(claims, "email")
+
+	// Email hanya dipakai untuk mencocokkan / membuat akun jika IdP menyatakan email_verified = true.
+	// Claim yang tidak ada dianggap belum terverifikasi, sehingga hanya NIM/NIP yang dicocokkan.
+	if verified, _ := claims["email_verified"].(bool); !verified {
+		email = ""
+	}
+
+	if nim != "" {
+		if student, err := s.userRepo.FindStudentByNIM(nim); err == nil {
+			return &student.User, 200, nil
+		}
+	}
+	if nip != "" {
+		if lecturer, err := s.userRepo.FindLecturerByNIP(nip); err == nil {
+			return &lecturer.User, 200, nil
+		}
+	}
+	if email != "" {
+		if user, err := s.userRepo.FindByEmail(email); err == nil {
+			return user, 200, nil
+		}
+	}
+
+	if !cfg.JITProvisioning || email == "" || (nim == "" && nip == "") {
+		return nil, 403, errors.New("No local account is linked to this SSO identity")
+	}
+	return s.provisionUser(claims, nim, nip, email)
+}
+
+// provisionUser membuat user + profil Mahasiswa/Dosen dari claim IdP (just-in-time provisioning)
+func (s *OIDCService) provisionUser(claims jwt.MapClaims, nim, nip, email string) (*model.User, int, error) {
+	roleName := model.RoleMahasiswa
+	username := nim
+	if nim == "" {
+		roleName = model.RoleDosenWali
+		username = nip
+	}
+	if preferred := claimString(claims, "preferred_username"); preferred != "" {
+		username = preferred
+	}
+
+	fullName := claimString(claims, "name")
+	if fullName == "" {
+		fullName = username
+	}
+
+	role, err := s.roleRepo.FindByName(roleName)
+	if err != nil {
+		return nil, 500, errors.New("Role " + roleName + " is not configured")
+	}
+
+	// Password acak yang tidak diketahui siapa pun, user SSO login lewat IdP (bisa reset password jika perlu)
+	randomPassword, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return nil, 500, errors.New("Failed to provision user")
+	}
+	hash, err := utils.HashPassword(randomPassword)
+	if err != nil {
+		return nil, 500, errors.New("Failed to provision user")
+	}
+
+	user := &model.User{
+		Username:     username,
+		Email:        email,
+		FullName:     fullName,
+		PasswordHash: hash,
+		RoleID:       role.ID,
+		IsActive:     true,
+	}
+
+	var student *model.Student
+	var lecturer *model.Lecturer
+	if nim != "" {
+		student = &model.Student{StudentID: nim}
+	} else {
+		lecturer = &model.Lecturer{LecturerID: nip}
+	}
+
+	if err := s.userRepo.CreateWithProfile(user, student, lecturer); err != nil {
+		return nil, 409, errors.New("Failed to provision user: " + err.Error())
+	}
+
+	user.Role = *role
+	return user, 200, nil
+}
+
+func claimString(claims jwt.MapClaims, name string) string {
+	value, _ := claims[name].(string)
+	return strings.TrimSpace(value)
+}
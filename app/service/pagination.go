@@ -0,0 +1,57 @@
+package service
+
+import (
+	"math"
+	"uas/app/model"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ==========================================
+// HELPER FUNCTIONS (Untuk Modul 6)
+// Dipakai bersama oleh list achievements, users, dll.
+// ==========================================
+
+const maxPageLimit = 100
+
+func parsePagination(c *fiber.Ctx) model.PaginationParam {
+	param := model.PaginationParam{
+		Page:   c.QueryInt("page", 1),
+		Limit:  c.QueryInt("limit", 10),
+		SortBy: c.Query("sortBy", "created_at"),
+		Order:  c.Query("order", "desc"),
+		Search: c.Query("search", ""),
+	}
+
+	// Cegah page/limit tidak valid (limit 0 juga membuat totalPage dibagi nol)
+	if param.Page < 1 {
+		param.Page = 1
+	}
+	if param.Limit < 1 {
+		param.Limit = 10
+	}
+	if param.Limit > maxPageLimit {
+		param.Limit = maxPageLimit
+	}
+	return param
+}
+
+func sendPaginationResponse(c *fiber.Ctx, data interface{}, total int64, param model.PaginationParam) error {
+	totalPages := int(math.Ceil(float64(total) / float64(param.Limit)))
+
+	return c.JSON(model.WebResponse{
+		Code:    200,
+		Status:  "success",
+		Message: "Data retrieved successfully",
+		Data:    data,
+		Meta: &model.MetaInfo{
+			Page:      param.Page,
+			Limit:     param.Limit,
+			TotalData: total,
+			TotalPage: totalPages,
+			SortBy:    param.SortBy,
+			Order:     param.Order,
+			Search:    param.Search,
+		},
+	})
+}
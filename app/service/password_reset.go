@@ -0,0 +1,50 @@
+package service
+
+import (
+	"net/url"
+	"os"
+	"time"
+	"uas/app/model"
+	"uas/utils"
+)
+
+// Masa berlaku link reset password
+const passwordResetTTL = 30 * time.Minute
+
+// Masa berlaku link undangan (set password pertama kali) untuk user hasil import
+const inviteTTL = 7 * 24 * time.Hour
+
+// passwordResetMail menyusun email berisi link reset password
+func passwordResetMail(user *model.User, rawToken string) utils.Mail {
+	link := passwordResetLink(rawToken)
+
+	return utils.Mail{
+		To:      user.Email,
+		Subject: "Reset Password Sistem Pelaporan Prestasi",
+		Body: "Halo " + user.FullName + ",\n\n" +
+			"Kami menerima permintaan reset password untuk akun Anda.\n" +
+			"Buka link berikut untuk membuat password baru (berlaku 30 menit, sekali pakai):\n\n" +
+			link + "\n\n" +
+			"Abaikan email ini jika Anda tidak meminta reset password.\n",
+	}
+}
+
+// inviteMail menyusun email undangan untuk akun baru, berisi link untuk membuat password pertama kali
+func inviteMail(user *model.User, rawToken string) utils.Mail {
+	return utils.Mail{
+		To:      user.Email,
+		Subject: "Undangan Akun Sistem Pelaporan Prestasi",
+		Body: "Halo " + user.FullName + ",\n\n" +
+			"Akun Anda di Sistem Pelaporan Prestasi telah dibuat dengan username: " + user.Username + "\n" +
+			"Buka link berikut untuk membuat password Anda (berlaku 7 hari, sekali pakai):\n\n" +
+			passwordResetLink(rawToken) + "\n",
+	}
+}
+
+func passwordResetLink(rawToken string) string {
+	baseURL := os.Getenv("PASSWORD_RESET_URL")
+	if baseURL == "" {
+		baseURL = "http://localhost:3000/reset-password"
+	}
+	return baseURL + "?token=" + url.QueryEscape(rawToken)
+}
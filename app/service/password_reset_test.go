@@ -0,0 +1,94 @@
+package service
+
+import (
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"uas/app/model"
+	"uas/utils"
+)
+
+// readOutboxLink membaca satu-satunya email di outbox dan mengambil link di dalamnya
+func readOutboxLink(t *testing.T, dir string) (string, *url.URL) {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil || len(files) != 1 {
+		t.Fatalf("expected exactly one email in outbox, got %d (%v)", len(files), err)
+	}
+	raw, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, line := range strings.Split(string(raw), "\n") {
+		if strings.HasPrefix(line, "https://") || strings.HasPrefix(line, "http://") {
+			link, err := url.Parse(strings.TrimSpace(line))
+			if err != nil {
+				t.Fatal(err)
+			}
+			return string(raw), link
+		}
+	}
+	t.Fatalf("no link found in email:\n%s", raw)
+	return "", nil
+}
+
+// Alur forgot-password sampai token: token yang diterima user lewat email (outbox)
+// harus cocok dengan hash yang disimpan, dan bisa dikirim ulang ke /reset-password apa adanya.
+func TestPasswordResetMailThroughOutbox(t *testing.T) {
+	t.Setenv("PASSWORD_RESET_URL", "https://prestasi.example.ac.id/reset-password")
+	dir := t.TempDir()
+	mailer := &utils.OutboxMailer{Dir: dir, From: "no-reply@example.ac.id"}
+
+	user := &model.User{Email: "mahasiswa@example.ac.id", FullName: "Budi"}
+	rawToken, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+	storedHash := utils.HashToken(rawToken) // Yang disimpan di password_reset_tokens
+
+	if err := mailer.Send(passwordResetMail(user, rawToken)); err != nil {
+		t.Fatal(err)
+	}
+
+	message, link := readOutboxLink(t, dir)
+	if !strings.Contains(message, "To: mahasiswa@example.ac.id\r\n") {
+		t.Errorf("email not addressed to the user:\n%s", message)
+	}
+	if link.Host != "prestasi.example.ac.id" || link.Path != "/reset-password" {
+		t.Errorf("unexpected reset link %s", link)
+	}
+
+	token := link.Query().Get("token")
+	if token != rawToken {
+		t.Errorf("token in email %q differs from issued token %q", token, rawToken)
+	}
+	if utils.HashToken(token) != storedHash {
+		t.Error("token from email does not match the stored hash")
+	}
+}
+
+func TestInviteMailThroughOutbox(t *testing.T) {
+	t.Setenv("PASSWORD_RESET_URL", "")
+	dir := t.TempDir()
+	mailer := &utils.OutboxMailer{Dir: dir, From: "no-reply@example.ac.id"}
+
+	user := &model.User{Email: "dosen@example.ac.id", FullName: "Siti", Username: "siti"}
+	if err := mailer.Send(inviteMail(user, "token+with/special=chars")); err != nil {
+		t.Fatal(err)
+	}
+
+	message, link := readOutboxLink(t, dir)
+	if !strings.Contains(message, "username: siti") {
+		t.Errorf("invite does not mention the username:\n%s", message)
+	}
+	if link.Host != "localhost:3000" {
+		t.Errorf("expected default reset URL, got %s", link)
+	}
+	if got := link.Query().Get("token"); got != "token+with/special=chars" {
+		t.Errorf("token not escaped correctly, got %q", got)
+	}
+}
@@ -0,0 +1,542 @@
+package service
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"uas/app/model"
+	"uas/app/repository"
+	"uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+)
+
+// Permission yang dipakai untuk mengelola user & role, minimal satu role harus tetap memilikinya
+const userManagePermission = "user:manage"
+
+// Role bawaan dirujuk berdasarkan nama oleh kode (login, import, profil), jadi tidak boleh diganti nama / dihapus
+var builtinRoles = map[string]bool{
+	model.RoleAdmin:     true,
+	model.RoleMahasiswa: true,
+	model.RoleDosenWali: true,
+}
+
+// Format resource & action permission: huruf kecil, angka, underscore, atau wildcard "*"
+var permissionPartPattern = regexp.MustCompile(`^(\*|[a-z][a-z0-9_]{0,49})$`)
+
+// RoleService menangani administrasi role & permission (RBAC) oleh Admin. Setiap perubahan diaudit.
+type RoleService struct {
+	roleRepo *repository.RoleRepository
+	userRepo *repository.UserRepository
+}
+
+func NewRoleService(roleRepo *repository.RoleRepository, userRepo *repository.UserRepository) *RoleService {
+	return &RoleService{roleRepo: roleRepo, userRepo: userRepo}
+}
+
+// List Roles
+// Desc: Semua role beserta permission & jumlah user-nya
+func (s *RoleService) GetAllRoles(c *fiber.Ctx) error {
+	roles, err := s.roleRepo.FindAll()
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+	keys, err := s.roleRepo.PermissionKeysByRole()
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+	counts, err := s.roleRepo.CountUsersByRole(false)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+
+	data := make([]fiber.Map, 0, len(roles))
+	for _, role := range roles {
+		data = append(data, roleData(&role, keys[role.ID], counts[role.ID]))
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Roles retrieved successfully", Data: data})
+}
+
+// Detail Role
+// Desc: Role beserta daftar permission lengkap (id, resource, action)
+func (s *RoleService) GetRoleDetail(c *fiber.Ctx) error {
+	role, err := s.roleRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Role not found"})
+	}
+
+	return s.sendRoleDetail(c, role, "Role retrieved successfully")
+}
+
+// Create Role
+// Desc: Buat role baru, opsional langsung dengan permissionIds
+func (s *RoleService) CreateRole(c *fiber.Ctx) error {
+	var req struct {
+		Name          string   `json:"name"`
+		Description   string   `json:"description"`
+		MFARequired   bool     `json:"mfaRequired"`
+		PermissionIDs []string `json:"permissionIds"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid request body"})
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" || len(name) > 50 {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Role name must be 1-50 characters"})
+	}
+	if _, err := s.roleRepo.FindByName(name); err == nil {
+		return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: "Role name is already used"})
+	}
+
+	permissions, status, msg := s.findPermissions(req.PermissionIDs)
+	if msg != "" {
+		return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+	}
+
+	role := &model.Role{Name: name, Description: strings.TrimSpace(req.Description), MFARequired: req.MFARequired}
+	audit := newAuditLog(c, "role.create", "role", "", map[string]interface{}{
+		"name":        role.Name,
+		"mfaRequired": role.MFARequired,
+		"permissions": permissionKeys(permissions),
+	})
+	audit.StatusCode = 201
+	if err := s.roleRepo.CreateRole(role, permissionIDs(permissions), audit); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to create role: " + err.Error()})
+	}
+
+	c.Status(201)
+	return s.sendRoleDetail(c, role, "Role created successfully")
+}
+
+// Update Role
+// Desc: Ubah nama, deskripsi & wajib MFA. Role bawaan tidak bisa diganti nama.
+func (s *RoleService) UpdateRole(c *fiber.Ctx) error {
+	role, err := s.roleRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Role not found"})
+	}
+
+	var req struct {
+		Name        *string `json:"name"`
+		Description *string `json:"description"`
+		MFARequired *bool   `json:"mfaRequired"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid request body"})
+	}
+
+	updates := map[string]interface{}{}
+	changes := map[string]interface{}{}
+	if req.Name != nil && strings.TrimSpace(*req.Name) != role.Name {
+		name := strings.TrimSpace(*req.Name)
+		if builtinRoles[role.Name] {
+			return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Built-in roles cannot be renamed"})
+		}
+		if name == "" || len(name) > 50 {
+			return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Role name must be 1-50 characters"})
+		}
+		if _, err := s.roleRepo.FindByName(name); err == nil {
+			return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: "Role name is already used"})
+		}
+		updates["name"] = name
+		changes["name"] = fiber.Map{"from": role.Name, "to": name}
+	}
+	if req.Description != nil && strings.TrimSpace(*req.Description) != role.Description {
+		updates["description"] = strings.TrimSpace(*req.Description)
+		changes["description"] = fiber.Map{"from": role.Description, "to": updates["description"]}
+	}
+	if req.MFARequired != nil && *req.MFARequired != role.MFARequired {
+		updates["mfa_required"] = *req.MFARequired
+		changes["mfaRequired"] = fiber.Map{"from": role.MFARequired, "to": *req.MFARequired}
+	}
+
+	if len(updates) > 0 {
+		audit := newAuditLog(c, "role.update", "role", role.ID, changes)
+		if err := s.roleRepo.UpdateRole(role.ID, updates, audit); err != nil {
+			return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to update role: " + err.Error()})
+		}
+		s.roleRepo.InvalidateRole(role.ID)
+
+		if role, err = s.roleRepo.FindByID(role.ID); err != nil {
+			return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+		}
+	}
+
+	return s.sendRoleDetail(c, role, "Role updated successfully")
+}
+
+// Delete Role
+// Desc: Hanya role non-bawaan yang tidak dipakai user (termasuk user di trash).
+// Role terakhir yang memiliki user:manage tidak bisa dihapus.
+func (s *RoleService) DeleteRole(c *fiber.Ctx) error {
+	role, err := s.roleRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Role not found"})
+	}
+	if builtinRoles[role.Name] {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Built-in roles cannot be deleted"})
+	}
+
+	inUse, err := s.userRepo.IsRoleInUse(role.ID)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+	if inUse {
+		return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: "Role is still assigned to users, reassign them before deleting the role"})
+	}
+
+	// Guardrail user:manage dicek di dalam transaksi penghapusan (baris role_permissions dikunci)
+	guard := func(keys map[string][]string, activeUsers map[string]int64) error {
+		return checkUserManageKept(keys, activeUsers, role.ID, nil)
+	}
+
+	audit := newAuditLog(c, "role.delete", "role", role.ID, map[string]interface{}{"name": role.Name})
+	if err := s.roleRepo.DeleteRole(role.ID, guard, audit); err != nil {
+		var guardErr *roleGuardError
+		if errors.As(err, &guardErr) {
+			return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: guardErr.Message})
+		}
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to delete role: " + err.Error()})
+	}
+	s.roleRepo.InvalidateRole(role.ID)
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Role deleted successfully"})
+}
+
+// Attach Permissions
+// Desc: Tambahkan permission ke role (body: permissionIds), permission yang sudah terpasang diabaikan
+func (s *RoleService) AttachPermissions(c *fiber.Ctx) error {
+	role, err := s.roleRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Role not found"})
+	}
+
+	var req struct {
+		PermissionIDs []string `json:"permissionIds"`
+	}
+	if err := c.BodyParser(&req); err != nil || len(req.PermissionIDs) == 0 {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "permissionIds is required"})
+	}
+
+	permissions, status, msg := s.findPermissions(req.PermissionIDs)
+	if msg != "" {
+		return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+	}
+
+	audit := newAuditLog(c, "role.permissions.attach", "role", role.ID, map[string]interface{}{
+		"role":        role.Name,
+		"permissions": permissionKeys(permissions),
+	})
+	if err := s.roleRepo.AttachPermissions(role.ID, permissionIDs(permissions), audit); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to attach permissions: " + err.Error()})
+	}
+	s.roleRepo.InvalidateRole(role.ID)
+
+	return s.sendRoleDetail(c, role, "Permissions attached successfully")
+}
+
+// Detach Permission
+// Desc: Lepas satu permission dari role. Ditolak jika membuat tidak ada lagi user aktif yang memiliki user:manage.
+func (s *RoleService) DetachPermission(c *fiber.Ctx) error {
+	role, err := s.roleRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Role not found"})
+	}
+	permission, err := s.roleRepo.FindPermissionByID(c.Params("permissionId"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Permission not found"})
+	}
+
+	// Guardrail user:manage dicek di dalam transaksi (baris role_permissions dikunci)
+	guard := func(keys map[string][]string, activeUsers map[string]int64) error {
+		remaining := make([]string, 0, len(keys[role.ID]))
+		for _, key := range keys[role.ID] {
+			if key != permission.Key() {
+				remaining = append(remaining, key)
+			}
+		}
+		return checkUserManageKept(keys, activeUsers, role.ID, remaining)
+	}
+
+	audit := newAuditLog(c, "role.permissions.detach", "role", role.ID, map[string]interface{}{
+		"role":       role.Name,
+		"permission": permission.Key(),
+	})
+	if err := s.roleRepo.DetachPermission(role.ID, permission.ID, guard, audit); err != nil {
+		var guardErr *roleGuardError
+		if errors.As(err, &guardErr) {
+			return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: guardErr.Message})
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Permission is not attached to this role"})
+		}
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to detach permission: " + err.Error()})
+	}
+	s.roleRepo.InvalidateRole(role.ID)
+
+	return s.sendRoleDetail(c, role, "Permission detached successfully")
+}
+
+// List Role Users
+// Desc: User yang memiliki role ini (pagination, search, sort seperti GET /users)
+func (s *RoleService) GetRoleUsers(c *fiber.Ctx) error {
+	role, err := s.roleRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Role not found"})
+	}
+
+	param := parsePagination(c)
+	users, total, err := s.userRepo.FindAll(param, repository.UserFilter{RoleID: role.ID})
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+
+	return sendPaginationResponse(c, users, total, param)
+}
+
+// --- Permissions ---
+
+// List Permissions
+func (s *RoleService) GetAllPermissions(c *fiber.Ctx) error {
+	permissions, err := s.roleRepo.FindAllPermissions()
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Permissions retrieved successfully", Data: permissions})
+}
+
+// Create Permission
+// Desc: Permission baru dengan format resource:action (boleh wildcard "*")
+func (s *RoleService) CreatePermission(c *fiber.Ctx) error {
+	var req struct {
+		Resource    string `json:"resource"`
+		Action      string `json:"action"`
+		Description string `json:"description"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid request body"})
+	}
+
+	resource := strings.TrimSpace(req.Resource)
+	action := strings.TrimSpace(req.Action)
+	if !permissionPartPattern.MatchString(resource) || !permissionPartPattern.MatchString(action) {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Resource and action must be lowercase letters, digits or underscores (max 50), or \"*\""})
+	}
+
+	permission := &model.Permission{
+		Name:        resource + ":" + action,
+		Resource:    resource,
+		Action:      action,
+		Description: strings.TrimSpace(req.Description),
+	}
+
+	taken, err := s.roleRepo.IsPermissionTaken(permission.Name, resource, action)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+	if taken {
+		return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: "Permission already exists"})
+	}
+
+	audit := newAuditLog(c, "permission.create", "permission", "", map[string]interface{}{"permission": permission.Name})
+	audit.StatusCode = 201
+	if err := s.roleRepo.CreatePermission(permission, audit); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to create permission: " + err.Error()})
+	}
+
+	return c.Status(201).JSON(model.WebResponse{Code: 201, Status: "success", Message: "Permission created successfully", Data: permission})
+}
+
+// Update Permission
+// Desc: Hanya deskripsi. Resource & action dirujuk oleh route, jadi tidak bisa diubah.
+func (s *RoleService) UpdatePermission(c *fiber.Ctx) error {
+	permission, err := s.roleRepo.FindPermissionByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Permission not found"})
+	}
+
+	var req struct {
+		Description string `json:"description"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid request body"})
+	}
+
+	description := strings.TrimSpace(req.Description)
+	if description != permission.Description {
+		audit := newAuditLog(c, "permission.update", "permission", permission.ID, map[string]interface{}{
+			"permission":  permission.Key(),
+			"description": fiber.Map{"from": permission.Description, "to": description},
+		})
+		if err := s.roleRepo.UpdatePermissionDescription(permission.ID, description, audit); err != nil {
+			return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to update permission: " + err.Error()})
+		}
+		permission.Description = description
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Permission updated successfully", Data: permission})
+}
+
+// Delete Permission
+// Desc: Hanya permission yang tidak terpasang di role mana pun
+func (s *RoleService) DeletePermission(c *fiber.Ctx) error {
+	permission, err := s.roleRepo.FindPermissionByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Permission not found"})
+	}
+
+	used, err := s.roleRepo.CountRolesWithPermission(permission.ID)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+	if used > 0 {
+		return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: "Permission is still attached to roles, detach it first"})
+	}
+
+	audit := newAuditLog(c, "permission.delete", "permission", permission.ID, map[string]interface{}{"permission": permission.Key()})
+	if err := s.roleRepo.DeletePermission(permission.ID, audit); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to delete permission: " + err.Error()})
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Permission deleted successfully"})
+}
+
+// --- Helper ---
+
+// roleGuardError adalah penolakan guardrail (409), dibedakan dari error database
+type roleGuardError struct {
+	Message string
+}
+
+func (e *roleGuardError) Error() string {
+	return e.Message
+}
+
+// checkUserManageKept memastikan setelah perubahan (role roleID punya permission remaining, nil = role dihapus)
+// masih ada role lain yang memiliki user:manage, dan dimiliki minimal satu user aktif.
+// Tanpa itu tidak ada lagi yang bisa mengelola user & role lewat API.
+// Dipanggil sebagai RolePermissionGuard di dalam transaksi perubahan.
+func checkUserManageKept(keys map[string][]string, activeUsers map[string]int64, roleID string, remaining []string) error {
+	// Perubahan yang tidak menyentuh user:manage selalu aman
+	if !utils.HasPermission(keys[roleID], userManagePermission) || (remaining != nil && utils.HasPermission(remaining, userManagePermission)) {
+		return nil
+	}
+
+	var holders int
+	var activeHolders int64
+	for id, granted := range keys {
+		if id == roleID || !utils.HasPermission(granted, userManagePermission) {
+			continue
+		}
+		holders++
+		activeHolders += activeUsers[id]
+	}
+
+	if holders == 0 {
+		return &roleGuardError{Message: "This is the last role holding " + userManagePermission + ", it cannot be removed"}
+	}
+	if activeHolders == 0 {
+		return &roleGuardError{Message: "No active user would hold " + userManagePermission + " after this change"}
+	}
+	return nil
+}
+
+// findPermissions memvalidasi daftar permission ID (semua harus ada)
+func (s *RoleService) findPermissions(ids []string) ([]model.Permission, int, string) {
+	if len(ids) == 0 {
+		return nil, 0, ""
+	}
+
+	unique := make([]string, 0, len(ids))
+	seen := map[string]bool{}
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	permissions, err := s.roleRepo.FindPermissionsByIDs(unique)
+	if err != nil {
+		return nil, 400, "Invalid permission ID"
+	}
+	if len(permissions) != len(unique) {
+		return nil, 400, "One or more permissions were not found"
+	}
+	return permissions, 0, ""
+}
+
+// sendRoleDetail mengirim role beserta permission lengkap & jumlah user-nya
+func (s *RoleService) sendRoleDetail(c *fiber.Ctx, role *model.Role, message string) error {
+	permissions, err := s.roleRepo.GetPermissionsByRoleID(role.ID)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+	counts, err := s.roleRepo.CountUsersByRole(false)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+
+	data := roleData(role, permissionKeys(permissions), counts[role.ID])
+	data["permissionDetails"] = permissions
+
+	code := c.Response().StatusCode()
+	return c.Status(code).JSON(model.WebResponse{Code: code, Status: "success", Message: message, Data: data})
+}
+
+func roleData(role *model.Role, permissions []string, users int64) fiber.Map {
+	if permissions == nil {
+		permissions = []string{}
+	}
+	return fiber.Map{
+		"id":          role.ID,
+		"name":        role.Name,
+		"description": role.Description,
+		"mfaRequired": role.MFARequired,
+		"builtin":     builtinRoles[role.Name],
+		"permissions": permissions,
+		"userCount":   users,
+		"createdAt":   role.CreatedAt,
+	}
+}
+
+func permissionKeys(permissions []model.Permission) []string {
+	keys := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		keys = append(keys, p.Key())
+	}
+	return keys
+}
+
+func permissionIDs(permissions []model.Permission) []string {
+	ids := make([]string, 0, len(permissions))
+	for _, p := range permissions {
+		ids = append(ids, p.ID)
+	}
+	return ids
+}
+
+// newAuditLog menyiapkan entry audit untuk aksi user yang sedang login.
+// Selama impersonation, actor adalah admin yang melakukan impersonate.
+func newAuditLog(c *fiber.Ctx, action string, targetType string, targetID string, metadata map[string]interface{}) *model.AuditLog {
+	entry := &model.AuditLog{
+		ActorID:    c.Locals("user_id").(string),
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+		Method:     c.Method(),
+		Path:       model.AuditPath(c.OriginalURL()),
+		StatusCode: 200,
+		IPAddress:  c.IP(),
+		Metadata:   metadata,
+	}
+	if impersonator, ok := c.Locals("impersonator_id").(string); ok && impersonator != "" {
+		onBehalf := entry.ActorID
+		entry.ActorID = impersonator
+		entry.OnBehalf = &onBehalf
+	}
+	return entry
+}
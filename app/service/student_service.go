@@ -0,0 +1,343 @@
+package service
+
+import (
+	"strings"
+	"uas/app/model"
+	"uas/app/repository"
+	"uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// StudentService menangani data Mahasiswa & Dosen Wali (FR: 5.5 Students & Lecturers)
+type StudentService struct {
+	userRepo *repository.UserRepository
+}
+
+func NewStudentService(userRepo *repository.UserRepository) *StudentService {
+	return &StudentService{userRepo: userRepo}
+}
+
+// List Students
+// Desc: Pagination, search (NIM / nama), sort, filter programStudyId, academicYearId, departmentId,
+// facultyId, advisorId & isActive.
+// Data dibatasi sesuai role: Admin melihat semua, Dosen Wali hanya mahasiswa bimbingannya,
+// Mahasiswa hanya dirinya sendiri.
+func (s *StudentService) GetAllStudents(c *fiber.Ctx) error {
+	param := parsePagination(c)
+
+	filter := repository.StudentFilter{
+		ProgramStudyID: c.Query("programStudyId"),
+		AcademicYearID: c.Query("academicYearId"),
+		DepartmentID:   c.Query("departmentId"),
+		FacultyID:      c.Query("facultyId"),
+		AdvisorID:      c.Query("advisorId"),
+		IsActive:       queryBool(c, "isActive"),
+	}
+
+	// Scope data berdasarkan role
+	userID := c.Locals("user_id").(string)
+	permissions, _ := c.Locals("permissions").([]string)
+	role, _ := c.Locals("role").(string)
+	switch {
+	case utils.HasPermission(permissions, "user:manage"):
+		// Admin: tanpa batasan
+	case role == model.RoleDosenWali:
+		lecturer, err := s.userRepo.FindLecturerByUserID(userID)
+		if err != nil {
+			return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Lecturer profile not found"})
+		}
+		filter.AdvisorID = lecturer.ID
+	case role == model.RoleMahasiswa:
+		filter.UserID = userID
+	default:
+		return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "Access denied"})
+	}
+
+	students, total, err := s.userRepo.FindStudents(param, filter)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+
+	data := make([]fiber.Map, 0, len(students))
+	for i := range students {
+		data = append(data, studentRow(&students[i]))
+	}
+	return sendPaginationResponse(c, data, total, param)
+}
+
+// Detail Student
+// Desc: Profil mahasiswa, dosen wali & jumlah prestasi per status.
+// Bisa dilihat Admin, mahasiswa itu sendiri, dan dosen wali-nya saat ini.
+func (s *StudentService) GetStudentDetail(c *fiber.Ctx) error {
+	student, err := s.userRepo.FindStudentByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Student not found"})
+	}
+	if !canViewStudent(c, student) {
+		return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "Access denied"})
+	}
+
+	stats, err := s.userRepo.CountAchievementsByStatus(student.ID)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+
+	data := studentRow(student)
+	data["programStudyRef"] = student.ProgramStudyRef // Beserta jurusan & fakultas
+	data["academicYearRef"] = student.AcademicYearRef
+	data["achievementStats"] = stats
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Student retrieved successfully", Data: data})
+}
+
+// List Lecturers
+// Desc: Pagination, search (NIP / nama), sort, filter departmentId, facultyId & isActive, beserta jumlah
+// mahasiswa bimbingan & prestasi yang menunggu verifikasi.
+// Admin melihat semua, Dosen Wali hanya dirinya sendiri, Mahasiswa hanya dosen wali-nya.
+func (s *StudentService) GetAllLecturers(c *fiber.Ctx) error {
+	param := parsePagination(c)
+
+	filter := repository.LecturerFilter{
+		DepartmentID: c.Query("departmentId"),
+		FacultyID:    c.Query("facultyId"),
+		IsActive:     queryBool(c, "isActive"),
+	}
+
+	userID := c.Locals("user_id").(string)
+	permissions, _ := c.Locals("permissions").([]string)
+	role, _ := c.Locals("role").(string)
+	switch {
+	case utils.HasPermission(permissions, "user:manage"):
+	case role == model.RoleDosenWali:
+		filter.UserID = userID
+	case role == model.RoleMahasiswa:
+		student, err := s.userRepo.FindStudentByUserID(userID)
+		if err != nil {
+			return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Student profile not found"})
+		}
+		if student.AdvisorID == nil {
+			return sendPaginationResponse(c, []fiber.Map{}, 0, param)
+		}
+		filter.LecturerID = *student.AdvisorID
+	default:
+		return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "Access denied"})
+	}
+
+	lecturers, total, err := s.userRepo.FindLecturers(param, filter)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+
+	ids := make([]string, 0, len(lecturers))
+	for _, l := range lecturers {
+		ids = append(ids, l.ID)
+	}
+	advisees, err := s.userRepo.CountAdviseesByLecturers(ids)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+	pending, err := s.userRepo.CountPendingByLecturers(ids)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+
+	data := make([]fiber.Map, 0, len(lecturers))
+	for _, l := range lecturers {
+		data = append(data, fiber.Map{
+			"id":                  l.ID,
+			"userId":              l.UserID,
+			"lecturerId":          l.LecturerID,
+			"fullName":            l.User.FullName,
+			"email":               l.User.Email,
+			"department":          l.Department,
+			"departmentId":        l.DepartmentID,
+			"isActive":            l.User.IsActive,
+			"adviseeCount":        advisees[l.ID],
+			"pendingVerification": pending[l.ID],
+			"createdAt":           l.CreatedAt,
+		})
+	}
+	return sendPaginationResponse(c, data, total, param)
+}
+
+// Update Student Advisor
+// Desc: Ganti dosen wali mahasiswa (advisorId null = lepas dosen wali). Perubahan dicatat di riwayat,
+// prestasi yang sudah diverifikasi tetap menampilkan dosen wali saat itu.
+func (s *StudentService) UpdateStudentAdvisor(c *fiber.Ctx) error {
+	actorID := c.Locals("user_id").(string)
+
+	student, err := s.userRepo.FindStudentByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Student not found"})
+	}
+
+	var req struct {
+		AdvisorID *string `json:"advisorId"`
+		Reason    string  `json:"reason"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid request body"})
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if len(reason) > 255 {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Reason must be at most 255 characters"})
+	}
+
+	var advisorID *string
+	if req.AdvisorID != nil && *req.AdvisorID != "" {
+		if _, status, msg := validateAdvisor(s.userRepo, *req.AdvisorID); msg != "" {
+			return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+		}
+		advisorID = req.AdvisorID
+	}
+
+	changed, err := s.userRepo.AssignAdvisor(student.ID, advisorID, &actorID, reason)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to update advisor: " + err.Error()})
+	}
+
+	message := "Advisor is unchanged"
+	if changed {
+		message = "Advisor updated successfully"
+		if student, err = s.userRepo.FindStudentByID(student.ID); err != nil {
+			return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+		}
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: message, Data: student})
+}
+
+// Advisor History
+// Desc: Riwayat dosen wali mahasiswa beserta periode berlakunya.
+// Bisa dilihat Admin (user:manage), mahasiswa itu sendiri, dan dosen wali-nya saat ini.
+func (s *StudentService) GetAdvisorHistory(c *fiber.Ctx) error {
+	student, err := s.userRepo.FindStudentByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Student not found"})
+	}
+
+	if !canViewStudent(c, student) {
+		return c.Status(403).JSON(model.WebResponse{Code: 403, Status: "error", Message: "Access denied"})
+	}
+
+	history, err := s.userRepo.FindAdvisorHistory(student.ID)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "Advisor history retrieved successfully", Data: history})
+}
+
+// Reassign Advisees
+// Desc: Pindahkan semua mahasiswa bimbingan dosen :id ke dosen lain (mis. dosen pensiun / pindah tugas)
+func (s *StudentService) ReassignAdvisees(c *fiber.Ctx) error {
+	actorID := c.Locals("user_id").(string)
+
+	from, err := s.userRepo.FindLecturerByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Lecturer not found"})
+	}
+
+	var req struct {
+		ToLecturerID string `json:"toLecturerId"`
+		Reason       string `json:"reason"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.ToLecturerID == "" {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "toLecturerId is required"})
+	}
+	if req.ToLecturerID == from.ID {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Target lecturer must be different from the current lecturer"})
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if len(reason) > 255 {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Reason must be at most 255 characters"})
+	}
+
+	to, status, msg := validateAdvisor(s.userRepo, req.ToLecturerID)
+	if msg != "" {
+		return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+	}
+
+	studentIDs, err := s.userRepo.ReassignAdvisees(from.ID, to.ID, &actorID, reason)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to reassign advisees: " + err.Error()})
+	}
+	if studentIDs == nil {
+		studentIDs = []string{}
+	}
+
+	return c.JSON(model.WebResponse{
+		Code:    200,
+		Status:  "success",
+		Message: "Advisees reassigned successfully",
+		Data: fiber.Map{
+			"fromLecturerId": from.ID,
+			"toLecturerId":   to.ID,
+			"moved":          len(studentIDs),
+			"studentIds":     studentIDs,
+		},
+	})
+}
+
+// validateAdvisor memastikan lecturerID adalah dosen dengan akun aktif ber-role Dosen Wali.
+// Return status & pesan error (kosong jika valid).
+func validateAdvisor(userRepo *repository.UserRepository, lecturerID string) (*model.Lecturer, int, string) {
+	lecturer, err := userRepo.FindLecturerByID(lecturerID)
+	if err != nil || lecturer.User.ID == "" {
+		return nil, 400, "Advisor must be an existing lecturer"
+	}
+	if lecturer.User.Role.Name != model.RoleDosenWali {
+		return nil, 400, "Advisor must have the " + model.RoleDosenWali + " role"
+	}
+	if !lecturer.User.IsActive {
+		return nil, 400, "Advisor account is deactivated"
+	}
+	return lecturer, 0, ""
+}
+
+// canViewStudent: Admin (user:manage), mahasiswa itu sendiri, atau dosen wali-nya saat ini
+func canViewStudent(c *fiber.Ctx, student *model.Student) bool {
+	userID := c.Locals("user_id").(string)
+	permissions, _ := c.Locals("permissions").([]string)
+
+	if utils.HasPermission(permissions, "user:manage") || student.UserID == userID {
+		return true
+	}
+	return student.Advisor != nil && student.Advisor.UserID == userID
+}
+
+// studentRow menyusun data mahasiswa untuk direktori (tanpa data sensitif akun)
+func studentRow(student *model.Student) fiber.Map {
+	row := fiber.Map{
+		"id":             student.ID,
+		"userId":         student.UserID,
+		"studentId":      student.StudentID,
+		"fullName":       student.User.FullName,
+		"email":          student.User.Email,
+		"programStudy":   student.ProgramStudy,
+		"programStudyId": student.ProgramStudyID,
+		"academicYear":   student.AcademicYear,
+		"academicYearId": student.AcademicYearID,
+		"isActive":       student.User.IsActive,
+		"advisor":        nil,
+		"createdAt":      student.CreatedAt,
+	}
+	if student.Advisor != nil {
+		row["advisor"] = fiber.Map{
+			"id":         student.Advisor.ID,
+			"lecturerId": student.Advisor.LecturerID,
+			"fullName":   student.Advisor.User.FullName,
+		}
+	}
+	return row
+}
+
+// queryBool membaca query boolean opsional (nil jika tidak dikirim)
+func queryBool(c *fiber.Ctx, key string) *bool {
+	raw := c.Query(key)
+	if raw == "" {
+		return nil
+	}
+	value := raw == "true"
+	return &value
+}
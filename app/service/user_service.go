@@ -0,0 +1,659 @@
+package service
+
+import (
+	"net/mail"
+	"strings"
+	"time"
+	"uas/app/model"
+	"uas/app/repository"
+	"uas/utils"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// UserService menangani manajemen user oleh Admin (FR: 5.2 Users)
+type UserService struct {
+	userRepo    *repository.UserRepository
+	roleRepo    *repository.RoleRepository
+	masterRepo  *repository.MasterRepository // Prodi, angkatan & jurusan merujuk ke data master
+	authService *AuthService                 // Untuk mencabut sesi & token user yang dinonaktifkan / dihapus
+}
+
+func NewUserService(userRepo *repository.UserRepository, roleRepo *repository.RoleRepository, masterRepo *repository.MasterRepository, authService *AuthService) *UserService {
+	return &UserService{userRepo: userRepo, roleRepo: roleRepo, masterRepo: masterRepo, authService: authService}
+}
+
+// Data profil Mahasiswa pada request create / ganti role.
+// programStudy, academicYear & department diisi ID, kode, nama, atau alias data master.
+type studentProfileRequest struct {
+	StudentID    string  `json:"studentId"`
+	ProgramStudy string  `json:"programStudy"`
+	AcademicYear string  `json:"academicYear"`
+	AdvisorID    *string `json:"advisorId"`
+}
+
+// Data profil Dosen pada request create / ganti role
+type lecturerProfileRequest struct {
+	LecturerID string `json:"lecturerId"`
+	Department string `json:"department"`
+}
+
+// List Users
+// Desc: Pagination, search (username/email/nama), sort, filter roleId & isActive
+func (s *UserService) GetAllUsers(c *fiber.Ctx) error {
+	param := parsePagination(c)
+
+	filter := repository.UserFilter{RoleID: c.Query("roleId")}
+	if active := c.Query("isActive"); active != "" {
+		isActive := active == "true"
+		filter.IsActive = &isActive
+	}
+
+	users, total, err := s.userRepo.FindAll(param, filter)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+
+	return sendPaginationResponse(c, users, total, param)
+}
+
+// Detail User
+// Desc: User + role + permissions + profil Mahasiswa/Dosen
+func (s *UserService) GetUserDetail(c *fiber.Ctx) error {
+	user, err := s.userRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "User not found"})
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Data: s.userDetail(user)})
+}
+
+// Create User
+// Desc: Membuat user beserta profil Mahasiswa/Dosen sesuai role dalam satu transaksi
+func (s *UserService) CreateUser(c *fiber.Ctx) error {
+	var req struct {
+		Username string                  `json:"username"`
+		Email    string                  `json:"email"`
+		Password string                  `json:"password"`
+		FullName string                  `json:"fullName"`
+		RoleID   string                  `json:"roleId"`
+		Student  *studentProfileRequest  `json:"student"`
+		Lecturer *lecturerProfileRequest `json:"lecturer"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid request body"})
+	}
+
+	// 1. Validasi field wajib
+	req.Username = strings.TrimSpace(req.Username)
+	req.Email = strings.TrimSpace(req.Email)
+	req.FullName = strings.TrimSpace(req.FullName)
+	if req.Username == "" || req.Email == "" || req.FullName == "" || req.RoleID == "" || req.Password == "" {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "username, email, password, fullName and roleId are required"})
+	}
+	if msg := validateUserFields(req.Username, req.Email, req.FullName); msg != "" {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: msg})
+	}
+	if err := utils.ValidatePassword(req.Password, nil); err != nil {
+		return sendPasswordError(c, err)
+	}
+
+	role, err := s.roleRepo.FindByID(req.RoleID)
+	if err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Role not found"})
+	}
+
+	// 2. Username / email harus unik
+	taken, err := s.userRepo.IsUsernameOrEmailTaken(req.Username, req.Email, "")
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+	if taken {
+		return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: "Username or email is already used"})
+	}
+
+	// 3. Profil sesuai role (Mahasiswa -> students, Dosen Wali -> lecturers)
+	student, lecturer, status, msg := s.buildProfile(role.Name, nil, nil, req.Student, req.Lecturer)
+	if msg != "" {
+		return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+	}
+
+	hash, err := utils.HashPassword(req.Password)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to hash password"})
+	}
+
+	user := &model.User{
+		Username:     req.Username,
+		Email:        req.Email,
+		PasswordHash: hash,
+		FullName:     req.FullName,
+		RoleID:       role.ID,
+		IsActive:     true,
+	}
+
+	// 4. Simpan user + profil (transaksi)
+	if err := s.userRepo.CreateWithProfile(user, student, lecturer); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to create user: " + err.Error()})
+	}
+
+	user.Role = *role
+	return c.Status(201).JSON(model.WebResponse{
+		Code:    201,
+		Status:  "success",
+		Message: "User created successfully",
+		Data:    s.userDetail(user),
+	})
+}
+
+// Update User
+// Desc: Ubah data user & profilnya (role diganti lewat PUT /users/:id/role, dosen wali lewat /students/:id/advisor)
+func (s *UserService) UpdateUser(c *fiber.Ctx) error {
+	var req struct {
+		Username *string `json:"username"`
+		Email    *string `json:"email"`
+		FullName *string `json:"fullName"`
+		Student  *struct {
+			StudentID    *string `json:"studentId"`
+			ProgramStudy *string `json:"programStudy"`
+			AcademicYear *string `json:"academicYear"`
+		} `json:"student"`
+		Lecturer *struct {
+			LecturerID *string `json:"lecturerId"`
+			Department *string `json:"department"`
+		} `json:"lecturer"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Invalid request body"})
+	}
+
+	user, err := s.userRepo.FindByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "User not found"})
+	}
+
+	// 1. Data user
+	username, email, fullName := user.Username, user.Email, user.FullName
+	if req.Username != nil {
+		username = strings.TrimSpace(*req.Username)
+	}
+	if req.Email != nil {
+		email = strings.TrimSpace(*req.Email)
+	}
+	if req.FullName != nil {
+		fullName = strings.TrimSpace(*req.FullName)
+	}
+	if msg := validateUserFields(username, email, fullName); msg != "" {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: msg})
+	}
+
+	userUpdates := map[string]interface{}{}
+	if username != user.Username {
+		userUpdates["username"] = username
+	}
+	if email != user.Email {
+		userUpdates["email"] = email
+	}
+	if fullName != user.FullName {
+		userUpdates["full_name"] = fullName
+	}
+
+	if len(userUpdates) > 0 {
+		taken, err := s.userRepo.IsUsernameOrEmailTaken(username, email, user.ID)
+		if err != nil {
+			return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+		}
+		if taken {
+			return c.Status(409).JSON(model.WebResponse{Code: 409, Status: "error", Message: "Username or email is already used"})
+		}
+	}
+
+	// 2. Data profil (hanya jika user memang punya profil tersebut)
+	studentUpdates := map[string]interface{}{}
+	if req.Student != nil {
+		student, err := s.userRepo.FindStudentByUserID(user.ID)
+		if err != nil {
+			return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "User has no student profile"})
+		}
+		if req.Student.StudentID != nil && *req.Student.StudentID != student.StudentID {
+			nim := strings.TrimSpace(*req.Student.StudentID)
+			if nim == "" || len(nim) > 20 {
+				return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "studentId must be 1-20 characters"})
+			}
+			if status, msg := checkStudentIDAvailable(s.userRepo, nim); msg != "" {
+				return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+			}
+			studentUpdates["student_id"] = nim
+		}
+		if req.Student.ProgramStudy != nil {
+			id, name, status, msg := resolveMasterLabel(s.masterRepo, model.MasterProgramStudy, "program study", *req.Student.ProgramStudy)
+			if msg != "" {
+				return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+			}
+			studentUpdates["program_study_id"] = id
+			studentUpdates["program_study"] = name
+		}
+		if req.Student.AcademicYear != nil {
+			id, name, status, msg := resolveMasterLabel(s.masterRepo, model.MasterAcademicYear, "academic year", *req.Student.AcademicYear)
+			if msg != "" {
+				return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+			}
+			studentUpdates["academic_year_id"] = id
+			studentUpdates["academic_year"] = name
+		}
+	}
+
+	lecturerUpdates := map[string]interface{}{}
+	if req.Lecturer != nil {
+		lecturer, err := s.userRepo.FindLecturerByUserID(user.ID)
+		if err != nil {
+			return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "User has no lecturer profile"})
+		}
+		if req.Lecturer.LecturerID != nil && *req.Lecturer.LecturerID != lecturer.LecturerID {
+			nip := strings.TrimSpace(*req.Lecturer.LecturerID)
+			if nip == "" || len(nip) > 20 {
+				return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "lecturerId must be 1-20 characters"})
+			}
+			if status, msg := checkLecturerIDAvailable(s.userRepo, nip); msg != "" {
+				return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+			}
+			lecturerUpdates["lecturer_id"] = nip
+		}
+		if req.Lecturer.Department != nil {
+			id, name, status, msg := resolveMasterLabel(s.masterRepo, model.MasterDepartment, "department", *req.Lecturer.Department)
+			if msg != "" {
+				return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+			}
+			lecturerUpdates["department_id"] = id
+			lecturerUpdates["department"] = name
+		}
+	}
+
+	// 3. Simpan (transaksi)
+	if err := s.userRepo.UpdateWithProfile(user.ID, userUpdates, studentUpdates, lecturerUpdates); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to update user: " + err.Error()})
+	}
+
+	updated, err := s.userRepo.FindByID(user.ID)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "User updated successfully", Data: s.userDetail(updated)})
+}
+
+// Delete User
+// Desc: Soft delete, user & profilnya masuk trash (bisa di-restore), prestasi tetap utuh.
+// Semua sesi & token dicabut. Dosen yang masih punya mahasiswa bimbingan tidak bisa dihapus.
+func (s *UserService) DeleteUser(c *fiber.Ctx) error {
+	userID := c.Params("id")
+	if userID == c.Locals("user_id").(string) {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Cannot delete your own account"})
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "User not found"})
+	}
+
+	if status, msg := s.checkProfileRemovable(user.ID, false, true); msg != "" {
+		return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+	}
+
+	if err := s.userRepo.Delete(user.ID); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to delete user: " + err.Error()})
+	}
+	s.roleRepo.InvalidateUser(user.ID)
+
+	if err := s.authService.revokeAllTokens(user.ID, time.Now()); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "User deleted, but failed to revoke sessions: " + err.Error()})
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "User moved to trash"})
+}
+
+// List Deleted Users (Trash)
+// Desc: User yang sudah dihapus (soft delete), terbaru di atas. Mendukung pagination & search.
+func (s *UserService) GetDeletedUsers(c *fiber.Ctx) error {
+	param := parsePagination(c)
+
+	users, total, err := s.userRepo.FindDeleted(param)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+
+	return sendPaginationResponse(c, users, total, param)
+}
+
+// Restore User
+// Desc: Kembalikan user dari trash beserta profilnya. Status aktif tidak berubah, user harus login ulang.
+func (s *UserService) RestoreUser(c *fiber.Ctx) error {
+	user, err := s.userRepo.FindDeletedByID(c.Params("id"))
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "Deleted user not found"})
+	}
+
+	if err := s.userRepo.Restore(user.ID); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to restore user: " + err.Error()})
+	}
+	s.roleRepo.InvalidateUser(user.ID)
+
+	restored, err := s.userRepo.FindByID(user.ID)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "User restored successfully", Data: s.userDetail(restored)})
+}
+
+// Update User Status
+// Desc: Aktifkan / nonaktifkan akun. User yang dinonaktifkan langsung ditolak di semua endpoint
+// (AuthRequired) dan seluruh sesi & token-nya dicabut.
+func (s *UserService) UpdateUserStatus(c *fiber.Ctx) error {
+	userID := c.Params("id")
+
+	var req struct {
+		IsActive *bool `json:"isActive"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.IsActive == nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "isActive is required"})
+	}
+
+	if userID == c.Locals("user_id").(string) && !*req.IsActive {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Cannot deactivate your own account"})
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "User not found"})
+	}
+
+	if user.IsActive != *req.IsActive {
+		if err := s.userRepo.SetActive(user.ID, *req.IsActive); err != nil {
+			return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to update user status: " + err.Error()})
+		}
+		s.roleRepo.InvalidateUser(user.ID)
+
+		if !*req.IsActive {
+			if err := s.authService.revokeAllTokens(user.ID, time.Now()); err != nil {
+				return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "User deactivated, but failed to revoke sessions: " + err.Error()})
+			}
+		}
+		user.IsActive = *req.IsActive
+	}
+
+	message := "User activated successfully"
+	if !user.IsActive {
+		message = "User deactivated successfully"
+	}
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: message, Data: s.userDetail(user)})
+}
+
+// Update User Role
+// Desc: Ganti role user. Profil ikut dikonversi: jadi Mahasiswa -> wajib data student (jika belum ada),
+// jadi Dosen Wali -> wajib data lecturer, profil lama dihapus jika tidak lagi sesuai (dan aman dihapus).
+func (s *UserService) UpdateUserRole(c *fiber.Ctx) error {
+	var req struct {
+		RoleID   string                  `json:"roleId"`
+		Student  *studentProfileRequest  `json:"student"`
+		Lecturer *lecturerProfileRequest `json:"lecturer"`
+	}
+	if err := c.BodyParser(&req); err != nil || req.RoleID == "" {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "roleId is required"})
+	}
+
+	userID := c.Params("id")
+	if userID == c.Locals("user_id").(string) {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Cannot change your own role"})
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return c.Status(404).JSON(model.WebResponse{Code: 404, Status: "error", Message: "User not found"})
+	}
+
+	role, err := s.roleRepo.FindByID(req.RoleID)
+	if err != nil {
+		return c.Status(400).JSON(model.WebResponse{Code: 400, Status: "error", Message: "Role not found"})
+	}
+
+	// 1. Profil yang sudah ada
+	var currentStudent *model.Student
+	if student, err := s.userRepo.FindStudentByUserID(user.ID); err == nil {
+		currentStudent = student
+	}
+	var currentLecturer *model.Lecturer
+	if lecturer, err := s.userRepo.FindLecturerByUserID(user.ID); err == nil {
+		currentLecturer = lecturer
+	}
+
+	// 2. Tentukan profil untuk role baru
+	student, lecturer, status, msg := s.buildProfile(role.Name, currentStudent, currentLecturer, req.Student, req.Lecturer)
+	if msg != "" {
+		return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+	}
+
+	// 3. Profil lama yang akan dihapus harus aman dihapus
+	if status, msg := s.checkProfileRemovable(user.ID, currentStudent != nil && student == nil, currentLecturer != nil && lecturer == nil); msg != "" {
+		return c.Status(status).JSON(model.WebResponse{Code: status, Status: "error", Message: msg})
+	}
+
+	// 4. Simpan (transaksi), versi permission user naik lewat trigger DB
+	if err := s.userRepo.ChangeRole(user.ID, role.ID, student, lecturer); err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: "Failed to change role: " + err.Error()})
+	}
+	s.roleRepo.InvalidateUser(user.ID)
+
+	updated, err := s.userRepo.FindByID(user.ID)
+	if err != nil {
+		return c.Status(500).JSON(model.WebResponse{Code: 500, Status: "error", Message: err.Error()})
+	}
+	return c.JSON(model.WebResponse{Code: 200, Status: "success", Message: "User role updated successfully", Data: s.userDetail(updated)})
+}
+
+// --- Helper ---
+
+// buildProfile menentukan profil yang harus dimiliki user untuk role tertentu.
+// Profil yang sudah ada dipakai ulang; jika belum ada, dibuat dari data request (wajib diisi).
+// Return status & pesan error (kosong jika valid).
+func (s *UserService) buildProfile(
+	roleName string,
+	currentStudent *model.Student,
+	currentLecturer *model.Lecturer,
+	studentReq *studentProfileRequest,
+	lecturerReq *lecturerProfileRequest,
+) (*model.Student, *model.Lecturer, int, string) {
+	switch roleName {
+	case model.RoleMahasiswa:
+		if currentStudent != nil {
+			return currentStudent, nil, 0, ""
+		}
+		if studentReq == nil || strings.TrimSpace(studentReq.StudentID) == "" {
+			return nil, nil, 400, "student.studentId is required for role " + roleName
+		}
+
+		nim := strings.TrimSpace(studentReq.StudentID)
+		if len(nim) > 20 {
+			return nil, nil, 400, "studentId must be at most 20 characters"
+		}
+		if status, msg := checkStudentIDAvailable(s.userRepo, nim); msg != "" {
+			return nil, nil, status, msg
+		}
+
+		student := &model.Student{StudentID: nim}
+		var status int
+		var msg string
+		if student.ProgramStudyID, student.ProgramStudy, status, msg = resolveMasterLabel(s.masterRepo, model.MasterProgramStudy, "program study", studentReq.ProgramStudy); msg != "" {
+			return nil, nil, status, msg
+		}
+		if student.AcademicYearID, student.AcademicYear, status, msg = resolveMasterLabel(s.masterRepo, model.MasterAcademicYear, "academic year", studentReq.AcademicYear); msg != "" {
+			return nil, nil, status, msg
+		}
+		if studentReq.AdvisorID != nil && *studentReq.AdvisorID != "" {
+			if _, status, msg := validateAdvisor(s.userRepo, *studentReq.AdvisorID); msg != "" {
+				return nil, nil, status, msg
+			}
+			student.AdvisorID = studentReq.AdvisorID
+		}
+		return student, nil, 0, ""
+
+	case model.RoleDosenWali:
+		if currentLecturer != nil {
+			return nil, currentLecturer, 0, ""
+		}
+		if lecturerReq == nil || strings.TrimSpace(lecturerReq.LecturerID) == "" {
+			return nil, nil, 400, "lecturer.lecturerId is required for role " + roleName
+		}
+
+		nip := strings.TrimSpace(lecturerReq.LecturerID)
+		if len(nip) > 20 {
+			return nil, nil, 400, "lecturerId must be at most 20 characters"
+		}
+		if status, msg := checkLecturerIDAvailable(s.userRepo, nip); msg != "" {
+			return nil, nil, status, msg
+		}
+
+		lecturer := &model.Lecturer{LecturerID: nip}
+		var status int
+		var msg string
+		if lecturer.DepartmentID, lecturer.Department, status, msg = resolveMasterLabel(s.masterRepo, model.MasterDepartment, "department", lecturerReq.Department); msg != "" {
+			return nil, nil, status, msg
+		}
+		return nil, lecturer, 0, ""
+	}
+
+	// Role lain (mis. Admin) tidak punya profil akademik
+	return nil, nil, 0, ""
+}
+
+// checkProfileRemovable memastikan profil Mahasiswa/Dosen milik user boleh dihapus:
+// mahasiswa tidak punya prestasi, dosen tidak punya mahasiswa bimbingan.
+func (s *UserService) checkProfileRemovable(userID string, student bool, lecturer bool) (int, string) {
+	if student {
+		if current, err := s.userRepo.FindStudentByUserID(userID); err == nil {
+			hasAchievements, err := s.userRepo.StudentHasAchievements(current.ID)
+			if err != nil {
+				return 500, err.Error()
+			}
+			if hasAchievements {
+				return 409, "Student still owns achievements, the student profile cannot be removed"
+			}
+		}
+	}
+
+	if lecturer {
+		if current, err := s.userRepo.FindLecturerByUserID(userID); err == nil {
+			advisees, err := s.userRepo.CountAdvisees(current.ID)
+			if err != nil {
+				return 500, err.Error()
+			}
+			if advisees > 0 {
+				return 409, "Lecturer still has advisees, reassign them before removing the lecturer profile"
+			}
+		}
+	}
+	return 0, ""
+}
+
+// userDetail menyusun data user lengkap (role, permissions, profil) untuk response
+func (s *UserService) userDetail(user *model.User) fiber.Map {
+	permissions := []string{}
+	if perms, err := s.roleRepo.GetPermissionsByRoleID(user.RoleID); err == nil {
+		for _, p := range perms {
+			permissions = append(permissions, p.Key())
+		}
+	}
+
+	data := fiber.Map{
+		"id":               user.ID,
+		"username":         user.Username,
+		"email":            user.Email,
+		"fullName":         user.FullName,
+		"isActive":         user.IsActive,
+		"isServiceAccount": user.IsServiceAccount,
+		"role":             user.Role,
+		"permissions":      permissions,
+		"lockedUntil":      user.LockedUntil,
+		"createdAt":        user.CreatedAt,
+		"updatedAt":        user.UpdatedAt,
+	}
+	addProfileData(s.userRepo, user.ID, data)
+	return data
+}
+
+// addProfileData menambahkan profil Mahasiswa (beserta dosen wali) atau Dosen (beserta jumlah bimbingan) ke data user
+func addProfileData(userRepo *repository.UserRepository, userID string, data fiber.Map) {
+	if student, err := userRepo.FindStudentByUserID(userID); err == nil {
+		studentData := fiber.Map{
+			"id":             student.ID,
+			"studentId":      student.StudentID,
+			"programStudy":   student.ProgramStudy,
+			"programStudyId": student.ProgramStudyID,
+			"academicYear":   student.AcademicYear,
+			"academicYearId": student.AcademicYearID,
+			"advisor":        nil,
+		}
+		if student.Advisor != nil {
+			studentData["advisor"] = fiber.Map{
+				"id":         student.Advisor.ID,
+				"lecturerId": student.Advisor.LecturerID,
+				"fullName":   student.Advisor.User.FullName,
+				"email":      student.Advisor.User.Email,
+			}
+		}
+		data["student"] = studentData
+	} else if lecturer, err := userRepo.FindLecturerByUserID(userID); err == nil {
+		adviseeCount, _ := userRepo.CountAdvisees(lecturer.ID)
+		data["lecturer"] = fiber.Map{
+			"id":           lecturer.ID,
+			"lecturerId":   lecturer.LecturerID,
+			"department":   lecturer.Department,
+			"departmentId": lecturer.DepartmentID,
+			"adviseeCount": adviseeCount,
+		}
+	}
+}
+
+// validateUserFields memvalidasi username, email & nama lengkap. Return pesan error (kosong jika valid).
+func validateUserFields(username, email, fullName string) string {
+	if username == "" || len(username) > 50 {
+		return "Username must be 1-50 characters"
+	}
+	if _, err := mail.ParseAddress(email); err != nil || len(email) > 100 {
+		return "Invalid email address"
+	}
+	if fullName == "" || len(fullName) > 100 {
+		return "Full name must be 1-100 characters"
+	}
+	return ""
+}
+
+// checkStudentIDAvailable memastikan NIM belum dipakai, termasuk oleh user di trash
+// (kolom student_id unique, jadi insert akan gagal walaupun pemiliknya sudah dihapus)
+func checkStudentIDAvailable(repo *repository.UserRepository, nim string) (int, string) {
+	found, trashed, err := repo.FindStudentIDOwner(nim)
+	if err != nil {
+		return 500, err.Error()
+	}
+	if trashed {
+		return 409, "Student ID (NIM) " + nim + " belongs to a deleted user, restore that user instead"
+	}
+	if found {
+		return 409, "Student ID (NIM) is already used"
+	}
+	return 0, ""
+}
+
+// checkLecturerIDAvailable sama seperti checkStudentIDAvailable untuk NIP dosen
+func checkLecturerIDAvailable(repo *repository.UserRepository, nip string) (int, string) {
+	found, trashed, err := repo.FindLecturerIDOwner(nip)
+	if err != nil {
+		return 500, err.Error()
+	}
+	if trashed {
+		return 409, "Lecturer ID (NIP) " + nip + " belongs to a deleted user, restore that user instead"
+	}
+	if found {
+		return 409, "Lecturer ID (NIP) is already used"
+	}
+	return 0, ""
+}
@@ -0,0 +1,226 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"uas/app/service"
+	config "uas/database"
+	"uas/utils"
+
+	"gorm.io/gorm"
+)
+
+// Services adalah dependency yang dibutuhkan subcommand CLI
+type Services struct {
+	DB     *gorm.DB
+	Import *service.ImportService
+}
+
+// IsCommand mengecek apakah argumen pertama adalah subcommand CLI (bukan menjalankan server)
+func IsCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	switch args[0] {
+	case "import", "seed", "help", "-h", "--help":
+		return true
+	}
+	return false
+}
+
+// Run menjalankan subcommand, mis. `go run . import -type student -file roster.xlsx -dry-run`
+func Run(args []string, services Services) error {
+	if len(args) == 0 {
+		return errors.New("missing command")
+	}
+
+	switch args[0] {
+	case "import":
+		return runImport(args[1:], services.Import, os.Stdout)
+	case "seed":
+		return runSeed(args[1:], services.DB)
+	case "help", "-h", "--help":
+		printUsage(os.Stdout)
+		return nil
+	}
+	printUsage(os.Stderr)
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Usage:")
+	fmt.Fprintln(w, "  uas                      Start the HTTP server")
+	fmt.Fprintln(w, "  uas seed [flags]         Create default roles, permissions and the first admin")
+	fmt.Fprintln(w, "  uas import [flags]       Import students/lecturers from a CSV or XLSX roster")
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Run 'uas <command> -h' for the command flags.")
+}
+
+// runImport: import roster dari file lokal, hasil per baris ditulis sebagai JSON
+func runImport(args []string, importService *service.ImportService, out io.Writer) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	importType := fs.String("type", "", "roster type: student | lecturer")
+	file := fs.String("file", "", "path to the .csv or .xlsx roster")
+	dryRun := fs.Bool("dry-run", false, "validate only, roll back all changes")
+	invite := fs.Bool("invite", false, "email an invitation link instead of generating initial passwords")
+	reportPath := fs.String("out", "", "write the JSON report to this file instead of stdout")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *file == "" {
+		return errors.New("-file is required")
+	}
+
+	data, err := os.ReadFile(*file)
+	if err != nil {
+		return err
+	}
+	rows, err := utils.ReadSpreadsheet(*file, data)
+	if err != nil {
+		return err
+	}
+
+	report, err := importService.Import(rows, service.ImportOptions{Type: *importType, DryRun: *dryRun, Invite: *invite})
+	if err != nil {
+		return err
+	}
+
+	encoded, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if *reportPath != "" {
+		// Laporan bisa berisi password awal, jadi hanya bisa dibaca pemilik file
+		if err := os.WriteFile(*reportPath, append(encoded, '\n'), 0600); err != nil {
+			return err
+		}
+	} else {
+		fmt.Fprintln(out, string(encoded))
+	}
+
+	summary := fmt.Sprintf("%d rows: %d created, %d updated, %d unchanged, %d failed",
+		report.Total, report.Created, report.Updated, report.Unchanged, report.Failed)
+	if report.DryRun {
+		summary += " (dry run, nothing saved)"
+	}
+	fmt.Fprintln(os.Stderr, summary)
+
+	if report.Failed > 0 {
+		return fmt.Errorf("%d rows failed", report.Failed)
+	}
+	return nil
+}
+
+// runSeed: seeder RBAC + Admin pertama. Data admin diambil dari SEED_ADMIN_*, atau ditanyakan
+// secara interaktif jika belum ada Admin aktif dan dijalankan dari terminal.
+func runSeed(args []string, db *gorm.DB) error {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	noPrompt := fs.Bool("no-prompt", false, "never ask for the first admin interactively (use SEED_ADMIN_* only)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	admin := config.AdminSeedFromEnv()
+	if admin == nil && !*noPrompt && isTerminal(os.Stdin) {
+		exists, err := config.HasActiveAdmin(db)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			if admin, err = promptAdmin(os.Stdin, os.Stderr); err != nil {
+				return err
+			}
+		}
+	}
+
+	result, err := config.SeedRBAC(db, admin)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Seed completed: %d roles, %d permissions, %d role mappings created\n",
+		result.RolesCreated, result.PermissionsCreated, result.MappingsCreated)
+	switch {
+	case result.AdminCreated:
+		fmt.Fprintf(os.Stderr, "Admin %s created\n", admin.Username)
+	case result.AdminExists:
+		fmt.Fprintln(os.Stderr, "An active admin already exists, no admin created")
+	default:
+		fmt.Fprintln(os.Stderr, "Warning: no active admin exists, set SEED_ADMIN_* or run 'uas seed' from a terminal")
+	}
+	return nil
+}
+
+// promptAdmin menanyakan data Admin pertama (password tidak ditampilkan jika stty tersedia)
+func promptAdmin(in *os.File, out io.Writer) (*config.AdminSeed, error) {
+	reader := bufio.NewReader(in)
+	ask := func(label string) (string, error) {
+		fmt.Fprint(out, label)
+		line, err := reader.ReadString('\n')
+		if err != nil && line == "" {
+			return "", err
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	fmt.Fprintln(out, "No active admin found, create the first admin account.")
+	admin := &config.AdminSeed{}
+	var err error
+	if admin.Username, err = ask("Username: "); err != nil {
+		return nil, err
+	}
+	if admin.Email, err = ask("Email: "); err != nil {
+		return nil, err
+	}
+	if admin.FullName, err = ask("Full name [Administrator]: "); err != nil {
+		return nil, err
+	}
+	admin.Username = strings.TrimSpace(admin.Username)
+	admin.Email = strings.TrimSpace(admin.Email)
+	if admin.FullName = strings.TrimSpace(admin.FullName); admin.FullName == "" {
+		admin.FullName = "Administrator"
+	}
+
+	setEcho(in, false)
+	password, err := ask("Password: ")
+	fmt.Fprintln(out)
+	if err == nil {
+		var confirm string
+		confirm, err = ask("Confirm password: ")
+		fmt.Fprintln(out)
+		if err == nil && confirm != password {
+			err = errors.New("passwords do not match")
+		}
+	}
+	setEcho(in, true)
+	if err != nil {
+		return nil, err
+	}
+	admin.Password = password
+
+	return admin, admin.Validate()
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// setEcho menyalakan / mematikan echo terminal lewat stty (diabaikan jika tidak tersedia, mis. Windows)
+func setEcho(f *os.File, on bool) {
+	mode := "-echo"
+	if on {
+		mode = "echo"
+	}
+	cmd := exec.Command("stty", mode)
+	cmd.Stdin = f
+	_ = cmd.Run()
+}
@@ -0,0 +1,260 @@
+// Mock OpenID Connect identity provider untuk development & pengujian SSO lokal.
+//
+// Jalankan: go run ./cmd/mockidp
+// Lalu set OIDC_ISSUER=http://localhost:9000 dan OIDC_CLIENT_ID sesuai MOCK_IDP_CLIENT_ID di backend.
+//
+// Halaman /authorize menampilkan form untuk mengisi claim (email, nim, nip, name).
+// Set MOCK_IDP_AUTO_APPROVE=true agar langsung redirect dengan claim default dari env.
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"html/template"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const keyID = "mock-idp-key"
+
+// authRequest menyimpan data permintaan login sampai code ditukar di /token
+type authRequest struct {
+	ClientID      string
+	RedirectURI   string
+	Nonce         string
+	CodeChallenge string
+	Claims        map[string]string
+	ExpiresAt     time.Time
+}
+
+type mockIDP struct {
+	issuer   string
+	clientID string
+	key      *rsa.PrivateKey
+
+	mu    sync.Mutex
+	codes map[string]*authRequest
+}
+
+var authorizeForm = template.Must(template.New("authorize").Parse(`<!doctype html>
+<html><body>
+<h2>Mock IdP Login</h2>
+<form method="post" action="/authorize">
+  {{range $k, $v := .Params}}<input type="hidden" name="{{$k}}" value="{{$v}}">{{end}}
+  <p>Name <input name="claim_name" value="{{.Claims.name}}"></p>
+  <p>Email <input name="claim_email" value="{{.Claims.email}}"></p>
+  <p>NIM <input name="claim_nim" value="{{.Claims.nim}}"></p>
+  <p>NIP <input name="claim_nip" value="{{.Claims.nip}}"></p>
+  <button type="submit">Login</button>
+</form>
+</body></html>`))
+
+func main() {
+	port := getenv("MOCK_IDP_PORT", "9000")
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Fatal("Failed to generate signing key: ", err)
+	}
+
+	idp := &mockIDP{
+		issuer:   getenv("MOCK_IDP_ISSUER", "http://localhost:"+port),
+		clientID: getenv("MOCK_IDP_CLIENT_ID", "prestasi-backend"),
+		key:      key,
+		codes:    map[string]*authRequest{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", idp.discovery)
+	mux.HandleFunc("/authorize", idp.authorize)
+	mux.HandleFunc("/token", idp.token)
+	mux.HandleFunc("/jwks", idp.jwks)
+
+	log.Printf("Mock IdP running at %s (client_id=%s)", idp.issuer, idp.clientID)
+	log.Fatal(http.ListenAndServe(":"+port, mux))
+}
+
+func (m *mockIDP) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"issuer":                                m.issuer,
+		"authorization_endpoint":                m.issuer + "/authorize",
+		"token_endpoint":                        m.issuer + "/token",
+		"jwks_uri":                              m.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (m *mockIDP) authorize(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "invalid request", http.StatusBadRequest)
+		return
+	}
+
+	params := map[string]string{}
+	for _, name := range []string{"client_id", "redirect_uri", "state", "nonce", "code_challenge", "code_challenge_method", "response_type"} {
+		params[name] = r.Form.Get(name)
+	}
+
+	if params["client_id"] != m.clientID || params["redirect_uri"] == "" {
+		http.Error(w, "unknown client or missing redirect_uri", http.StatusBadRequest)
+		return
+	}
+	if params["response_type"] != "code" || params["code_challenge"] == "" || params["code_challenge_method"] != "S256" {
+		http.Error(w, "only response_type=code with PKCE S256 is supported", http.StatusBadRequest)
+		return
+	}
+
+	claims := map[string]string{
+		"name":  os.Getenv("MOCK_IDP_NAME"),
+		"email": os.Getenv("MOCK_IDP_EMAIL"),
+		"nim":   os.Getenv("MOCK_IDP_NIM"),
+		"nip":   os.Getenv("MOCK_IDP_NIP"),
+	}
+
+	// GET tanpa auto approve: tampilkan form agar tester bisa memilih identitas
+	if r.Method == http.MethodGet && os.Getenv("MOCK_IDP_AUTO_APPROVE") != "true" {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		authorizeForm.Execute(w, map[string]interface{}{"Params": params, "Claims": claims})
+		return
+	}
+	if r.Method == http.MethodPost {
+		for name := range claims {
+			claims[name] = r.Form.Get("claim_" + name)
+		}
+	}
+
+	code := randomString()
+	m.mu.Lock()
+	m.codes[code] = &authRequest{
+		ClientID:      params["client_id"],
+		RedirectURI:   params["redirect_uri"],
+		Nonce:         params["nonce"],
+		CodeChallenge: params["code_challenge"],
+		Claims:        claims,
+		ExpiresAt:     time.Now().Add(time.Minute),
+	}
+	m.mu.Unlock()
+
+	target, err := url.Parse(params["redirect_uri"])
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	q := target.Query()
+	q.Set("code", code)
+	q.Set("state", params["state"])
+	target.RawQuery = q.Encode()
+	http.Redirect(w, r, target.String(), http.StatusFound)
+}
+
+func (m *mockIDP) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ParseForm() != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	// Code hanya bisa dipakai sekali
+	m.mu.Lock()
+	req, ok := m.codes[r.Form.Get("code")]
+	delete(m.codes, r.Form.Get("code"))
+	m.mu.Unlock()
+
+	if !ok || time.Now().After(req.ExpiresAt) || r.Form.Get("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+	if r.Form.Get("client_id") != req.ClientID || r.Form.Get("redirect_uri") != req.RedirectURI {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	// Verifikasi PKCE: BASE64URL(SHA256(code_verifier)) == code_challenge
+	sum := sha256.Sum256([]byte(r.Form.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != req.CodeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	}
+
+	now := time.Now()
+	claims := jwt.MapClaims{
+		"iss":            m.issuer,
+		"aud":            req.ClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          req.Nonce,
+		"email_verified": true,
+	}
+	subject := ""
+	for name, value := range req.Claims {
+		if value != "" {
+			claims[name] = value
+			subject += name + ":" + value + ";"
+		}
+	}
+	digest := sha256.Sum256([]byte(subject))
+	claims["sub"] = hex.EncodeToString(digest[:16])
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = keyID
+	idToken, err := token.SignedString(m.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     idToken,
+	})
+}
+
+func (m *mockIDP) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := m.key.Public().(*rsa.PublicKey)
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": keyID,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func randomString() string {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func getenv(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
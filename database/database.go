@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"uas/app/model" // Ganti 'project-uas' sesuai nama module di go.mod Anda
+	"uas/app/repository"
 
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
@@ -70,15 +71,226 @@ db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
 		&model.Lecturer{},
 		&model.Student{},
 		&model.AchievementReference{},
+		&model.RefreshToken{},
+		&model.RevokedToken{},
+		&model.UserTokenCutoff{},
+		&model.LoginAttempt{},
+		&model.PasswordResetToken{},
+		&model.UserMFA{},
+		&model.MFARecoveryCode{},
+		&model.APIToken{},
+		&model.Session{},
+		&model.PasswordHistory{},
+		&model.AuditLog{},
+		&model.AdvisorAssignment{},
+		&model.Faculty{},
+		&model.Department{},
+		&model.ProgramStudy{},
+		&model.AcademicYear{},
+		&model.Semester{},
+		&model.MasterAlias{},
+		&model.AchievementStatusHistory{},
 	)
 
 	if err != nil {
 		log.Fatal("❌ Gagal migrasi database:", err)
 	}
 
+	if err := migratePermissionVersionTriggers(db); err != nil {
+		log.Fatal("❌ Gagal membuat trigger versi permission:", err)
+	}
+
+	if err := migrateAdvisorHistory(db); err != nil {
+		log.Fatal("❌ Gagal migrasi riwayat dosen wali:", err)
+	}
+
+	if err := migrateMasterData(db); err != nil {
+		log.Fatal("❌ Gagal memetakan data master:", err)
+	}
+
+	if err := migrateAchievementHistory(db); err != nil {
+		log.Fatal("❌ Gagal migrasi riwayat status prestasi:", err)
+	}
+
+	if err := migrateAchievementStatusCheck(db); err != nil {
+		log.Fatal("❌ Gagal membuat constraint status prestasi:", err)
+	}
+
 	return db
 }
 
+// --- RIWAYAT DOSEN WALI ---
+// Data lama (sebelum ada advisor_assignments): dosen wali saat ini dicatat sebagai periode berjalan
+// sejak mahasiswa dibuat, lalu prestasi yang sudah diverifikasi/ditolak diisi snapshot dosen wali-nya
+// dari riwayat tersebut. Idempotent, hanya mengisi data yang masih kosong.
+func migrateAdvisorHistory(db *gorm.DB) error {
+	statements := []string{
+		`INSERT INTO advisor_assignments (student_id, lecturer_id, effective_from, reason)
+		SELECT s.id, s.advisor_id, s.created_at, 'Initial assignment (migrated)'
+		FROM students s
+		WHERE s.advisor_id IS NOT NULL
+			AND NOT EXISTS (SELECT 1 FROM advisor_assignments a WHERE a.student_id = s.id)`,
+
+		`UPDATE achievement_references ar
+		SET advisor_id = a.lecturer_id
+		FROM advisor_assignments a
+		WHERE ar.advisor_id IS NULL
+			AND ar.verified_at IS NOT NULL
+			AND ar.status IN ('verified', 'rejected')
+			AND a.student_id = ar.student_id
+			AND a.effective_from <= ar.verified_at
+			AND (a.effective_to IS NULL OR a.effective_to > ar.verified_at)`,
+	}
+
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// --- RIWAYAT STATUS PRESTASI ---
+// Prestasi lama (sebelum ada achievement_status_history) dibuatkan riwayat dari kolom yang tersisa:
+// dibuat (created_at), diajukan (submitted_at), lalu diverifikasi / ditolak (verified_at & verified_by).
+// Hanya untuk prestasi yang belum punya riwayat sama sekali, sehingga idempotent.
+func migrateAchievementHistory(db *gorm.DB) error {
+	statements := []string{
+		`INSERT INTO achievement_status_history (achievement_id, from_status, to_status, created_at)
+		SELECT ar.id, '', 'draft', ar.created_at
+		FROM achievement_references ar
+		WHERE NOT EXISTS (SELECT 1 FROM achievement_status_history h WHERE h.achievement_id = ar.id)`,
+
+		`INSERT INTO achievement_status_history (achievement_id, from_status, to_status, created_at)
+		SELECT ar.id, 'draft', 'submitted', COALESCE(ar.submitted_at, ar.verified_at, ar.updated_at)
+		FROM achievement_references ar
+		WHERE ar.status IN ('submitted', 'verified', 'rejected')
+			AND NOT EXISTS (SELECT 1 FROM achievement_status_history h WHERE h.achievement_id = ar.id AND h.from_status <> '')`,
+
+		`INSERT INTO achievement_status_history (achievement_id, from_status, to_status, actor_id, note, points, created_at)
+		SELECT ar.id, 'submitted', ar.status, ar.verified_by, ar.rejection_note,
+			CASE WHEN ar.status = 'verified' THEN ar.points END,
+			COALESCE(ar.verified_at, ar.updated_at)
+		FROM achievement_references ar
+		WHERE ar.status IN ('verified', 'rejected')
+			AND NOT EXISTS (SELECT 1 FROM achievement_status_history h WHERE h.achievement_id = ar.id AND h.from_status = 'submitted')`,
+	}
+
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// --- CONSTRAINT STATUS PRESTASI ---
+// Status hanya boleh berisi nilai lifecycle (lihat achievement_lifecycle.go), termasuk untuk update
+// yang tidak lewat aplikasi. Constraint dibuat NOT VALID agar baris lama yang tidak valid tidak
+// menggagalkan startup, lalu divalidasi; jika masih ada data lama yang tidak valid cukup dilaporkan di log.
+func migrateAchievementStatusCheck(db *gorm.DB) error {
+	const statuses = `'draft', 'submitted', 'verified', 'rejected', 'revoked'`
+	constraints := []struct{ table, name, check string }{
+		{"achievement_references", "chk_achievement_references_status", "status IN (" + statuses + ")"},
+		{"achievement_status_history", "chk_achievement_status_history_to_status", "to_status IN (" + statuses + ")"},
+		{"achievement_status_history", "chk_achievement_status_history_from_status", "from_status IN (''," + statuses + ")"},
+	}
+
+	for _, c := range constraints {
+		stmt := fmt.Sprintf(`DO $$
+		BEGIN
+			IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = '%s') THEN
+				ALTER TABLE %s ADD CONSTRAINT %s CHECK (%s) NOT VALID;
+			END IF;
+		END;
+		$$`, c.name, c.table, c.name, c.check)
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+
+		if err := db.Exec(fmt.Sprintf("ALTER TABLE %s VALIDATE CONSTRAINT %s", c.table, c.name)).Error; err != nil {
+			log.Printf("⚠️  Constraint %s belum valid untuk data lama: %v", c.name, err)
+		}
+	}
+	return nil
+}
+
+// --- MASTER DATA AKADEMIK ---
+// Teks bebas lama (program_study, academic_year, department) dipetakan ke data master lewat kode,
+// nama resmi, atau alias (master_aliases). Nilai yang belum terpetakan dilaporkan di log dan bisa
+// dipetakan belakangan oleh Admin lewat /master/aliases. Idempotent, dijalankan setiap start.
+func migrateMasterData(db *gorm.DB) error {
+	result, err := repository.NewMasterRepository(db).MapLegacyValues()
+	if err != nil {
+		return err
+	}
+
+	if mapped := result.ProgramStudies + result.AcademicYears + result.Departments; mapped > 0 {
+		log.Printf("✅ Data master: %d prodi, %d tahun akademik, %d jurusan terpetakan",
+			result.ProgramStudies, result.AcademicYears, result.Departments)
+	}
+	if len(result.Unmapped) > 0 {
+		log.Printf("⚠️  %d nilai teks lama belum terpetakan ke data master, cek GET /api/v1/master/unmapped", len(result.Unmapped))
+	}
+	return nil
+}
+
+// --- TRIGGER VERSI PERMISSION ---
+// Menaikkan roles.permission_version / users.permission_version setiap kali role_permissions,
+// permissions, atau role user berubah (termasuk edit manual lewat SQL), sehingga token lama
+// terdeteksi basi oleh AuthMiddleware.
+func migratePermissionVersionTriggers(db *gorm.DB) error {
+	statements := []string{
+		`CREATE OR REPLACE FUNCTION bump_role_permission_version() RETURNS trigger AS $$
+		BEGIN
+			IF TG_OP IN ('INSERT', 'UPDATE') THEN
+				UPDATE roles SET permission_version = permission_version + 1 WHERE id = NEW.role_id;
+			END IF;
+			IF TG_OP IN ('UPDATE', 'DELETE') THEN
+				UPDATE roles SET permission_version = permission_version + 1 WHERE id = OLD.role_id;
+			END IF;
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS trg_role_permissions_version ON role_permissions`,
+		`CREATE TRIGGER trg_role_permissions_version
+			AFTER INSERT OR UPDATE OR DELETE ON role_permissions
+			FOR EACH ROW EXECUTE FUNCTION bump_role_permission_version()`,
+
+		`CREATE OR REPLACE FUNCTION bump_permission_roles_version() RETURNS trigger AS $$
+		BEGIN
+			UPDATE roles SET permission_version = permission_version + 1
+			WHERE id IN (SELECT role_id FROM role_permissions WHERE permission_id = NEW.id);
+			RETURN NULL;
+		END;
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS trg_permissions_version ON permissions`,
+		`CREATE TRIGGER trg_permissions_version
+			AFTER UPDATE ON permissions
+			FOR EACH ROW EXECUTE FUNCTION bump_permission_roles_version()`,
+
+		`CREATE OR REPLACE FUNCTION bump_user_permission_version() RETURNS trigger AS $$
+		BEGIN
+			IF NEW.role_id IS DISTINCT FROM OLD.role_id THEN
+				NEW.permission_version := OLD.permission_version + 1;
+			END IF;
+			RETURN NEW;
+		END;
+		$$ LANGUAGE plpgsql`,
+		`DROP TRIGGER IF EXISTS trg_users_permission_version ON users`,
+		`CREATE TRIGGER trg_users_permission_version
+			BEFORE UPDATE OF role_id ON users
+			FOR EACH ROW EXECUTE FUNCTION bump_user_permission_version()`,
+	}
+
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // --- KONEKSI MONGODB (Dynamic Data) ---
 func connectMongo() *mongo.Database {
 	uri := os.Getenv("MONGO_URI")
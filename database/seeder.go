@@ -0,0 +1,243 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"os"
+	"strings"
+
+	"uas/app/model"
+	"uas/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// --- SEEDER RBAC & ADMIN PERTAMA ---
+// Idempotent: aman dijalankan berulang kali (lewat `go run . seed` atau SEED_ON_STARTUP=true).
+// Seeder hanya menambahkan data yang belum ada, perubahan yang dibuat Admin lewat API tidak ditimpa.
+
+type seedPermission struct {
+	Resource    string
+	Action      string
+	Description string
+}
+
+// Semua permission yang dipakai route.SetupRoutes (PermissionRequired) & service.
+// Tambahkan di sini setiap kali ada permission baru di route.
+var seedPermissions = []seedPermission{
+	{"user", "manage", "Kelola user, role & permission"},
+	{"user", "impersonate", "Masuk sebagai user lain untuk keperluan helpdesk (diaudit)"},
+	{"achievement", "create", "Membuat & mengajukan prestasi"},
+	{"achievement", "update", "Mengubah prestasi milik sendiri"},
+	{"achievement", "delete", "Menghapus prestasi milik sendiri"},
+	{"achievement", "verify", "Memverifikasi / menolak prestasi mahasiswa bimbingan"},
+}
+
+type seedRole struct {
+	Name        string
+	Description string
+	Permissions []string // resource:action
+}
+
+// Role bawaan sistem (SRS) beserta permission default-nya
+var seedRoles = []seedRole{
+	{
+		Name:        model.RoleAdmin,
+		Description: "Administrator sistem, akses penuh",
+		Permissions: []string{"user:manage", "user:impersonate", "achievement:create", "achievement:update", "achievement:delete", "achievement:verify"},
+	},
+	{
+		Name:        model.RoleMahasiswa,
+		Description: "Mahasiswa, mengelola & mengajukan prestasi sendiri",
+		Permissions: []string{"achievement:create", "achievement:update", "achievement:delete"},
+	},
+	{
+		Name:        model.RoleDosenWali,
+		Description: "Dosen wali, memverifikasi prestasi mahasiswa bimbingan",
+		Permissions: []string{"achievement:verify"},
+	},
+}
+
+// AdminSeed adalah data akun Admin pertama
+type AdminSeed struct {
+	Username string
+	Email    string
+	FullName string
+	Password string
+}
+
+// SeedResult adalah ringkasan hasil seeding
+type SeedResult struct {
+	RolesCreated       int
+	PermissionsCreated int
+	MappingsCreated    int
+	AdminCreated       bool
+	AdminExists        bool // Sudah ada Admin aktif sebelum seeding
+}
+
+// AdminSeedFromEnv membaca SEED_ADMIN_USERNAME, SEED_ADMIN_EMAIL, SEED_ADMIN_PASSWORD & SEED_ADMIN_NAME.
+// Return nil jika username / email / password tidak diisi.
+func AdminSeedFromEnv() *AdminSeed {
+	admin := &AdminSeed{
+		Username: strings.TrimSpace(os.Getenv("SEED_ADMIN_USERNAME")),
+		Email:    strings.TrimSpace(os.Getenv("SEED_ADMIN_EMAIL")),
+		FullName: strings.TrimSpace(os.Getenv("SEED_ADMIN_NAME")),
+		Password: os.Getenv("SEED_ADMIN_PASSWORD"),
+	}
+	if admin.Username == "" || admin.Email == "" || admin.Password == "" {
+		return nil
+	}
+	if admin.FullName == "" {
+		admin.FullName = "Administrator"
+	}
+	return admin
+}
+
+// Validate mengecek format data admin & password policy
+func (a *AdminSeed) Validate() error {
+	if a.Username == "" || len(a.Username) > 50 {
+		return errors.New("admin username must be 1-50 characters")
+	}
+	if _, err := mail.ParseAddress(a.Email); err != nil || len(a.Email) > 100 {
+		return errors.New("invalid admin email address")
+	}
+	if a.FullName == "" || len(a.FullName) > 100 {
+		return errors.New("admin full name must be 1-100 characters")
+	}
+	return utils.ValidatePassword(a.Password, nil)
+}
+
+// HasActiveAdmin mengecek apakah sudah ada user aktif dengan role Admin
+func HasActiveAdmin(db *gorm.DB) (bool, error) {
+	var count int64
+	err := db.Model(&model.User{}).
+		Joins("JOIN roles ON roles.id = users.role_id").
+		Where("roles.name = ? AND users.is_active = ?", model.RoleAdmin, true).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// SeedRBAC membuat role, permission & mapping bawaan yang belum ada, lalu Admin pertama
+// (hanya jika admin != nil dan belum ada Admin aktif). Semua dalam satu transaksi.
+func SeedRBAC(db *gorm.DB, admin *AdminSeed) (*SeedResult, error) {
+	result := &SeedResult{}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// 1. Permissions (unik berdasarkan resource & action)
+		permissionIDs := map[string]string{}
+		for _, p := range seedPermissions {
+			key := utils.PermissionKey(p.Resource, p.Action)
+
+			var permission model.Permission
+			err := tx.Where("(resource = ? AND action = ?) OR name = ?", p.Resource, p.Action, key).First(&permission).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				permission = model.Permission{Name: key, Resource: p.Resource, Action: p.Action, Description: p.Description}
+				if err := tx.Create(&permission).Error; err != nil {
+					return fmt.Errorf("create permission %s: %w", key, err)
+				}
+				result.PermissionsCreated++
+			} else if err != nil {
+				return err
+			}
+			permissionIDs[key] = permission.ID
+		}
+
+		// 2. Roles & mapping role_permissions
+		for _, r := range seedRoles {
+			var role model.Role
+			err := tx.Where("name = ?", r.Name).First(&role).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				role = model.Role{Name: r.Name, Description: r.Description}
+				if err := tx.Create(&role).Error; err != nil {
+					return fmt.Errorf("create role %s: %w", r.Name, err)
+				}
+				result.RolesCreated++
+			} else if err != nil {
+				return err
+			}
+
+			for _, key := range r.Permissions {
+				insert := tx.Omit("Role", "Permission").Clauses(clause.OnConflict{DoNothing: true}).
+					Create(&model.RolePermission{RoleID: role.ID, PermissionID: permissionIDs[key]})
+				if insert.Error != nil {
+					return fmt.Errorf("attach %s to %s: %w", key, r.Name, insert.Error)
+				}
+				result.MappingsCreated += int(insert.RowsAffected)
+			}
+		}
+
+		// 3. Admin pertama
+		exists, err := HasActiveAdmin(tx)
+		if err != nil {
+			return err
+		}
+		result.AdminExists = exists
+		if exists || admin == nil {
+			return nil
+		}
+
+		if err := admin.Validate(); err != nil {
+			return err
+		}
+
+		var taken int64
+		if err := tx.Unscoped().Model(&model.User{}).
+			Where("username = ? OR email = ?", admin.Username, admin.Email).
+			Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return fmt.Errorf("username %s or email %s is already used by another user", admin.Username, admin.Email)
+		}
+
+		var adminRole model.Role
+		if err := tx.Where("name = ?", model.RoleAdmin).First(&adminRole).Error; err != nil {
+			return err
+		}
+
+		hash, err := utils.HashPassword(admin.Password)
+		if err != nil {
+			return err
+		}
+		if err := tx.Omit("Role").Create(&model.User{
+			Username:     admin.Username,
+			Email:        admin.Email,
+			FullName:     admin.FullName,
+			PasswordHash: hash,
+			RoleID:       adminRole.ID,
+			IsActive:     true,
+		}).Error; err != nil {
+			return fmt.Errorf("create admin: %w", err)
+		}
+		result.AdminCreated = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SeedOnStartup menjalankan seeder saat server start jika SEED_ON_STARTUP=true.
+// Admin pertama hanya dibuat dari env (tidak ada prompt interaktif di server).
+func SeedOnStartup(db *gorm.DB) {
+	if os.Getenv("SEED_ON_STARTUP") != "true" {
+		return
+	}
+
+	log.Println("🌱 Menjalankan seeder RBAC...")
+	result, err := SeedRBAC(db, AdminSeedFromEnv())
+	if err != nil {
+		log.Fatal("❌ Seeder gagal: ", err)
+	}
+
+	log.Printf("✅ Seeder selesai: %d role, %d permission, %d mapping baru", result.RolesCreated, result.PermissionsCreated, result.MappingsCreated)
+	if result.AdminCreated {
+		log.Println("✅ Admin pertama dibuat dari SEED_ADMIN_*")
+	} else if !result.AdminExists {
+		log.Println("⚠️  Belum ada Admin aktif, isi SEED_ADMIN_* atau jalankan `go run . seed`")
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"log"
 	"os"
 
+	"uas/cli"
 	"uas/middleware"
 	"uas/app/repository"
 	"uas/route"
 	"uas/app/service"
 	"uas/database"
+	"uas/utils"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -22,6 +24,14 @@ func main() {
 		log.Println("⚠️  Warning: .env file not found, using system environment variables")
 	}
 
+	// Algoritma hash password (bcrypt/argon2id) & password policy
+	if err := utils.InitPasswordHasher(); err != nil {
+		log.Fatal("❌ Konfigurasi password hasher tidak valid: ", err)
+	}
+	if err := utils.InitPasswordPolicy(); err != nil {
+		log.Fatal("❌ Konfigurasi password policy tidak valid: ", err)
+	}
+
 	// 2. Initialize Database (Hybrid: Postgres & Mongo)
 	// Config ini otomatis melakukan AutoMigrate untuk Postgres
 	db := config.InitDB()
@@ -37,18 +47,97 @@ func main() {
 	// AchRepo: Butuh DUA koneksi (Postgres untuk relasi, Mongo untuk data dinamis)
 	achRepo := repository.NewAchievementRepository(db.Postgres, db.Mongo)
 
+	// RefreshTokenRepo: Menyimpan hash refresh token (rotasi & deteksi reuse)
+	refreshRepo := repository.NewRefreshTokenRepository(db.Postgres)
+
+	// RevocationRepo: Daftar access token yang sudah di-logout (Postgres + cache in-memory)
+	revocationRepo := repository.NewRevocationRepository(db.Postgres)
+
+	// LoginAttemptRepo: Catatan percobaan login (proteksi brute-force per IP)
+	attemptRepo := repository.NewLoginAttemptRepository(db.Postgres)
+
+	// PasswordResetRepo: Token reset password (hash, sekali pakai)
+	resetRepo := repository.NewPasswordResetRepository(db.Postgres)
+
+	// MFARepo: Secret TOTP & recovery code (2FA)
+	mfaRepo := repository.NewMFARepository(db.Postgres)
+
+	// APITokenRepo: Personal access token untuk integrasi & service account
+	apiTokenRepo := repository.NewAPITokenRepository(db.Postgres)
+
+	// SessionRepo: Sesi login per perangkat (bisa dilihat & dicabut dari /auth/sessions)
+	sessionRepo := repository.NewSessionRepository(db.Postgres)
+
+	// AuditRepo: Jejak audit aksi sensitif (impersonation, dll)
+	auditRepo := repository.NewAuditRepository(db.Postgres)
+
+	// MasterRepo: Data master akademik (fakultas, jurusan, prodi, tahun akademik) & alias teks lama
+	masterRepo := repository.NewMasterRepository(db.Postgres)
+
 	// 4. Setup Services (Business Logic Layer)
 	// ---------------------------------------------------------
 	// AuthService: Butuh UserRepo & RoleRepo (untuk inject permissions ke token saat login)
-	authService := service.NewAuthService(userRepo, roleRepo)
+	// dan RefreshTokenRepo + RevocationRepo untuk rotasi refresh token & logout,
+	// serta LoginAttemptRepo untuk proteksi brute-force
+	// Mailer: SMTP / outbox file (MAIL_DRIVER) untuk email reset password
+	mailer := utils.NewMailerFromEnv()
+	authService := service.NewAuthService(userRepo, roleRepo, refreshRepo, revocationRepo, attemptRepo, resetRepo, mfaRepo, sessionRepo, mailer)
 	
+	// UserService: Manajemen user oleh Admin (user + profil Mahasiswa/Dosen dalam satu transaksi)
+	userService := service.NewUserService(userRepo, roleRepo, masterRepo, authService)
+
+	// RoleService: Administrasi role & permission (RBAC), setiap perubahan diaudit
+	roleService := service.NewRoleService(roleRepo, userRepo)
+
+	// APITokenService: Kelola personal access token & service account
+	apiTokenService := service.NewAPITokenService(apiTokenRepo, userRepo, roleRepo)
+
+	// OIDCService: SSO dengan identity provider kampus (nonaktif jika OIDC_ISSUER kosong)
+	var oidcProvider *utils.OIDCProvider
+	if oidcConfig := utils.LoadOIDCConfigFromEnv(); oidcConfig != nil {
+		oidcProvider = utils.NewOIDCProvider(oidcConfig)
+	}
+	oidcService := service.NewOIDCService(oidcProvider, userRepo, roleRepo, authService)
+
+	// ImpersonationService: Helpdesk login sebagai user lain (dengan audit)
+	impersonationService := service.NewImpersonationService(userRepo, roleRepo, auditRepo)
+
+	// ImportService: Import roster Mahasiswa/Dosen dari CSV/XLSX (HTTP & CLI)
+	importService := service.NewImportService(userRepo, roleRepo, masterRepo, resetRepo, mailer)
+
+	// Subcommand CLI (mis. `go run . import -type student -file roster.xlsx`), tidak menjalankan server
+	if cli.IsCommand(os.Args[1:]) {
+		if err := cli.Run(os.Args[1:], cli.Services{DB: db.Postgres, Import: importService}); err != nil {
+			log.Fatal("❌ ", err)
+		}
+		return
+	}
+
+	// Seeder role, permission & Admin pertama (opsional, SEED_ON_STARTUP=true)
+	config.SeedOnStartup(db.Postgres)
+
+	// Load key untuk sign JWT (RS256/EdDSA), server tidak boleh jalan tanpa key
+	if err := utils.InitKeyRing(); err != nil {
+		log.Fatal("❌ Gagal memuat JWT key: ", err)
+	}
+
+	// StudentService: Dosen wali mahasiswa (beserta riwayat & pemindahan massal)
+	studentService := service.NewStudentService(userRepo)
+
+	// MasterService: CRUD data master akademik & pemetaan teks lama
+	masterService := service.NewMasterService(masterRepo)
+
 	// AchService: Butuh AchRepo & UserRepo (untuk validasi profil mahasiswa/dosen)
 	achService := service.NewAchievementService(achRepo, userRepo)
 
 	// 5. Setup Middleware
 	// ---------------------------------------------------------
 	// AuthMiddleware: Butuh RoleRepo (jika ingin validasi permission level DB strict)
-	authMiddleware := middleware.NewAuthMiddleware(roleRepo)
+	// dan RevocationRepo untuk menolak token yang sudah di-logout,
+	// serta APITokenRepo agar personal access token diterima selain JWT,
+	// SessionRepo untuk menolak token dari sesi yang sudah dicabut,
+	// dan AuditRepo untuk mencatat setiap request selama impersonation
+	authMiddleware := middleware.NewAuthMiddleware(roleRepo, revocationRepo, apiTokenRepo, sessionRepo, auditRepo)
 
 	// 6. Initialize Fiber App
 	// ---------------------------------------------------------
@@ -74,7 +163,7 @@ func main() {
 	// 7. Setup Routes (Wiring Semua Komponen)
 	// ---------------------------------------------------------
 	// Kita kirimkan app, services, dan middleware ke file route
-	route.SetupRoutes(app, authService, achService, authMiddleware)
+	route.SetupRoutes(app, authService, userService, roleService, apiTokenService, oidcService, impersonationService, importService, studentService, masterService, achService, authMiddleware)
 
 	// 8. Start Server
 	// ---------------------------------------------------------
@@ -1,21 +1,40 @@
 package middleware
 
 import (
+	"log"
 	"uas/app/model"
 	"uas/app/repository"
 	"uas/utils"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 )
 
 type AuthMiddleware struct {
-	roleRepo *repository.RoleRepository
+	roleRepo       *repository.RoleRepository
+	revocationRepo *repository.RevocationRepository
+	apiTokenRepo   *repository.APITokenRepository
+	sessionRepo    *repository.SessionRepository
+	auditRepo      *repository.AuditRepository
 }
 
-// Constructor menerima RoleRepository (Sesuai wiring di main.go)
-func NewAuthMiddleware(roleRepo *repository.RoleRepository) *AuthMiddleware {
-	return &AuthMiddleware{roleRepo: roleRepo}
+// Constructor menerima RoleRepository, RevocationRepository, APITokenRepository, SessionRepository
+// & AuditRepository (Sesuai wiring di main.go)
+func NewAuthMiddleware(
+	roleRepo *repository.RoleRepository,
+	revocationRepo *repository.RevocationRepository,
+	apiTokenRepo *repository.APITokenRepository,
+	sessionRepo *repository.SessionRepository,
+	auditRepo *repository.AuditRepository,
+) *AuthMiddleware {
+	return &AuthMiddleware{
+		roleRepo:       roleRepo,
+		revocationRepo: revocationRepo,
+		apiTokenRepo:   apiTokenRepo,
+		sessionRepo:    sessionRepo,
+		auditRepo:      auditRepo,
+	}
 }
 
 // ==============================================================
@@ -44,6 +63,11 @@ func (m *AuthMiddleware) AuthRequired() fiber.Handler {
 			})
 		}
 
+		// Personal access token (integrasi) dibedakan dari JWT lewat prefix-nya
+		if strings.HasPrefix(tokenParts[1], utils.APITokenPrefix) {
+			return m.authenticateAPIToken(c, tokenParts[1])
+		}
+
 		// 2. Validasi token
 		claims, err := utils.ParseToken(tokenParts[1])
 		if err != nil {
@@ -54,21 +78,238 @@ func (m *AuthMiddleware) AuthRequired() fiber.Handler {
 			})
 		}
 
+		// Token tanpa exp/iat tidak bisa dicabut dengan benar, anggap tidak valid
+		if claims.ExpiresAt == nil || claims.IssuedAt == nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(model.WebResponse{
+				Code:    401,
+				Status:  "error",
+				Message: "Invalid or expired token",
+			})
+		}
+
+		// Tolak token yang sudah di-logout (jti dicabut / logout everywhere)
+		if m.revocationRepo.IsRevoked(claims.ID, claims.UserID, claims.IssuedAt.Time) {
+			return c.Status(fiber.StatusUnauthorized).JSON(model.WebResponse{
+				Code:    401,
+				Status:  "error",
+				Message: "Token has been revoked",
+			})
+		}
+
+		// Tolak token dari sesi yang sudah dicabut (logout remote dari /auth/sessions)
+		if claims.SessionID != "" && !m.sessionRepo.IsActive(claims.SessionID, c.IP()) {
+			return c.Status(fiber.StatusUnauthorized).JSON(model.WebResponse{
+				Code:    401,
+				Status:  "error",
+				Message: "Session has been revoked",
+			})
+		}
+
+		// Bandingkan stamp versi permission di token dengan versi terkini (cache RoleRepository)
+		authz, err := m.roleRepo.GetAuthzState(claims.UserID)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(model.WebResponse{
+				Code:    401,
+				Status:  "error",
+				Message: "User no longer exists",
+			})
+		}
+
+		// Akun yang dinonaktifkan Admin langsung ditolak, tanpa menunggu token kedaluwarsa
+		if !authz.IsActive {
+			return c.Status(fiber.StatusUnauthorized).JSON(model.WebResponse{
+				Code:    401,
+				Status:  "error",
+				Message: "Account is deactivated",
+			})
+		}
+
+		// Token impersonation punya aturan sendiri (tidak di-rehydrate, actor dicek ulang, semua request diaudit)
+		if claims.Actor != nil {
+			return m.authenticateImpersonation(c, claims, authz)
+		}
+
+		role, permissions := claims.Role, claims.Permissions
+		if authz.UserVersion != claims.Stamp.UserVersion || authz.RoleVersion != claims.Stamp.RoleVersion {
+			// Role/permission sudah berubah sejak token dibuat: pakai data terkini (re-hydrate),
+			// dan beri tahu client agar segera refresh token
+			role, permissions = authz.RoleName, authz.Permissions
+			c.Set("X-Token-Stale", "true")
+		}
+
 		// 3. Simpan data User ke Context (Locals)
 		// Agar bisa diakses di Controller/Service (c.Locals("user_id"))
 		c.Locals("user_id", claims.UserID)
-		c.Locals("role", claims.Role)
-		c.Locals("permissions", claims.Permissions) // Permissions dimuat dari Token (Cache Strategy)
+		c.Locals("role", role)
+		c.Locals("permissions", permissions) // Permissions dari Token, atau versi terkini jika token basi
+		c.Locals("jti", claims.ID)                  // Untuk logout (revocation)
+		c.Locals("token_exp", claims.ExpiresAt.Time)
+		c.Locals("session_id", claims.SessionID)
+		c.Locals("auth_type", "jwt")
 
 		return c.Next()
 	}
 }
 
+// authenticateImpersonation memvalidasi token impersonation: actor harus masih punya permission
+// user:impersonate dan token-nya tidak dicabut. Permission di token tidak pernah di-rehydrate
+// (sudah dibatasi saat token dibuat), jika role target berubah token langsung ditolak.
+// Setiap request dicatat ke audit log beserta status code-nya.
+func (m *AuthMiddleware) authenticateImpersonation(c *fiber.Ctx, claims *utils.JwtClaims, authz *repository.AuthzState) error {
+	actorID := claims.Actor.Subject
+
+	actor, err := m.roleRepo.GetAuthzState(actorID)
+	if err != nil || !actor.IsActive || !utils.HasPermission(actor.Permissions, "user:impersonate") ||
+		m.revocationRepo.IsRevoked("", actorID, claims.IssuedAt.Time) {
+		return c.Status(fiber.StatusUnauthorized).JSON(model.WebResponse{
+			Code:    401,
+			Status:  "error",
+			Message: "Impersonation is no longer allowed",
+		})
+	}
+
+	if authz.UserVersion != claims.Stamp.UserVersion || authz.RoleVersion != claims.Stamp.RoleVersion {
+		return c.Status(fiber.StatusUnauthorized).JSON(model.WebResponse{
+			Code:    401,
+			Status:  "error",
+			Message: "Impersonated user's permissions have changed, please start impersonation again",
+		})
+	}
+
+	c.Locals("user_id", claims.UserID)
+	c.Locals("role", claims.Role)
+	c.Locals("permissions", claims.Permissions)
+	c.Locals("jti", claims.ID)
+	c.Locals("token_exp", claims.ExpiresAt.Time)
+	c.Locals("session_id", claims.SessionID)
+	c.Locals("auth_type", "impersonation")
+	c.Locals("impersonator_id", actorID)
+
+	// Audit ditulis sebelum handler dijalankan: request impersonation tanpa jejak audit tidak boleh diproses
+	userID := claims.UserID
+	entry := &model.AuditLog{
+		ActorID:   actorID,
+		OnBehalf:  &userID,
+		Action:    "impersonation.request",
+		Method:    c.Method(),
+		Path:      model.AuditPath(c.OriginalURL()),
+		IPAddress: c.IP(),
+		Metadata:  map[string]interface{}{"jti": claims.ID},
+	}
+	if err := m.auditRepo.Create(entry); err != nil {
+		log.Println("⚠️  Gagal menulis audit log impersonation:", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(model.WebResponse{
+			Code:    500,
+			Status:  "error",
+			Message: "Failed to write audit log",
+		})
+	}
+
+	handlerErr := c.Next()
+
+	// Status code final (error dari handler diterjemahkan oleh ErrorHandler fiber)
+	status := c.Response().StatusCode()
+	if e, ok := handlerErr.(*fiber.Error); ok {
+		status = e.Code
+	} else if handlerErr != nil {
+		status = fiber.StatusInternalServerError
+	}
+	if err := m.auditRepo.SetStatusCode(entry.ID, status); err != nil {
+		log.Println("⚠️  Gagal mencatat status audit log impersonation:", err)
+	}
+
+	return handlerErr
+}
+
+// InteractiveOnly menolak request yang tidak berasal dari sesi login user itu sendiri
+// (personal access token & token impersonation), untuk endpoint sensitif seperti MFA & password.
+// Dipasang setelah AuthRequired.
+func (m *AuthMiddleware) InteractiveOnly() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if authType, _ := c.Locals("auth_type").(string); authType != "jwt" {
+			return c.Status(fiber.StatusForbidden).JSON(model.WebResponse{
+				Code:    403,
+				Status:  "error",
+				Message: "This action requires an interactive login session",
+			})
+		}
+		return c.Next()
+	}
+}
+
+// authenticateAPIToken memvalidasi personal access token. Permission efektif adalah scope token
+// yang masih tercakup permission pemiliknya saat ini (jika pemilik diturunkan role-nya, scope ikut menyempit).
+func (m *AuthMiddleware) authenticateAPIToken(c *fiber.Ctx, raw string) error {
+	token, err := m.apiTokenRepo.FindByHash(utils.HashToken(raw))
+	if err != nil || token.RevokedAt != nil || time.Now().After(token.ExpiresAt) || !token.User.IsActive {
+		return c.Status(fiber.StatusUnauthorized).JSON(model.WebResponse{
+			Code:    401,
+			Status:  "error",
+			Message: "Invalid, expired or revoked API token",
+		})
+	}
+
+	authz, err := m.roleRepo.GetAuthzState(token.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(model.WebResponse{
+			Code:    401,
+			Status:  "error",
+			Message: "User no longer exists",
+		})
+	}
+	if !authz.IsActive {
+		return c.Status(fiber.StatusUnauthorized).JSON(model.WebResponse{
+			Code:    401,
+			Status:  "error",
+			Message: "Account is deactivated",
+		})
+	}
+
+	permissions := make([]string, 0, len(token.Scopes))
+	for _, scope := range token.Scopes {
+		if utils.HasPermission(authz.Permissions, scope) {
+			permissions = append(permissions, scope)
+		}
+	}
+
+	// Gagal mencatat last used tidak boleh menggagalkan request
+	_ = m.apiTokenRepo.TouchLastUsed(token.ID, c.IP())
+
+	c.Locals("user_id", token.UserID)
+	c.Locals("role", authz.RoleName)
+	c.Locals("permissions", permissions)
+	c.Locals("auth_type", "api_token")
+	c.Locals("api_token_id", token.ID)
+
+	return c.Next()
+}
+
 // ==============================================================
 // Middleware 2: PermissionRequired (FR-002 Step 4 & 5)
 // Memastikan User memiliki Permission spesifik (RBAC)
+// Mendukung wildcard ("achievement:*", "*:read") dan aksi turunan ("achievement:manage")
 // ==============================================================
 func (m *AuthMiddleware) PermissionRequired(requiredPerm string) fiber.Handler {
+	return m.permissionCheck("Access denied. Missing permission: "+requiredPerm, func(userPerms []string) bool {
+		return utils.HasPermission(userPerms, requiredPerm)
+	})
+}
+
+// AnyPermissionRequired: lolos jika user memiliki minimal satu permission
+func (m *AuthMiddleware) AnyPermissionRequired(requiredPerms ...string) fiber.Handler {
+	return m.permissionCheck("Access denied. Requires one of: "+strings.Join(requiredPerms, ", "), func(userPerms []string) bool {
+		return utils.HasAnyPermission(userPerms, requiredPerms...)
+	})
+}
+
+// AllPermissionsRequired: lolos jika user memiliki semua permission
+func (m *AuthMiddleware) AllPermissionsRequired(requiredPerms ...string) fiber.Handler {
+	return m.permissionCheck("Access denied. Requires all of: "+strings.Join(requiredPerms, ", "), func(userPerms []string) bool {
+		return utils.HasAllPermissions(userPerms, requiredPerms...)
+	})
+}
+
+func (m *AuthMiddleware) permissionCheck(deniedMessage string, allowed func(userPerms []string) bool) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Ambil permissions yang sudah disimpan di Locals oleh AuthRequired
 		userPermsInterface := c.Locals("permissions")
@@ -92,21 +333,12 @@ func (m *AuthMiddleware) PermissionRequired(requiredPerm string) fiber.Handler {
 			})
 		}
 
-		// 4. Check apakah user memiliki permission yang diperlukan
-		hasPermission := false
-		for _, p := range userPerms {
-			if p == requiredPerm {
-				hasPermission = true
-				break
-			}
-		}
-
-		// 5. Allow/deny request
-		if !hasPermission {
+		// 4 & 5. Check permission lalu allow/deny request
+		if !allowed(userPerms) {
 			return c.Status(fiber.StatusForbidden).JSON(model.WebResponse{
 				Code:    403,
 				Status:  "error",
-				Message: "Access denied. Missing permission: " + requiredPerm,
+				Message: deniedMessage,
 			})
 		}
 
@@ -10,9 +10,20 @@ import (
 func SetupRoutes(
 	app *fiber.App,
 	authService *service.AuthService,
+	userService *service.UserService,
+	roleService *service.RoleService,
+	apiTokenService *service.APITokenService,
+	oidcService *service.OIDCService,
+	impersonationService *service.ImpersonationService,
+	importService *service.ImportService,
+	studentService *service.StudentService,
+	masterService *service.MasterService,
 	achService *service.AchievementService,
 	authMiddleware *middleware.AuthMiddleware,
 ) {
+	// Public key (JWKS) agar service lain bisa memverifikasi token kita
+	app.Get("/.well-known/jwks.json", authService.JWKS)
+
 	api := app.Group("/api/v1")
 
 	// =================================================================
@@ -20,31 +31,103 @@ func SetupRoutes(
 	// =================================================================
 	auth := api.Group("/auth")
 	auth.Post("/login", authService.Login)
+	auth.Post("/login/mfa", authService.VerifyLoginMFA)        // Langkah kedua login (TOTP / recovery code)
+	auth.Post("/login/mfa/enroll", authService.EnrollLoginMFA) // Enrollment wajib (role MFA required)
+	auth.Post("/login/mfa/confirm", authService.ConfirmLoginMFA)
 	auth.Post("/refresh", authService.RefreshToken) // Logic di service
-	auth.Post("/logout", authService.Logout)        // Logic di service
+	auth.Post("/logout", authMiddleware.AuthRequired(), authService.Logout) // Cabut token (jti) + opsi logout everywhere
+	auth.Post("/forgot-password", authService.ForgotPassword)
+	auth.Post("/reset-password", authService.ResetPassword)
+
+	// Single Sign-On (OIDC authorization code + PKCE)
+	auth.Get("/oidc/login", oidcService.Login)
+	auth.Get("/oidc/callback", oidcService.Callback)
 	
 	// Profile (Butuh Token)
 	auth.Get("/profile", 
 		authMiddleware.AuthRequired(), 
 		authService.GetProfile,
 	)
+	auth.Put("/profile", authMiddleware.AuthRequired(), authMiddleware.InteractiveOnly(), authService.UpdateProfile)
+	auth.Post("/change-password", authMiddleware.AuthRequired(), authMiddleware.InteractiveOnly(), authService.ChangePassword)
+
+	// MFA (TOTP) untuk akun yang sedang login
+	mfa := auth.Group("/mfa", authMiddleware.AuthRequired(), authMiddleware.InteractiveOnly())
+	mfa.Post("/enroll", authService.EnrollMFA)
+	mfa.Post("/confirm", authService.ConfirmMFA)
+	mfa.Post("/disable", authService.DisableMFA)
+	mfa.Post("/recovery-codes", authService.RegenerateRecoveryCodes)
+
+	// Personal Access Token (integrasi)
+	tokens := auth.Group("/tokens", authMiddleware.AuthRequired(), authMiddleware.InteractiveOnly())
+	tokens.Get("/", apiTokenService.ListMine)
+	tokens.Post("/", apiTokenService.CreateMine)
+	tokens.Delete("/:id", apiTokenService.RevokeMine)
+
+	// Impersonation (helpdesk melihat aplikasi sebagai user lain, semua request diaudit)
+	auth.Post("/impersonate/:id",
+		authMiddleware.AuthRequired(),
+		authMiddleware.InteractiveOnly(),
+		authMiddleware.PermissionRequired("user:impersonate"),
+		impersonationService.Impersonate,
+	)
+
+	// Sesi login aktif (per perangkat)
+	sessions := auth.Group("/sessions", authMiddleware.AuthRequired(), authMiddleware.InteractiveOnly())
+	sessions.Get("/", authService.ListSessions)
+	sessions.Delete("/", authService.RevokeOtherSessions) // Logout semua perangkat lain
+	sessions.Delete("/:id", authService.RevokeSession)
 
 	// =================================================================
 	// 5.2 Users (Admin Only) [cite: 728-734]
 	// =================================================================
-	// Karena kita belum memisahkan UserService, kita gunakan AuthService sementara
-	// atau method Not Implemented di AuthService
 	users := api.Group("/users", 
 		authMiddleware.AuthRequired(), 
 		authMiddleware.PermissionRequired("user:manage"),
 	)
 	
-	users.Get("/", authService.GetAllUsers)
-	users.Get("/:id", authService.GetUserDetail)
-	users.Post("/", authService.CreateUser)
-	users.Put("/:id", authService.UpdateUser)
-	users.Delete("/:id", authService.DeleteUser)
-	users.Put("/:id/role", authService.UpdateUserRole)
+	users.Get("/", userService.GetAllUsers)
+	users.Post("/import", importService.ImportUsers) // Import roster Mahasiswa/Dosen (CSV/XLSX, ?type=&dryRun=&invite=)
+	users.Post("/service-accounts", apiTokenService.CreateServiceAccount) // User non-manusia untuk integrasi
+	users.Get("/trash", userService.GetDeletedUsers) // User yang sudah dihapus (soft delete)
+	users.Get("/:id", userService.GetUserDetail)
+	users.Post("/", userService.CreateUser)
+	users.Put("/:id", userService.UpdateUser)
+	users.Delete("/:id", userService.DeleteUser)
+	users.Put("/:id/role", userService.UpdateUserRole)
+	users.Patch("/:id/restore", userService.RestoreUser)
+	users.Patch("/:id/status", userService.UpdateUserStatus) // Aktifkan / nonaktifkan akun
+	users.Post("/:id/unlock", authService.UnlockUser) // Buka kunci akun (brute-force lockout)
+	users.Delete("/:id/mfa", authService.ResetUserMFA) // Reset MFA (user kehilangan device)
+	users.Get("/:id/tokens", apiTokenService.ListForUser)
+	users.Post("/:id/tokens", apiTokenService.CreateForServiceAccount)
+	users.Delete("/:id/tokens/:tokenId", apiTokenService.RevokeForUser)
+	users.Get("/:id/sessions", authService.ListUserSessions)
+	users.Delete("/:id/sessions", authService.RevokeAllUserSessions)
+	users.Delete("/:id/sessions/:sessionId", authService.RevokeUserSession)
+
+	// Roles & Permissions (Admin Only), setiap perubahan dicatat di audit log
+	roles := api.Group("/roles",
+		authMiddleware.AuthRequired(),
+		authMiddleware.PermissionRequired("user:manage"),
+	)
+	roles.Get("/", roleService.GetAllRoles)
+	roles.Post("/", roleService.CreateRole)
+	roles.Get("/:id", roleService.GetRoleDetail)
+	roles.Put("/:id", roleService.UpdateRole)
+	roles.Delete("/:id", roleService.DeleteRole)
+	roles.Get("/:id/users", roleService.GetRoleUsers)
+	roles.Post("/:id/permissions", roleService.AttachPermissions)
+	roles.Delete("/:id/permissions/:permissionId", roleService.DetachPermission)
+
+	permissions := api.Group("/permissions",
+		authMiddleware.AuthRequired(),
+		authMiddleware.PermissionRequired("user:manage"),
+	)
+	permissions.Get("/", roleService.GetAllPermissions)
+	permissions.Post("/", roleService.CreatePermission)
+	permissions.Put("/:id", roleService.UpdatePermission)
+	permissions.Delete("/:id", roleService.DeletePermission)
 
 	// =================================================================
 	// 5.4 Achievements [cite: 735-746]
@@ -81,6 +164,12 @@ func SetupRoutes(
 		achService.RequestVerification,
 	)
 
+	// Withdraw submission (Mahasiswa)
+	ach.Post("/:id/withdraw", 
+		authMiddleware.PermissionRequired("achievement:create"), 
+		achService.Withdraw,
+	)
+
 	// Verify (Dosen Wali)
 	ach.Post("/:id/verify", 
 		authMiddleware.PermissionRequired("achievement:verify"), 
@@ -93,6 +182,12 @@ func SetupRoutes(
 		achService.Reject,
 	)
 
+	// Revoke verification (Admin)
+	ach.Post("/:id/revoke", 
+		authMiddleware.PermissionRequired("user:manage"), 
+		achService.Revoke,
+	)
+
 	// Status history
 	ach.Get("/:id/history", achService.GetHistory)
 
@@ -103,20 +198,64 @@ func SetupRoutes(
 	// 5.5 Students & Lecturers [cite: 747-753]
 	// =================================================================
 	std := api.Group("/students", authMiddleware.AuthRequired())
-	std.Get("/", authService.GetAllStudents)
-	std.Get("/:id", authService.GetStudentDetail)
+	std.Get("/", studentService.GetAllStudents)
+	std.Get("/:id", studentService.GetStudentDetail)
 	std.Get("/:id/achievements", achService.GetStudentAchievements) // Logic di AchievementService
 	std.Put("/:id/advisor", 
 		authMiddleware.PermissionRequired("user:manage"), 
-		authService.UpdateStudentAdvisor,
+		studentService.UpdateStudentAdvisor,
 	)
+	std.Get("/:id/advisor-history", studentService.GetAdvisorHistory) // Admin, mahasiswa ybs & dosen wali-nya
 
 	lec := api.Group("/lecturers", authMiddleware.AuthRequired())
-	lec.Get("/", authService.GetAllLecturers)
+	lec.Get("/", studentService.GetAllLecturers)
 	lec.Get("/:id/advisees", 
 		// Ini mirip GetAdviseeAchievements tapi spesifik ID dosen tertentu
 		achService.GetAdviseeAchievements, 
 	)
+	// Pindahkan semua mahasiswa bimbingan ke dosen lain (dosen pensiun / pindah tugas)
+	lec.Post("/:id/advisees/reassign",
+		authMiddleware.PermissionRequired("user:manage"),
+		studentService.ReassignAdvisees,
+	)
+
+	// =================================================================
+	// Master Data Akademik (fakultas, jurusan, prodi, tahun akademik)
+	// Semua user login bisa membaca, perubahan hanya Admin
+	// =================================================================
+	master := api.Group("/master", authMiddleware.AuthRequired())
+	manageMaster := authMiddleware.PermissionRequired("user:manage")
+
+	master.Get("/faculties", masterService.GetFaculties)
+	master.Post("/faculties", manageMaster, masterService.CreateFaculty)
+	master.Put("/faculties/:id", manageMaster, masterService.UpdateFaculty)
+	master.Delete("/faculties/:id", manageMaster, masterService.DeleteFaculty)
+
+	master.Get("/departments", masterService.GetDepartments)
+	master.Post("/departments", manageMaster, masterService.CreateDepartment)
+	master.Put("/departments/:id", manageMaster, masterService.UpdateDepartment)
+	master.Delete("/departments/:id", manageMaster, masterService.DeleteDepartment)
+
+	master.Get("/program-studies", masterService.GetProgramStudies)
+	master.Post("/program-studies", manageMaster, masterService.CreateProgramStudy)
+	master.Put("/program-studies/:id", manageMaster, masterService.UpdateProgramStudy)
+	master.Delete("/program-studies/:id", manageMaster, masterService.DeleteProgramStudy)
+
+	master.Get("/academic-years", masterService.GetAcademicYears)
+	master.Get("/academic-years/current", masterService.GetCurrentAcademicYear)
+	master.Post("/academic-years", manageMaster, masterService.CreateAcademicYear)
+	master.Put("/academic-years/:id", manageMaster, masterService.UpdateAcademicYear)
+	master.Delete("/academic-years/:id", manageMaster, masterService.DeleteAcademicYear)
+	master.Post("/academic-years/:id/semesters", manageMaster, masterService.CreateSemester)
+	master.Put("/semesters/:id", manageMaster, masterService.UpdateSemester)
+	master.Delete("/semesters/:id", manageMaster, masterService.DeleteSemester)
+
+	// Pemetaan teks bebas lama ke data master
+	master.Get("/aliases", manageMaster, masterService.GetAliases)
+	master.Post("/aliases", manageMaster, masterService.CreateAlias)
+	master.Delete("/aliases/:id", manageMaster, masterService.DeleteAlias)
+	master.Get("/unmapped", manageMaster, masterService.GetUnmappedValues)
+	master.Post("/remap", manageMaster, masterService.RemapLegacyValues)
 
 	// =================================================================
 	// 5.8 Reports & Analytics [cite: 754-756]
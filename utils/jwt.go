@@ -1,62 +1,148 @@
 package utils
 
 import (
-	"errors" // [1] Tambahkan import ini
-	"os"
+	"errors"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret = []byte(os.Getenv("JWT_SECRET"))
+// Access token dibuat berumur pendek, sesi panjang dijaga oleh refresh token
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+	MFAChallengeTTL = 5 * time.Minute
+	// Token impersonation tidak punya refresh token, harus diminta ulang jika habis
+	ImpersonationTokenTTL = 10 * time.Minute
+)
+
+// Nilai claim "purpose" untuk token selain access token
+const (
+	PurposeMFAChallenge = "mfa_challenge" // Password benar, tinggal verifikasi kode TOTP
+	PurposeMFAEnroll    = "mfa_enroll"    // Role wajib MFA tapi user belum enroll
+)
+
+// PermissionStamp adalah versi permission user & role saat token dibuat.
+// Middleware membandingkannya dengan versi terkini untuk mendeteksi permission yang sudah basi.
+type PermissionStamp struct {
+	UserVersion int `json:"u"`
+	RoleVersion int `json:"r"`
+}
 
+// Claim "jti" (RegisteredClaims.ID) selalu diisi agar token bisa dicabut satu per satu saat logout
 type JwtClaims struct {
-	UserID      string   `json:"user_id"`
-	Role        string   `json:"role"`
-	Permissions []string `json:"permissions"`
+	UserID      string          `json:"user_id"`
+	Role        string          `json:"role"`
+	Permissions []string        `json:"permissions"`
+	Stamp       PermissionStamp `json:"pv"`
+	SessionID   string          `json:"sid,omitempty"`     // Sesi login (lihat /auth/sessions)
+	Actor       *ActorClaim     `json:"act,omitempty"`     // Diisi jika token dipakai admin untuk impersonate user lain
+	Purpose     string          `json:"purpose,omitempty"` // Kosong untuk access token biasa
 	jwt.RegisteredClaims
 }
 
-func GenerateToken(userID string, role string, permissions []string) (string, error) {
-	if len(jwtSecret) == 0 {
-		jwtSecret = []byte("rahasia_default_jangan_dipakai_production")
+// ActorClaim adalah claim "act" (RFC 8693): user yang sebenarnya bertindak atas nama Subject
+type ActorClaim struct {
+	Subject string `json:"sub"`
+}
+
+// GenerateToken membuat access token yang ditandatangani key aktif di keyring (RS256/EdDSA)
+func GenerateToken(userID string, role string, permissions []string, stamp PermissionStamp, sessionID string) (string, error) {
+	jti, err := GenerateUUID()
+	if err != nil {
+		return "", err
 	}
 
 	claims := JwtClaims{
 		UserID:      userID,
 		Role:        role,
 		Permissions: permissions,
+		Stamp:       stamp,
+		SessionID:   sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)),
+			ID:        jti,
+			Subject:   userID,
+			Audience:  jwt.ClaimStrings{AccessTokenKind.Audience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenTTL)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtSecret)
+	return SignClaims(claims, AccessTokenKind)
 }
 
-func ParseToken(tokenString string) (*JwtClaims, error) {
-	if len(jwtSecret) == 0 {
-		jwtSecret = []byte("rahasia_default_jangan_dipakai_production")
+// GenerateImpersonationToken membuat access token berumur pendek atas nama userID yang dipakai oleh actorID.
+// sessionID adalah sesi milik actor, sehingga mencabut sesi actor ikut mematikan token ini.
+func GenerateImpersonationToken(userID string, role string, permissions []string, stamp PermissionStamp, actorID string, sessionID string) (string, time.Time, error) {
+	jti, err := GenerateUUID()
+	if err != nil {
+		return "", time.Time{}, err
 	}
 
-	token, err := jwt.ParseWithClaims(tokenString, &JwtClaims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrTokenSignatureInvalid // Ini ada di v5
-		}
-		return jwtSecret, nil
-	})
+	now := time.Now()
+	expiresAt := now.Add(ImpersonationTokenTTL)
+	claims := JwtClaims{
+		UserID:      userID,
+		Role:        role,
+		Permissions: permissions,
+		Stamp:       stamp,
+		SessionID:   sessionID,
+		Actor:       &ActorClaim{Subject: actorID},
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Subject:   userID,
+			Audience:  jwt.ClaimStrings{AccessTokenKind.Audience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
 
-	if err != nil {
+	token, err := SignClaims(claims, AccessTokenKind)
+	return token, expiresAt, err
+}
+
+// ParseToken memverifikasi access token. Token khusus (misal MFA challenge) ditolak di sini.
+func ParseToken(tokenString string) (*JwtClaims, error) {
+	claims := &JwtClaims{}
+	if err := ParseClaims(tokenString, claims, AccessTokenKind); err != nil {
 		return nil, err
 	}
+	if claims.Purpose != "" {
+		return nil, errors.New("token is not an access token")
+	}
+	return claims, nil
+}
 
-	// Validasi Claims
-	if claims, ok := token.Claims.(*JwtClaims); ok && token.Valid {
-		return claims, nil
+// GenerateMFAChallengeToken membuat token berumur pendek yang hanya bisa dipakai untuk langkah kedua login
+func GenerateMFAChallengeToken(userID string, purpose string) (string, error) {
+	jti, err := GenerateUUID()
+	if err != nil {
+		return "", err
+	}
+
+	claims := JwtClaims{
+		UserID:  userID,
+		Purpose: purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Subject:   userID,
+			Audience:  jwt.ClaimStrings{MFAChallengeTokenKind.Audience},
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(MFAChallengeTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
 	}
 
-	// [2] Perbaikan: Gunakan errors.New karena jwt.ErrTokenInvalid tidak ada
-	return nil, errors.New("token invalid") 
-}
\ No newline at end of file
+	return SignClaims(claims, MFAChallengeTokenKind)
+}
+
+// ParseMFAChallengeToken memverifikasi challenge token dengan purpose tertentu
+func ParseMFAChallengeToken(tokenString string, purpose string) (*JwtClaims, error) {
+	claims := &JwtClaims{}
+	if err := ParseClaims(tokenString, claims, MFAChallengeTokenKind); err != nil {
+		return nil, err
+	}
+	if claims.Purpose != purpose {
+		return nil, errors.New("invalid challenge token")
+	}
+	return claims, nil
+}
@@ -0,0 +1,304 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Format file keyring (JWT_KEYRING_FILE), contoh:
+//
+//	{
+//	  "keys": [
+//	    {"kid": "2026-09", "privateKeyFile": "jwt-2026-09.pem", "notBefore": "2026-09-01T00:00:00Z", "retireAt": "2026-10-01T00:00:00Z", "expiresAt": "2026-10-02T00:00:00Z"},
+//	    {"kid": "2026-10", "privateKeyFile": "jwt-2026-10.pem", "notBefore": "2026-10-01T00:00:00Z"}
+//	  ]
+//	}
+//
+// - notBefore : mulai dipakai untuk signing (kosong = langsung aktif)
+// - retireAt  : berhenti dipakai untuk signing, tapi masih bisa memverifikasi token lama
+// - expiresAt : berhenti memverifikasi & hilang dari JWKS (harus >= retireAt + umur token)
+// Key baru sebaiknya dipasang sebelum notBefore-nya, agar service lain sempat mengambil JWKS terbaru.
+// Path privateKeyFile relatif terhadap lokasi file keyring.
+type keyRingFile struct {
+	Keys []keyRingEntry `json:"keys"`
+}
+
+type keyRingEntry struct {
+	KID            string     `json:"kid"`
+	PrivateKeyFile string     `json:"privateKeyFile"`
+	NotBefore      *time.Time `json:"notBefore"`
+	RetireAt       *time.Time `json:"retireAt"`
+	ExpiresAt      *time.Time `json:"expiresAt"`
+}
+
+type signingKey struct {
+	kid       string
+	method    jwt.SigningMethod
+	private   crypto.Signer
+	public    crypto.PublicKey
+	notBefore time.Time
+	retireAt  *time.Time
+	expiresAt *time.Time
+}
+
+func (k *signingKey) canSign(now time.Time) bool {
+	return !now.Before(k.notBefore) && (k.retireAt == nil || now.Before(*k.retireAt)) && k.canVerify(now)
+}
+
+func (k *signingKey) canVerify(now time.Time) bool {
+	return k.expiresAt == nil || now.Before(*k.expiresAt)
+}
+
+// KeyRing berisi semua key (RS256 / EdDSA) yang dipakai untuk sign & verify JWT
+type KeyRing struct {
+	keys []*signingKey
+}
+
+var keyRing *KeyRing
+
+// InitKeyRing memuat key dari JWT_KEYRING_FILE, atau satu key dari JWT_PRIVATE_KEY_FILE (+ JWT_KEY_ID).
+// Return error jika tidak ada key yang bisa dipakai untuk signing, server tidak boleh jalan tanpa key.
+func InitKeyRing() error {
+	ring, err := loadKeyRing()
+	if err != nil {
+		return err
+	}
+
+	if _, err := ring.currentSigningKey(time.Now()); err != nil {
+		return err
+	}
+
+	keyRing = ring
+	return nil
+}
+
+func loadKeyRing() (*KeyRing, error) {
+	if path := os.Getenv("JWT_KEYRING_FILE"); path != "" {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read keyring %s: %w", path, err)
+		}
+
+		var file keyRingFile
+		if err := json.Unmarshal(raw, &file); err != nil {
+			return nil, fmt.Errorf("invalid keyring %s: %w", path, err)
+		}
+
+		ring := &KeyRing{}
+		seen := map[string]bool{}
+		for _, entry := range file.Keys {
+			if entry.KID == "" || entry.PrivateKeyFile == "" {
+				return nil, errors.New("keyring entry requires kid and privateKeyFile")
+			}
+			if seen[entry.KID] {
+				return nil, fmt.Errorf("duplicate kid %q in keyring", entry.KID)
+			}
+			seen[entry.KID] = true
+
+			keyPath := entry.PrivateKeyFile
+			if !filepath.IsAbs(keyPath) {
+				keyPath = filepath.Join(filepath.Dir(path), keyPath)
+			}
+
+			key, err := loadSigningKey(entry.KID, keyPath)
+			if err != nil {
+				return nil, err
+			}
+			if entry.NotBefore != nil {
+				key.notBefore = *entry.NotBefore
+			}
+			key.retireAt = entry.RetireAt
+			key.expiresAt = entry.ExpiresAt
+			ring.keys = append(ring.keys, key)
+		}
+		return ring, nil
+	}
+
+	if path := os.Getenv("JWT_PRIVATE_KEY_FILE"); path != "" {
+		kid := os.Getenv("JWT_KEY_ID")
+		if kid == "" {
+			kid = "default"
+		}
+		key, err := loadSigningKey(kid, path)
+		if err != nil {
+			return nil, err
+		}
+		return &KeyRing{keys: []*signingKey{key}}, nil
+	}
+
+	return nil, errors.New("no JWT signing key configured: set JWT_KEYRING_FILE or JWT_PRIVATE_KEY_FILE")
+}
+
+// loadSigningKey membaca private key PEM (RSA PKCS#1/PKCS#8 atau Ed25519 PKCS#8)
+func loadSigningKey(kid, path string) (*signingKey, error) {
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key %s: %w", path, err)
+	}
+
+	if rsaKey, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes); err == nil {
+		if rsaKey.N.BitLen() < 2048 {
+			return nil, fmt.Errorf("RSA key %s must be at least 2048 bits", kid)
+		}
+		return &signingKey{kid: kid, method: jwt.SigningMethodRS256, private: rsaKey, public: &rsaKey.PublicKey}, nil
+	}
+
+	if edKey, err := jwt.ParseEdPrivateKeyFromPEM(pemBytes); err == nil {
+		signer := edKey.(ed25519.PrivateKey)
+		return &signingKey{kid: kid, method: jwt.SigningMethodEdDSA, private: signer, public: signer.Public()}, nil
+	}
+
+	return nil, fmt.Errorf("key %s is not a supported RSA or Ed25519 private key", kid)
+}
+
+// currentSigningKey memilih key aktif dengan notBefore paling baru
+func (k *KeyRing) currentSigningKey(now time.Time) (*signingKey, error) {
+	var current *signingKey
+	for _, key := range k.keys {
+		if key.canSign(now) && (current == nil || key.notBefore.After(current.notBefore)) {
+			current = key
+		}
+	}
+	if current == nil {
+		return nil, errors.New("no active JWT signing key in keyring")
+	}
+	return current, nil
+}
+
+func (k *KeyRing) verificationKey(kid string, now time.Time) (*signingKey, error) {
+	for _, key := range k.keys {
+		if key.kid == kid {
+			if !key.canVerify(now) {
+				return nil, fmt.Errorf("key %q has expired", kid)
+			}
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown key %q", kid)
+}
+
+// TokenKind membedakan jenis token yang ditandatangani keyring yang sama (dan dipublikasikan di JWKS).
+// Setiap jenis punya header "typ" & claim "aud" sendiri, sehingga service lain yang memverifikasi
+// lewat JWKS tidak menerima challenge / state token sebagai access token.
+type TokenKind struct {
+	Type     string // Header "typ" (RFC 9068 untuk access token)
+	Audience string // Claim "aud"
+}
+
+var (
+	AccessTokenKind       = TokenKind{Type: "at+jwt", Audience: "uas-api"}
+	MFAChallengeTokenKind = TokenKind{Type: "mfa-challenge+jwt", Audience: "uas-mfa-challenge"}
+	OIDCStateTokenKind    = TokenKind{Type: "oidc-state+jwt", Audience: "uas-oidc-state"}
+)
+
+// SignClaims menandatangani claims dengan key aktif dan menambahkan header "kid" & "typ".
+// Claim "aud" diisi oleh pembuat claims sesuai kind.Audience.
+func SignClaims(claims jwt.Claims, kind TokenKind) (string, error) {
+	if keyRing == nil {
+		return "", errors.New("keyring not initialized")
+	}
+
+	key, err := keyRing.currentSigningKey(time.Now())
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.NewWithClaims(key.method, claims)
+	token.Header["kid"] = key.kid
+	token.Header["typ"] = kind.Type
+	return token.SignedString(key.private)
+}
+
+// ParseClaims memverifikasi token menggunakan key sesuai header "kid",
+// lalu memastikan header "typ" & claim "aud" sesuai jenis token yang diharapkan
+func ParseClaims(tokenString string, claims jwt.Claims, kind TokenKind) error {
+	if keyRing == nil {
+		return errors.New("keyring not initialized")
+	}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if typ, _ := token.Header["typ"].(string); typ != kind.Type {
+			return nil, fmt.Errorf("unexpected token type %q", typ)
+		}
+		kid, _ := token.Header["kid"].(string)
+		key, err := keyRing.verificationKey(kid, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		// Algoritma di header harus sama dengan jenis key, cegah algorithm confusion
+		if token.Method.Alg() != key.method.Alg() {
+			return nil, jwt.ErrTokenSignatureInvalid
+		}
+		return key.public, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodRS256.Alg(), jwt.SigningMethodEdDSA.Alg()}), jwt.WithAudience(kind.Audience))
+	if err != nil {
+		return err
+	}
+
+	if !token.Valid {
+		return errors.New("token invalid")
+	}
+	return nil
+}
+
+// --- JWKS (RFC 7517) ---
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JWKSet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicJWKS mengembalikan public key yang masih berlaku (termasuk key yang belum mulai dipakai signing)
+func PublicJWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	if keyRing == nil {
+		return set
+	}
+
+	now := time.Now()
+	keys := make([]*signingKey, 0, len(keyRing.keys))
+	for _, key := range keyRing.keys {
+		if key.canVerify(now) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i].notBefore.After(keys[j].notBefore) })
+
+	for _, key := range keys {
+		jwk := JWK{Kid: key.kid, Use: "sig", Alg: key.method.Alg()}
+		switch pub := key.public.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
@@ -0,0 +1,242 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// setupTestKeyRing membuat keyring dari file (seperti JWT_KEYRING_FILE) berisi:
+//   - "ed-active"  : Ed25519, key signing aktif
+//   - "rsa-retired": RSA, sudah tidak dipakai signing tapi masih memverifikasi
+//   - "rsa-expired": RSA, sudah kedaluwarsa (tidak boleh memverifikasi)
+func setupTestKeyRing(t *testing.T) {
+	t.Helper()
+
+	dir := t.TempDir()
+	writeKey := func(name string, key interface{}) {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeKey("ed.pem", edKey)
+	writeKey("rsa.pem", rsaKey)
+
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	longAgo := now.Add(-2 * time.Hour)
+	future := now.Add(time.Hour)
+	file := keyRingFile{Keys: []keyRingEntry{
+		{KID: "ed-active", PrivateKeyFile: "ed.pem"},
+		{KID: "rsa-retired", PrivateKeyFile: "rsa.pem", NotBefore: &longAgo, RetireAt: &past, ExpiresAt: &future},
+		{KID: "rsa-expired", PrivateKeyFile: "rsa.pem", NotBefore: &longAgo, RetireAt: &longAgo, ExpiresAt: &past},
+	}}
+	raw, err := json.Marshal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "keyring.json")
+	if err := os.WriteFile(path, raw, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	previous := keyRing
+	t.Cleanup(func() { keyRing = previous })
+	t.Setenv("JWT_KEYRING_FILE", path)
+	if err := InitKeyRing(); err != nil {
+		t.Fatalf("InitKeyRing: %v", err)
+	}
+}
+
+func testKey(t *testing.T, kid string) *signingKey {
+	t.Helper()
+	for _, key := range keyRing.keys {
+		if key.kid == kid {
+			return key
+		}
+	}
+	t.Fatalf("key %s not found", kid)
+	return nil
+}
+
+// signRaw menandatangani claims langsung (tanpa SignClaims) untuk membuat token yang tidak valid
+func signRaw(t *testing.T, method jwt.SigningMethod, signer interface{}, kid string, typ string, claims jwt.Claims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = kid
+	if typ != "" {
+		token.Header["typ"] = typ
+	}
+	signed, err := token.SignedString(signer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed
+}
+
+func accessClaims(exp time.Time, audience ...string) *JwtClaims {
+	return &JwtClaims{
+		UserID: "u-1",
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "jti-1",
+			Subject:   "u-1",
+			Audience:  audience,
+			ExpiresAt: jwt.NewNumericDate(exp),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+		},
+	}
+}
+
+func TestSignClaimsUsesActiveKey(t *testing.T) {
+	setupTestKeyRing(t)
+
+	token, err := GenerateToken("u-1", "Admin", []string{"user:manage"}, PermissionStamp{}, "s-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, _, err := jwt.NewParser().ParseUnverified(token, &JwtClaims{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed.Header["kid"] != "ed-active" || parsed.Header["typ"] != AccessTokenKind.Type || parsed.Method.Alg() != "EdDSA" {
+		t.Errorf("unexpected header %v", parsed.Header)
+	}
+
+	claims, err := ParseToken(token)
+	if err != nil {
+		t.Fatalf("ParseToken: %v", err)
+	}
+	if claims.UserID != "u-1" || claims.SessionID != "s-1" {
+		t.Errorf("unexpected claims %+v", claims)
+	}
+}
+
+func TestTokenKindsAreNotInterchangeable(t *testing.T) {
+	setupTestKeyRing(t)
+
+	access, err := GenerateToken("u-1", "Admin", nil, PermissionStamp{}, "s-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	challenge, err := GenerateMFAChallengeToken("u-1", PurposeMFAChallenge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enroll, err := GenerateMFAChallengeToken("u-1", PurposeMFAEnroll)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := GenerateOIDCStateToken("state", "nonce", "verifier")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ParseToken(challenge); err == nil {
+		t.Error("MFA challenge token accepted as access token")
+	}
+	if _, err := ParseToken(enroll); err == nil {
+		t.Error("MFA enroll token accepted as access token")
+	}
+	if _, err := ParseToken(state); err == nil {
+		t.Error("OIDC state token accepted as access token")
+	}
+	if _, err := ParseMFAChallengeToken(access, PurposeMFAChallenge); err == nil {
+		t.Error("access token accepted as MFA challenge token")
+	}
+	if _, err := ParseMFAChallengeToken(enroll, PurposeMFAChallenge); err == nil {
+		t.Error("MFA enroll token accepted for MFA challenge purpose")
+	}
+	if _, err := ParseOIDCStateToken(challenge); err == nil {
+		t.Error("MFA challenge token accepted as OIDC state token")
+	}
+
+	if _, err := ParseMFAChallengeToken(challenge, PurposeMFAChallenge); err != nil {
+		t.Errorf("MFA challenge token rejected: %v", err)
+	}
+	if claims, err := ParseOIDCStateToken(state); err != nil || claims.Nonce != "nonce" {
+		t.Errorf("OIDC state token rejected: %v", err)
+	}
+}
+
+func TestParseClaimsRejectsInvalidTokens(t *testing.T) {
+	setupTestKeyRing(t)
+
+	ed := testKey(t, "ed-active")
+	retired := testKey(t, "rsa-retired")
+	expiredKey := testKey(t, "rsa-expired")
+	valid := time.Now().Add(time.Minute)
+	aud := AccessTokenKind.Audience
+
+	tests := []struct {
+		name  string
+		token string
+		ok    bool
+	}{
+		{"valid Ed25519 access token", signRaw(t, jwt.SigningMethodEdDSA, ed.private, "ed-active", "at+jwt", accessClaims(valid, aud)), true},
+		{"retired RSA key still verifies", signRaw(t, jwt.SigningMethodRS256, retired.private, "rsa-retired", "at+jwt", accessClaims(valid, aud)), true},
+		{"missing typ", signRaw(t, jwt.SigningMethodEdDSA, ed.private, "ed-active", "", accessClaims(valid, aud)), false},
+		{"generic JWT typ", signRaw(t, jwt.SigningMethodEdDSA, ed.private, "ed-active", "JWT", accessClaims(valid, aud)), false},
+		{"challenge typ", signRaw(t, jwt.SigningMethodEdDSA, ed.private, "ed-active", MFAChallengeTokenKind.Type, accessClaims(valid, aud)), false},
+		{"missing audience", signRaw(t, jwt.SigningMethodEdDSA, ed.private, "ed-active", "at+jwt", accessClaims(valid)), false},
+		{"challenge audience", signRaw(t, jwt.SigningMethodEdDSA, ed.private, "ed-active", "at+jwt", accessClaims(valid, MFAChallengeTokenKind.Audience)), false},
+		{"expired token", signRaw(t, jwt.SigningMethodEdDSA, ed.private, "ed-active", "at+jwt", accessClaims(time.Now().Add(-time.Minute), aud)), false},
+		{"unknown kid", signRaw(t, jwt.SigningMethodEdDSA, ed.private, "unknown", "at+jwt", accessClaims(valid, aud)), false},
+		{"missing kid", signRaw(t, jwt.SigningMethodEdDSA, ed.private, "", "at+jwt", accessClaims(valid, aud)), false},
+		{"expired kid", signRaw(t, jwt.SigningMethodRS256, expiredKey.private, "rsa-expired", "at+jwt", accessClaims(valid, aud)), false},
+		{"RS256 token claiming Ed25519 kid", signRaw(t, jwt.SigningMethodRS256, retired.private, "ed-active", "at+jwt", accessClaims(valid, aud)), false},
+		{"EdDSA token claiming RSA kid", signRaw(t, jwt.SigningMethodEdDSA, ed.private, "rsa-retired", "at+jwt", accessClaims(valid, aud)), false},
+		{"HS256 signed with public key", signRaw(t, jwt.SigningMethodHS256, []byte(ed.public.(ed25519.PublicKey)), "ed-active", "at+jwt", accessClaims(valid, aud)), false},
+		{"signed by a key outside the keyring", signRaw(t, jwt.SigningMethodEdDSA, ed25519.NewKeyFromSeed(make([]byte, 32)), "ed-active", "at+jwt", accessClaims(valid, aud)), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ParseClaims(tt.token, &JwtClaims{}, AccessTokenKind)
+			if tt.ok && err != nil {
+				t.Errorf("expected token to be accepted, got %v", err)
+			}
+			if !tt.ok && err == nil {
+				t.Error("expected token to be rejected")
+			}
+		})
+	}
+}
+
+func TestPublicJWKSExcludesExpiredKeys(t *testing.T) {
+	setupTestKeyRing(t)
+
+	kids := map[string]bool{}
+	for _, key := range PublicJWKS().Keys {
+		kids[key.Kid] = true
+	}
+	if !kids["ed-active"] || !kids["rsa-retired"] {
+		t.Errorf("expected active and retired keys in JWKS, got %v", kids)
+	}
+	if kids["rsa-expired"] {
+		t.Error("expired key must not be published")
+	}
+}
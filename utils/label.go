@@ -0,0 +1,14 @@
+package utils
+
+import "strings"
+
+// NormalizeLabel menyeragamkan teks bebas (nama prodi, jurusan, alias) sebelum dicocokkan:
+// huruf kecil & spasi berlebih dibuang, mis. "  Teknik  Informatika " -> "teknik informatika"
+func NormalizeLabel(s string) string {
+	return strings.ToLower(CleanLabel(s))
+}
+
+// CleanLabel merapikan spasi tanpa mengubah huruf besar/kecil (untuk nama yang disimpan)
+func CleanLabel(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
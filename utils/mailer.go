@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"fmt"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Mail adalah satu email plain-text yang akan dikirim
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer adalah abstraksi pengirim email (SMTP untuk production, outbox file untuk dev & test)
+type Mailer interface {
+	Send(mail Mail) error
+}
+
+// NewMailerFromEnv memilih implementasi berdasarkan MAIL_DRIVER (smtp | outbox, default outbox)
+func NewMailerFromEnv() Mailer {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "no-reply@localhost"
+	}
+
+	if os.Getenv("MAIL_DRIVER") == "smtp" {
+		return &SMTPMailer{
+			Host:     os.Getenv("SMTP_HOST"),
+			Port:     os.Getenv("SMTP_PORT"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}
+	}
+
+	dir := os.Getenv("MAIL_OUTBOX_DIR")
+	if dir == "" {
+		dir = "storage/outbox"
+	}
+	return &OutboxMailer{Dir: dir, From: from}
+}
+
+// --- SMTP ---
+
+type SMTPMailer struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+}
+
+func (m *SMTPMailer) Send(mail Mail) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		auth = smtp.PlainAuth("", m.Username, m.Password, m.Host)
+	}
+	return smtp.SendMail(m.Host+":"+m.Port, auth, m.From, []string{mail.To}, buildMessage(m.From, mail))
+}
+
+// --- Outbox (File) ---
+
+// OutboxMailer menulis setiap email sebagai file .eml di folder Dir, tidak ada email yang benar-benar terkirim
+type OutboxMailer struct {
+	Dir  string
+	From string
+}
+
+func (m *OutboxMailer) Send(mail Mail) error {
+	if err := os.MkdirAll(m.Dir, 0o755); err != nil {
+		return err
+	}
+
+	suffix, err := GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("%s-%s.eml", time.Now().Format("20060102-150405"), suffix[:8])
+	return os.WriteFile(filepath.Join(m.Dir, name), buildMessage(m.From, mail), 0o600)
+}
+
+func buildMessage(from string, mail Mail) []byte {
+	// Cegah header injection lewat newline di subject/alamat
+	clean := strings.NewReplacer("\r", "", "\n", "")
+
+	var b strings.Builder
+	b.WriteString("From: " + clean.Replace(from) + "\r\n")
+	b.WriteString("To: " + clean.Replace(mail.To) + "\r\n")
+	b.WriteString("Subject: " + clean.Replace(mail.Subject) + "\r\n")
+	b.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(mail.Body)
+	return []byte(b.String())
+}
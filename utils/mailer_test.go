@@ -0,0 +1,79 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBuildMessageStripsHeaderNewlines(t *testing.T) {
+	msg := string(buildMessage("no-reply@localhost\r\nBcc: attacker@example.com", Mail{
+		To:      "user@example.com\nBcc: attacker@example.com",
+		Subject: "Reset\r\nX-Injected: yes",
+		Body:    "Baris 1\nBaris 2",
+	}))
+
+	headers, body, found := strings.Cut(msg, "\r\n\r\n")
+	if !found {
+		t.Fatalf("message has no header/body separator: %q", msg)
+	}
+
+	for _, line := range strings.Split(headers, "\r\n") {
+		name, _, _ := strings.Cut(line, ":")
+		switch name {
+		case "From", "To", "Subject", "Date", "MIME-Version", "Content-Type":
+		default:
+			t.Errorf("unexpected header line %q", line)
+		}
+	}
+	if strings.Contains(headers, "\n\n") || strings.Count(headers, "\n") != strings.Count(headers, "\r\n") {
+		t.Error("headers contain a bare newline")
+	}
+	if !strings.Contains(headers, "Subject: ResetX-Injected: yes") {
+		t.Errorf("subject not flattened: %q", headers)
+	}
+
+	// Body tidak diubah
+	if body != "Baris 1\nBaris 2" {
+		t.Errorf("unexpected body %q", body)
+	}
+}
+
+func TestOutboxMailerWritesEmlFiles(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	mailer := &OutboxMailer{Dir: dir, From: "no-reply@localhost"}
+
+	for _, to := range []string{"a@example.com", "b@example.com"} {
+		if err := mailer.Send(Mail{To: to, Subject: "Halo", Body: "Isi email " + to}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("expected 2 .eml files, got %d", len(files))
+	}
+
+	var recipients []string
+	for _, file := range files {
+		raw, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(string(raw), "From: no-reply@localhost\r\n") {
+			t.Errorf("%s: unexpected content %q", file, raw)
+		}
+		for _, line := range strings.Split(string(raw), "\r\n") {
+			if to, ok := strings.CutPrefix(line, "To: "); ok {
+				recipients = append(recipients, to)
+			}
+		}
+	}
+	if strings.Join(recipients, ",") != "a@example.com,b@example.com" && strings.Join(recipients, ",") != "b@example.com,a@example.com" {
+		t.Errorf("unexpected recipients %v", recipients)
+	}
+}
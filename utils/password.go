@@ -1,17 +1,209 @@
 package utils
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
-// HashPassword mengenkripsi password menggunakan bcrypt
-func HashPassword(password string) (string, error) {
-	bytes, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+// PasswordHasher adalah algoritma hash password. Hash lama dengan algoritma/parameter lain
+// tetap bisa diverifikasi, lalu di-hash ulang saat user login (lihat PasswordNeedsRehash).
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Supports mengecek apakah format hash dikenali hasher ini
+	Supports(hash string) bool
+	Verify(password, hash string) bool
+	// NeedsRehash true jika hash memakai parameter yang berbeda dari konfigurasi saat ini
+	NeedsRehash(hash string) bool
+}
+
+// --- bcrypt ---
+
+type BcryptHasher struct {
+	Cost int
+}
+
+func (h BcryptHasher) Hash(password string) (string, error) {
+	bytes, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
 	return string(bytes), err
 }
 
+func (h BcryptHasher) Supports(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
+
+func (h BcryptHasher) Verify(password, hash string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+func (h BcryptHasher) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.Cost
+}
+
+// --- argon2id ---
+
+// Argon2idHasher menyimpan hash dalam format PHC: $argon2id$v=19$m=65536,t=3,p=2$<salt>$<hash>
+type Argon2idHasher struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  int
+	KeyLength   uint32
+}
+
+type argon2Params struct {
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+	salt        []byte
+	key         []byte
+}
+
+func (h Argon2idHasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, h.Memory, h.Iterations, h.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+func (h Argon2idHasher) Supports(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+func (h Argon2idHasher) Verify(password, hash string) bool {
+	p, err := parseArgon2Hash(hash)
+	if err != nil {
+		return false
+	}
+
+	key := argon2.IDKey([]byte(password), p.salt, p.iterations, p.memory, p.parallelism, uint32(len(p.key)))
+	return subtle.ConstantTimeCompare(key, p.key) == 1
+}
+
+func (h Argon2idHasher) NeedsRehash(hash string) bool {
+	p, err := parseArgon2Hash(hash)
+	if err != nil {
+		return true
+	}
+	return p.memory != h.Memory || p.iterations != h.Iterations || p.parallelism != h.Parallelism ||
+		len(p.salt) != h.SaltLength || uint32(len(p.key)) != h.KeyLength
+}
+
+func parseArgon2Hash(hash string) (*argon2Params, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, errors.New("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return nil, errors.New("unsupported argon2 version")
+	}
+
+	p := &argon2Params{}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.memory, &p.iterations, &p.parallelism); err != nil {
+		return nil, errors.New("invalid argon2id parameters")
+	}
+
+	var err error
+	if p.salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return nil, err
+	}
+	if p.key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// --- Hasher aktif ---
+
+// Default: bcrypt cost 10 (sama seperti sebelumnya), bisa diganti lewat InitPasswordHasher
+var passwordHasher PasswordHasher = BcryptHasher{Cost: bcrypt.DefaultCost}
+
+// Semua algoritma yang bisa diverifikasi, agar hash lama tetap bisa dipakai login setelah algoritma diganti
+var knownHashers = []PasswordHasher{BcryptHasher{}, Argon2idHasher{}}
+
+// InitPasswordHasher memilih algoritma hash dari env:
+// PASSWORD_HASHER=bcrypt|argon2id, BCRYPT_COST, ARGON2_MEMORY_KB, ARGON2_ITERATIONS, ARGON2_PARALLELISM
+func InitPasswordHasher() error {
+	switch strings.ToLower(os.Getenv("PASSWORD_HASHER")) {
+	case "", "bcrypt":
+		cost, err := envInt("BCRYPT_COST", bcrypt.DefaultCost)
+		if err != nil {
+			return err
+		}
+		if cost < bcrypt.MinCost || cost > bcrypt.MaxCost {
+			return fmt.Errorf("BCRYPT_COST must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+		passwordHasher = BcryptHasher{Cost: cost}
+	case "argon2id":
+		memory, err1 := envInt("ARGON2_MEMORY_KB", 64*1024)
+		iterations, err2 := envInt("ARGON2_ITERATIONS", 3)
+		parallelism, err3 := envInt("ARGON2_PARALLELISM", 2)
+		if err := errors.Join(err1, err2, err3); err != nil {
+			return err
+		}
+		if memory < 8*1024 || iterations < 1 || parallelism < 1 || parallelism > 255 {
+			return errors.New("invalid argon2id parameters (min 8192 KiB memory, 1 iteration, 1-255 parallelism)")
+		}
+		passwordHasher = Argon2idHasher{
+			Memory:      uint32(memory),
+			Iterations:  uint32(iterations),
+			Parallelism: uint8(parallelism),
+			SaltLength:  16,
+			KeyLength:   32,
+		}
+	default:
+		return fmt.Errorf("unsupported PASSWORD_HASHER %q", os.Getenv("PASSWORD_HASHER"))
+	}
+	return nil
+}
+
+// HashPassword mengenkripsi password menggunakan hasher aktif (bcrypt / argon2id)
+func HashPassword(password string) (string, error) {
+	return passwordHasher.Hash(password)
+}
+
 // CheckPasswordHash membandingkan password inputan user dengan hash di database
+// (algoritma dikenali dari format hash-nya)
 func CheckPasswordHash(password, hash string) bool {
-	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
-	return err == nil
-}
\ No newline at end of file
+	for _, hasher := range knownHashers {
+		if hasher.Supports(hash) {
+			return hasher.Verify(password, hash)
+		}
+	}
+	return false
+}
+
+// PasswordNeedsRehash true jika hash dibuat dengan algoritma/parameter yang berbeda dari hasher aktif
+func PasswordNeedsRehash(hash string) bool {
+	return !passwordHasher.Supports(hash) || passwordHasher.NeedsRehash(hash)
+}
+
+func envInt(name string, fallback int) (int, error) {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return fallback, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number", name)
+	}
+	return value, nil
+}
@@ -0,0 +1,138 @@
+package utils
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// PasswordPolicy adalah aturan password baru (dipakai saat create user, reset & ganti password)
+type PasswordPolicy struct {
+	MinLength   int
+	MaxLength   int
+	HistorySize int // Password baru tidak boleh sama dengan N password terakhir (0 = nonaktif)
+
+	breached map[string]struct{} // SHA-1 (hex, huruf besar) dari password yang pernah bocor
+}
+
+// Default tanpa daftar password bocor, diganti lewat InitPasswordPolicy
+var passwordPolicy = &PasswordPolicy{MinLength: 8, MaxLength: 72, HistorySize: 5}
+
+// PasswordPolicyError adalah error validasi policy, pesannya aman ditampilkan ke user
+type PasswordPolicyError struct {
+	Reason string
+}
+
+func (e *PasswordPolicyError) Error() string { return e.Reason }
+
+// InitPasswordPolicy membaca policy dari env:
+// PASSWORD_MIN_LENGTH, PASSWORD_HISTORY, PASSWORD_BREACHED_LIST (file, satu password atau SHA-1 hex per baris)
+func InitPasswordPolicy() error {
+	minLength, err1 := envInt("PASSWORD_MIN_LENGTH", 8)
+	history, err2 := envInt("PASSWORD_HISTORY", 5)
+	if err := errors.Join(err1, err2); err != nil {
+		return err
+	}
+	if minLength < 8 {
+		return errors.New("PASSWORD_MIN_LENGTH cannot be lower than 8")
+	}
+	if history < 0 {
+		return errors.New("PASSWORD_HISTORY cannot be negative")
+	}
+
+	policy := &PasswordPolicy{MinLength: minLength, MaxLength: 72, HistorySize: history}
+
+	// bcrypt hanya memakai 72 byte pertama, argon2id tidak punya batas tersebut
+	if _, ok := passwordHasher.(Argon2idHasher); ok {
+		policy.MaxLength = 256
+	}
+	if policy.MinLength > policy.MaxLength {
+		return fmt.Errorf("PASSWORD_MIN_LENGTH cannot exceed %d", policy.MaxLength)
+	}
+
+	if path := os.Getenv("PASSWORD_BREACHED_LIST"); path != "" {
+		breached, err := loadBreachedPasswords(path)
+		if err != nil {
+			return fmt.Errorf("failed to load breached password list: %w", err)
+		}
+		policy.breached = breached
+	}
+
+	passwordPolicy = policy
+	return nil
+}
+
+// CurrentPasswordPolicy mengembalikan policy yang sedang aktif
+func CurrentPasswordPolicy() *PasswordPolicy {
+	return passwordPolicy
+}
+
+// ValidatePassword mengecek password baru terhadap policy aktif.
+// previousHashes berisi hash password saat ini & riwayatnya (untuk cek reuse), boleh kosong.
+func ValidatePassword(password string, previousHashes []string) error {
+	p := passwordPolicy
+
+	if length := utf8.RuneCountInString(password); length < p.MinLength {
+		return &PasswordPolicyError{Reason: fmt.Sprintf("Password must be at least %d characters", p.MinLength)}
+	}
+	if len(password) > p.MaxLength {
+		return &PasswordPolicyError{Reason: fmt.Sprintf("Password must be at most %d bytes", p.MaxLength)}
+	}
+
+	if len(p.breached) > 0 {
+		if _, found := p.breached[sha1Hex(password)]; found {
+			return &PasswordPolicyError{Reason: "Password has appeared in a data breach, please choose another one"}
+		}
+	}
+
+	for i, hash := range previousHashes {
+		if i >= p.HistorySize {
+			break
+		}
+		if CheckPasswordHash(password, hash) {
+			return &PasswordPolicyError{Reason: fmt.Sprintf("Password cannot be the same as any of your last %d passwords", p.HistorySize)}
+		}
+	}
+	return nil
+}
+
+// loadBreachedPasswords membaca file daftar password bocor. Setiap baris boleh berupa password
+// biasa atau SHA-1 hex (format dump HIBP, bagian ":count" diabaikan).
+func loadBreachedPasswords(path string) (map[string]struct{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	breached := map[string]struct{}{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if hash, _, _ := strings.Cut(line, ":"); len(hash) == 40 && isHex(hash) {
+			breached[strings.ToUpper(hash)] = struct{}{}
+		} else {
+			breached[sha1Hex(line)] = struct{}{}
+		}
+	}
+	return breached, scanner.Err()
+}
+
+func sha1Hex(s string) string {
+	sum := sha1.Sum([]byte(s))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// usePolicy mengganti policy aktif selama satu test
+func usePolicy(t *testing.T, policy *PasswordPolicy) {
+	t.Helper()
+	previous := passwordPolicy
+	t.Cleanup(func() { passwordPolicy = previous })
+	passwordPolicy = policy
+}
+
+func TestLoadBreachedPasswords(t *testing.T) {
+	// SHA-1("password") & SHA-1("123456") dalam format dump HIBP "HASH:count"
+	content := strings.Join([]string{
+		"# daftar password bocor",
+		"5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824",
+		"7c4a8d09ca3762af61e59520943dc26494f8941b",
+		"",
+		"  qwerty123  ",
+		"not-a-hash:123",
+	}, "\n")
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	breached, err := loadBreachedPasswords(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, password := range []string{"password", "123456", "qwerty123", "not-a-hash:123"} {
+		if _, ok := breached[sha1Hex(password)]; !ok {
+			t.Errorf("expected %q to be listed as breached", password)
+		}
+	}
+	if _, ok := breached[sha1Hex("# daftar password bocor")]; ok {
+		t.Error("comment lines must be ignored")
+	}
+	if len(breached) != 4 {
+		t.Errorf("expected 4 entries, got %d", len(breached))
+	}
+
+	if _, err := loadBreachedPasswords(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("expected error for a missing file")
+	}
+}
+
+func TestValidatePassword(t *testing.T) {
+	useHasher(t, BcryptHasher{Cost: bcrypt.MinCost})
+	usePolicy(t, &PasswordPolicy{
+		MinLength:   8,
+		MaxLength:   72,
+		HistorySize: 2,
+		breached:    map[string]struct{}{sha1Hex("password123"): {}},
+	})
+
+	hash := func(password string) string {
+		h, err := HashPassword(password)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+	// Urutan: password saat ini lalu riwayat dari yang terbaru
+	previous := []string{hash("Current#2026"), hash("Previous#2025"), hash("Ancient#2024")}
+
+	tests := []struct {
+		name     string
+		password string
+		previous []string
+		wantErr  bool
+	}{
+		{"valid password", "Brand-New#2026", previous, false},
+		{"too short", "Ab#1", nil, true},
+		{"minimum length counts characters", "ééééééé", nil, true},
+		{"too long for bcrypt", strings.Repeat("a", 73), nil, true},
+		{"breached password", "password123", nil, true},
+		{"same as current password", "Current#2026", previous, true},
+		{"same as last history entry within limit", "Previous#2025", previous, true},
+		{"older than history limit is allowed", "Ancient#2024", previous, false},
+		{"no history given", "Current#2026", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePassword(tt.password, tt.previous)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidatePassword error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var policyErr *PasswordPolicyError
+			if err != nil && !errors.As(err, &policyErr) {
+				t.Errorf("expected PasswordPolicyError, got %T", err)
+			}
+		})
+	}
+}
+
+func TestValidatePasswordHistoryDisabled(t *testing.T) {
+	useHasher(t, BcryptHasher{Cost: bcrypt.MinCost})
+	usePolicy(t, &PasswordPolicy{MinLength: 8, MaxLength: 72, HistorySize: 0})
+
+	current, _ := HashPassword("Current#2026")
+	if err := ValidatePassword("Current#2026", []string{current}); err != nil {
+		t.Errorf("history check should be disabled, got %v", err)
+	}
+}
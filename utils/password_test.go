@@ -0,0 +1,134 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Parameter argon2id kecil agar test cepat
+var testArgon2 = Argon2idHasher{Memory: 8 * 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+// useHasher mengganti hasher aktif selama satu test
+func useHasher(t *testing.T, hasher PasswordHasher) {
+	t.Helper()
+	previous := passwordHasher
+	t.Cleanup(func() { passwordHasher = previous })
+	passwordHasher = hasher
+}
+
+func TestPasswordHashRoundTrip(t *testing.T) {
+	hashers := map[string]PasswordHasher{
+		"bcrypt":   BcryptHasher{Cost: bcrypt.MinCost},
+		"argon2id": testArgon2,
+	}
+
+	for name, hasher := range hashers {
+		t.Run(name, func(t *testing.T) {
+			useHasher(t, hasher)
+
+			hash, err := HashPassword("Rahasia#2026")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !hasher.Supports(hash) {
+				t.Fatalf("hasher does not recognise its own hash %q", hash)
+			}
+			if !CheckPasswordHash("Rahasia#2026", hash) {
+				t.Error("correct password rejected")
+			}
+			if CheckPasswordHash("rahasia#2026", hash) {
+				t.Error("wrong password accepted")
+			}
+			if PasswordNeedsRehash(hash) {
+				t.Error("fresh hash should not need rehash")
+			}
+
+			// Salt acak: hash yang sama tidak boleh dihasilkan dua kali
+			again, _ := HashPassword("Rahasia#2026")
+			if again == hash {
+				t.Error("expected a different salt for each hash")
+			}
+		})
+	}
+}
+
+func TestPasswordNeedsRehash(t *testing.T) {
+	bcrypt4, _ := BcryptHasher{Cost: bcrypt.MinCost}.Hash("password123")
+	bcrypt5, _ := BcryptHasher{Cost: bcrypt.MinCost + 1}.Hash("password123")
+	argon, _ := testArgon2.Hash("password123")
+
+	moreMemory := testArgon2
+	moreMemory.Memory *= 2
+	moreIterations := testArgon2
+	moreIterations.Iterations++
+	moreParallelism := testArgon2
+	moreParallelism.Parallelism++
+	longerKey := testArgon2
+	longerKey.KeyLength = 64
+
+	tests := []struct {
+		name   string
+		active PasswordHasher
+		hash   string
+		want   bool
+	}{
+		{"bcrypt same cost", BcryptHasher{Cost: bcrypt.MinCost}, bcrypt4, false},
+		{"bcrypt cost raised", BcryptHasher{Cost: bcrypt.MinCost}, bcrypt5, true},
+		{"bcrypt cost lowered", BcryptHasher{Cost: bcrypt.MinCost + 1}, bcrypt4, true},
+		{"bcrypt to argon2id", testArgon2, bcrypt4, true},
+		{"argon2id to bcrypt", BcryptHasher{Cost: bcrypt.MinCost}, argon, true},
+		{"argon2id same parameters", testArgon2, argon, false},
+		{"argon2id memory changed", moreMemory, argon, true},
+		{"argon2id iterations changed", moreIterations, argon, true},
+		{"argon2id parallelism changed", moreParallelism, argon, true},
+		{"argon2id key length changed", longerKey, argon, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useHasher(t, tt.active)
+			if got := PasswordNeedsRehash(tt.hash); got != tt.want {
+				t.Errorf("PasswordNeedsRehash = %v, want %v", got, tt.want)
+			}
+			// Hash lama tetap bisa dipakai login apa pun hasher aktifnya
+			if !CheckPasswordHash("password123", tt.hash) {
+				t.Error("old hash no longer verifies")
+			}
+		})
+	}
+}
+
+func TestParseArgon2Hash(t *testing.T) {
+	hash, _ := testArgon2.Hash("password123")
+
+	p, err := parseArgon2Hash(hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.memory != testArgon2.Memory || p.iterations != testArgon2.Iterations || p.parallelism != testArgon2.Parallelism ||
+		len(p.salt) != testArgon2.SaltLength || uint32(len(p.key)) != testArgon2.KeyLength {
+		t.Errorf("unexpected parameters %+v", p)
+	}
+
+	parts := strings.Split(hash, "$")
+	invalid := map[string]string{
+		"empty":            "",
+		"bcrypt":           "$2a$04$abcdefghijklmnopqrstuuABCDEFGHIJKLMNOPQRSTUVWXYZ01234",
+		"argon2i variant":  strings.Replace(hash, "$argon2id$", "$argon2i$", 1),
+		"wrong version":    strings.Replace(hash, "v=19", "v=16", 1),
+		"missing params":   strings.Join([]string{"", parts[1], parts[2], "m=1", parts[4], parts[5]}, "$"),
+		"invalid salt":     strings.Join([]string{"", parts[1], parts[2], parts[3], "!!!", parts[5]}, "$"),
+		"invalid key":      strings.Join([]string{"", parts[1], parts[2], parts[3], parts[4], "!!!"}, "$"),
+		"too few sections": strings.Join(parts[:5], "$"),
+	}
+	for name, value := range invalid {
+		if _, err := parseArgon2Hash(value); err == nil {
+			t.Errorf("%s: expected parse error", name)
+		}
+		if testArgon2.Verify("password123", value) {
+			t.Errorf("%s: malformed hash verified", name)
+		}
+	}
+}
@@ -0,0 +1,84 @@
+package utils
+
+import "strings"
+
+// Wildcard yang didukung pada permission milik role:
+//   - "*" atau "*:*"     : semua permission
+//   - "achievement:*"   : semua aksi pada resource achievement
+//   - "*:read"          : aksi read pada semua resource
+//
+// ImpliedActions: aksi yang otomatis dimiliki jika punya aksi lain (berlaku transitif),
+// misal "achievement:manage" memberi achievement:create/read/update/delete.
+var ImpliedActions = map[string][]string{
+	"manage": {"create", "read", "update", "delete"},
+}
+
+// PermissionKey menyusun nama permission standar "resource:action"
+func PermissionKey(resource, action string) string {
+	return resource + ":" + action
+}
+
+// HasPermission mengecek apakah salah satu permission yang dimiliki memenuhi permission yang dibutuhkan
+func HasPermission(granted []string, required string) bool {
+	reqResource, reqAction := splitPermission(required)
+
+	for _, g := range granted {
+		if g == required || g == "*" {
+			return true
+		}
+
+		resource, action := splitPermission(g)
+		if resource != "*" && resource != reqResource {
+			continue
+		}
+		if action == "*" || actionImplies(action, reqAction, map[string]bool{}) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAnyPermission: minimal satu dari permission yang dibutuhkan dimiliki
+func HasAnyPermission(granted []string, required ...string) bool {
+	for _, r := range required {
+		if HasPermission(granted, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAllPermissions: semua permission yang dibutuhkan dimiliki
+func HasAllPermissions(granted []string, required ...string) bool {
+	for _, r := range required {
+		if !HasPermission(granted, r) {
+			return false
+		}
+	}
+	return true
+}
+
+func splitPermission(permission string) (string, string) {
+	resource, action, found := strings.Cut(permission, ":")
+	if !found {
+		return permission, ""
+	}
+	return resource, action
+}
+
+func actionImplies(granted, required string, visited map[string]bool) bool {
+	if granted == required {
+		return true
+	}
+	if visited[granted] {
+		return false
+	}
+	visited[granted] = true
+
+	for _, implied := range ImpliedActions[granted] {
+		if actionImplies(implied, required, visited) {
+			return true
+		}
+	}
+	return false
+}
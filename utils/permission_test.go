@@ -0,0 +1,81 @@
+package utils
+
+import "testing"
+
+func TestHasPermission(t *testing.T) {
+	tests := []struct {
+		name     string
+		granted  []string
+		required string
+		want     bool
+	}{
+		{"exact match", []string{"user:manage"}, "user:manage", true},
+		{"no permissions", nil, "user:manage", false},
+		{"global wildcard", []string{"*"}, "achievement:verify", true},
+		{"global resource and action wildcard", []string{"*:*"}, "achievement:verify", true},
+		{"resource wildcard", []string{"achievement:*"}, "achievement:delete", true},
+		{"resource wildcard on other resource", []string{"achievement:*"}, "user:manage", false},
+		{"action wildcard across resources", []string{"*:read"}, "report:read", true},
+		{"action wildcard does not grant other actions", []string{"*:read"}, "report:update", false},
+		{"manage implies create", []string{"achievement:manage"}, "achievement:create", true},
+		{"manage implies read", []string{"achievement:manage"}, "achievement:read", true},
+		{"manage implies update", []string{"achievement:manage"}, "achievement:update", true},
+		{"manage implies delete", []string{"achievement:manage"}, "achievement:delete", true},
+		{"manage does not imply verify", []string{"achievement:manage"}, "achievement:verify", false},
+		{"manage on other resource", []string{"user:manage"}, "achievement:create", false},
+		{"wildcard resource with manage", []string{"*:manage"}, "report:delete", true},
+		{"implied action does not reverse", []string{"achievement:read"}, "achievement:manage", false},
+		{"empty action is not manage", []string{"user:"}, "user:manage", false},
+		{"resource without action", []string{"user"}, "user:manage", false},
+		{"resource prefix is not a match", []string{"users:manage"}, "user:manage", false},
+		{"wrong resource same action", []string{"role:manage"}, "user:manage", false},
+		{"one of several granted", []string{"achievement:read", "user:manage"}, "user:manage", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasPermission(tt.granted, tt.required); got != tt.want {
+				t.Errorf("HasPermission(%v, %q) = %v, want %v", tt.granted, tt.required, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasPermissionImpliedActionCycle(t *testing.T) {
+	original := ImpliedActions
+	t.Cleanup(func() { ImpliedActions = original })
+
+	// Konfigurasi siklik tidak boleh membuat rekursi tanpa akhir
+	ImpliedActions = map[string][]string{
+		"manage":  {"review"},
+		"review":  {"approve", "manage"},
+		"approve": {"review", "read"},
+	}
+
+	if !HasPermission([]string{"achievement:manage"}, "achievement:read") {
+		t.Error("expected manage to imply read through the cycle")
+	}
+	if HasPermission([]string{"achievement:manage"}, "achievement:delete") {
+		t.Error("expected manage not to imply delete")
+	}
+	if !HasPermission([]string{"achievement:approve"}, "achievement:manage") {
+		t.Error("expected approve to imply manage through the cycle")
+	}
+}
+
+func TestHasAnyAndAllPermissions(t *testing.T) {
+	granted := []string{"achievement:manage", "report:read"}
+
+	if !HasAnyPermission(granted, "user:manage", "report:read") {
+		t.Error("expected HasAnyPermission to match report:read")
+	}
+	if HasAnyPermission(granted, "user:manage", "role:read") {
+		t.Error("expected HasAnyPermission to match nothing")
+	}
+	if !HasAllPermissions(granted, "achievement:create", "report:read") {
+		t.Error("expected HasAllPermissions to match both")
+	}
+	if HasAllPermissions(granted, "achievement:create", "user:manage") {
+		t.Error("expected HasAllPermissions to fail on user:manage")
+	}
+}
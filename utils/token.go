@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// Prefix personal access token, dipakai AuthMiddleware untuk membedakan API token dari JWT
+const APITokenPrefix = "uas_pat_"
+
+// GenerateAPIToken membuat personal access token baru (prefix + token acak)
+func GenerateAPIToken() (string, error) {
+	raw, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	return APITokenPrefix + raw, nil
+}
+
+// GenerateOpaqueToken membuat token acak (URL safe) untuk refresh token, reset token, dll.
+func GenerateOpaqueToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// GenerateUUID membuat UUID v4 acak (dipakai untuk ID yang dibuat di sisi aplikasi)
+func GenerateUUID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // versi 4
+	b[8] = (b[8] & 0x3f) | 0x80 // varian RFC 4122
+
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:32], nil
+}
+
+// HashToken menghasilkan SHA-256 (hex) dari token, yang disimpan di database hanya hash-nya
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// Huruf & angka tanpa karakter yang mirip (0/O, 1/l/I) agar password awal mudah diketik
+const readablePasswordAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz23456789"
+
+// GenerateReadablePassword membuat password acak sepanjang length (mis. password awal hasil import)
+func GenerateReadablePassword(length int) (string, error) {
+	b := make([]byte, length)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	// 256 bukan kelipatan panjang alphabet, buang byte di atas batas agar distribusinya rata
+	limit := byte(256 - 256%len(readablePasswordAlphabet))
+	out := make([]byte, 0, length)
+	for len(out) < length {
+		for _, v := range b {
+			if v < limit && len(out) < length {
+				out = append(out, readablePasswordAlphabet[int(v)%len(readablePasswordAlphabet)])
+			}
+		}
+		if _, err := rand.Read(b); err != nil {
+			return "", err
+		}
+	}
+	return string(out), nil
+}
@@ -0,0 +1,114 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+// Secret RFC 6238 Appendix B untuk SHA-1 ("12345678901234567890" dalam base32)
+var rfc6238Secret = base32NoPad.EncodeToString([]byte("12345678901234567890"))
+
+func TestTOTPCodeRFC6238Vectors(t *testing.T) {
+	// Vektor uji RFC 6238 (8 digit), kita memakai 6 digit terakhir
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},          // 94287082
+		{1111111109, "081804"},  // 07081804
+		{1111111111, "050471"},  // 14050471
+		{1234567890, "005924"},  // 89005924
+		{2000000000, "279037"},  // 69279037
+		{20000000000, "353130"}, // 65353130
+	}
+
+	key := []byte("12345678901234567890")
+	for _, tt := range tests {
+		if got := totpCode(key, tt.unix/totpPeriod); got != tt.code {
+			t.Errorf("totpCode at %d = %s, want %s", tt.unix, got, tt.code)
+		}
+
+		step, ok := ValidateTOTP(rfc6238Secret, tt.code, time.Unix(tt.unix, 0), 0)
+		if !ok || step != tt.unix/totpPeriod {
+			t.Errorf("ValidateTOTP at %d = (%d, %v), want (%d, true)", tt.unix, step, ok, tt.unix/totpPeriod)
+		}
+	}
+}
+
+func TestValidateTOTPWindow(t *testing.T) {
+	key := []byte("12345678901234567890")
+	now := time.Unix(1234567890, 0)
+	current := now.Unix() / totpPeriod
+
+	tests := []struct {
+		name     string
+		step     int64
+		lastStep int64
+		wantOK   bool
+	}{
+		{"current step", current, 0, true},
+		{"previous step within skew", current - 1, 0, true},
+		{"next step within skew", current + 1, 0, true},
+		{"two steps behind", current - 2, 0, false},
+		{"two steps ahead", current + 2, 0, false},
+		{"replay of last used step", current, current, false},
+		{"older than last used step", current - 1, current, false},
+		{"newer than last used step", current + 1, current, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := ValidateTOTP(rfc6238Secret, totpCode(key, tt.step), now, tt.lastStep)
+			if ok != tt.wantOK {
+				t.Fatalf("ValidateTOTP ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && step != tt.step {
+				t.Errorf("ValidateTOTP step = %d, want %d", step, tt.step)
+			}
+		})
+	}
+}
+
+func TestValidateTOTPMalformedInput(t *testing.T) {
+	now := time.Unix(59, 0)
+
+	tests := []struct {
+		name   string
+		secret string
+		code   string
+		wantOK bool
+	}{
+		{"surrounding spaces are trimmed", rfc6238Secret, " 287082 ", true},
+		{"lowercase secret", "gezdgnbvgy3tqojqgezdgnbvgy3tqojq", "287082", true},
+		{"empty code", rfc6238Secret, "", false},
+		{"five digits", rfc6238Secret, "87082", false},
+		{"seven digits", rfc6238Secret, "4287082", false},
+		{"eight digit RFC code", rfc6238Secret, "94287082", false},
+		{"letters", rfc6238Secret, "28708a", false},
+		{"invalid secret", "not base32!", "287082", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, ok := ValidateTOTP(tt.secret, tt.code, now, 0); ok != tt.wantOK {
+				t.Errorf("ValidateTOTP(%q) ok = %v, want %v", tt.code, ok, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestNormalizeRecoveryCode(t *testing.T) {
+	codes, err := GenerateRecoveryCodes(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, code := range codes {
+		if len(code) != 9 || code[4] != '-' {
+			t.Errorf("unexpected recovery code format %q", code)
+		}
+	}
+
+	if got := NormalizeRecoveryCode(" abcd-ef12 "); got != "ABCDEF12" {
+		t.Errorf("NormalizeRecoveryCode = %q, want ABCDEF12", got)
+	}
+}